@@ -11,13 +11,25 @@ if [ "$1" = "push" ]; then
   docker build --pull --push --file nop.Dockerfile --tag micahparks/magiclinksdevnop .
   docker build --pull --push --file ses.Dockerfile --tag micahparks/magiclinksdevses .
   docker build --pull --push --file sendgrid.Dockerfile --tag micahparks/magiclinksdevsendgrid .
+  docker build --pull --push --file smtp.Dockerfile --tag micahparks/magiclinksdevsmtp .
+  docker build --pull --push --file postmark.Dockerfile --tag micahparks/magiclinksdevpostmark .
+  docker build --pull --push --file mailgun.Dockerfile --tag micahparks/magiclinksdevmailgun .
+  docker build --pull --push --file resend.Dockerfile --tag micahparks/magiclinksdevresend .
   docker build --pull --push --file multi.Dockerfile --tag "micahparks/magiclinksdevmulti:$TAG" .
   docker build --pull --push --file nop.Dockerfile --tag "micahparks/magiclinksdevnop:$TAG" .
   docker build --pull --push --file ses.Dockerfile --tag "micahparks/magiclinksdevses:$TAG" .
   docker build --pull --push --file sendgrid.Dockerfile --tag "micahparks/magiclinksdevsendgrid:$TAG" .
+  docker build --pull --push --file smtp.Dockerfile --tag "micahparks/magiclinksdevsmtp:$TAG" .
+  docker build --pull --push --file postmark.Dockerfile --tag "micahparks/magiclinksdevpostmark:$TAG" .
+  docker build --pull --push --file mailgun.Dockerfile --tag "micahparks/magiclinksdevmailgun:$TAG" .
+  docker build --pull --push --file resend.Dockerfile --tag "micahparks/magiclinksdevresend:$TAG" .
 else
   docker build --pull --file multi.Dockerfile --tag micahparks/magiclinksdevmulti .
   docker build --pull --file nop.Dockerfile --tag micahparks/magiclinksdevnop .
   docker build --pull --file ses.Dockerfile --tag micahparks/magiclinksdevses .
   docker build --pull --file sendgrid.Dockerfile --tag micahparks/magiclinksdevsendgrid .
+  docker build --pull --file smtp.Dockerfile --tag micahparks/magiclinksdevsmtp .
+  docker build --pull --file postmark.Dockerfile --tag micahparks/magiclinksdevpostmark .
+  docker build --pull --file mailgun.Dockerfile --tag micahparks/magiclinksdevmailgun .
+  docker build --pull --file resend.Dockerfile --tag micahparks/magiclinksdevresend .
 fi
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 const (
@@ -48,6 +50,44 @@ func (s SigningBytesClaims) GetAudience() (jwt.ClaimStrings, error) {
 	return aud, nil
 }
 
+// Renew returns a copy of the claims for a JWT signed at the given time. The "iat" and "nbf" claims are set to the
+// given time, the "exp" claim is moved forward to keep the same lifespan, and the "jti" claim is regenerated. Claims
+// that are not present are not added.
+func (s SigningBytesClaims) Renew(now time.Time) (jwt.Claims, error) {
+	renewed := make(json.RawMessage, len(s.Claims))
+	copy(renewed, s.Claims)
+
+	var err error
+	iat := gjson.GetBytes(renewed, AttrIat)
+	exp := gjson.GetBytes(renewed, AttrExp)
+	if iat.Exists() && exp.Exists() {
+		renewed, err = sjson.SetBytes(renewed, AttrExp, now.Unix()+exp.Int()-iat.Int())
+		if err != nil {
+			return nil, fmt.Errorf("failed to set exp claim: %w", err)
+		}
+	}
+	for _, attr := range []string{AttrIat, AttrNbf} {
+		if gjson.GetBytes(renewed, attr).Exists() {
+			renewed, err = sjson.SetBytes(renewed, attr, now.Unix())
+			if err != nil {
+				return nil, fmt.Errorf("failed to set %s claim: %w", attr, err)
+			}
+		}
+	}
+	if gjson.GetBytes(renewed, AttrJti).Exists() {
+		u, err := uuid.NewRandom()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate UUID: %w", err)
+		}
+		renewed, err = sjson.SetBytes(renewed, AttrJti, u.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to set jti claim: %w", err)
+		}
+	}
+
+	return SigningBytesClaims{Claims: renewed}, nil
+}
+
 // MarshalJSON helps implement the json.Marshaler interface.
 func (s SigningBytesClaims) MarshalJSON() ([]byte, error) {
 	return s.Claims, nil
@@ -174,6 +174,42 @@ func (c Client) MagicLinkEmailCreate(ctx context.Context, req model.MagicLinkEma
 	return resp, errResp, nil
 }
 
+// MagicLinkOTPEmailCreate calls the /magic-link-otp-email/create endpoint and returns the appropriate response.
+func (c Client) MagicLinkOTPEmailCreate(ctx context.Context, req model.MagicLinkOTPEmailCreateRequest) (model.MagicLinkOTPEmailCreateResponse, model.Error, error) {
+	resp, errResp, err := request[model.MagicLinkOTPEmailCreateRequest, model.MagicLinkOTPEmailCreateResponse](ctx, c, http.StatusCreated, network.PathMagicLinkOTPEmailCreate, req)
+	if err != nil {
+		return model.MagicLinkOTPEmailCreateResponse{}, errResp, fmt.Errorf("failed to create magic link and OTP email: %w", err)
+	}
+	return resp, errResp, nil
+}
+
+// MagicLinkExchange calls the /magic-link/exchange endpoint and returns the appropriate response.
+func (c Client) MagicLinkExchange(ctx context.Context, req model.MagicLinkExchangeRequest) (model.MagicLinkExchangeResponse, model.Error, error) {
+	resp, errResp, err := request[model.MagicLinkExchangeRequest, model.MagicLinkExchangeResponse](ctx, c, http.StatusOK, network.PathMagicLinkExchange, req)
+	if err != nil {
+		return model.MagicLinkExchangeResponse{}, errResp, fmt.Errorf("failed to exchange link code: %w", err)
+	}
+	return resp, errResp, nil
+}
+
+// MagicLinkRevoke calls the /magic-link/revoke endpoint and returns the appropriate response.
+func (c Client) MagicLinkRevoke(ctx context.Context, req model.MagicLinkRevokeRequest) (model.MagicLinkRevokeResponse, model.Error, error) {
+	resp, errResp, err := request[model.MagicLinkRevokeRequest, model.MagicLinkRevokeResponse](ctx, c, http.StatusOK, network.PathMagicLinkRevoke, req)
+	if err != nil {
+		return model.MagicLinkRevokeResponse{}, errResp, fmt.Errorf("failed to revoke link: %w", err)
+	}
+	return resp, errResp, nil
+}
+
+// MagicLinkStatus calls the /magic-link/status endpoint and returns the appropriate response.
+func (c Client) MagicLinkStatus(ctx context.Context, req model.MagicLinkStatusRequest) (model.MagicLinkStatusResponse, model.Error, error) {
+	resp, errResp, err := request[model.MagicLinkStatusRequest, model.MagicLinkStatusResponse](ctx, c, http.StatusOK, network.PathMagicLinkStatus, req)
+	if err != nil {
+		return model.MagicLinkStatusResponse{}, errResp, fmt.Errorf("failed to read link status: %w", err)
+	}
+	return resp, errResp, nil
+}
+
 // OTPCreate calls the /otp/create endpoint and returns the appropriate response.
 func (c Client) OTPCreate(ctx context.Context, req model.OTPCreateRequest) (model.OTPCreateResponse, model.Error, error) {
 	resp, errResp, err := request[model.OTPCreateRequest, model.OTPCreateResponse](ctx, c, http.StatusCreated, network.PathOTPCreate, req)
@@ -201,6 +237,33 @@ func (c Client) OTPEmailCreate(ctx context.Context, req model.OTPEmailCreateRequ
 	return resp, errResp, nil
 }
 
+// OTPResend calls the /otp/resend endpoint and returns the appropriate response.
+func (c Client) OTPResend(ctx context.Context, req model.OTPResendRequest) (model.OTPResendResponse, model.Error, error) {
+	resp, errResp, err := request[model.OTPResendRequest, model.OTPResendResponse](ctx, c, http.StatusOK, network.PathOTPResend, req)
+	if err != nil {
+		return model.OTPResendResponse{}, errResp, fmt.Errorf("failed to resend OTP: %w", err)
+	}
+	return resp, errResp, nil
+}
+
+// TOTPCreate calls the /totp/create endpoint and returns the appropriate response.
+func (c Client) TOTPCreate(ctx context.Context, req model.TOTPCreateRequest) (model.TOTPCreateResponse, model.Error, error) {
+	resp, errResp, err := request[model.TOTPCreateRequest, model.TOTPCreateResponse](ctx, c, http.StatusCreated, network.PathTOTPCreate, req)
+	if err != nil {
+		return model.TOTPCreateResponse{}, errResp, fmt.Errorf("failed to create TOTP: %w", err)
+	}
+	return resp, errResp, nil
+}
+
+// TOTPValidate calls the /totp/validate endpoint and returns the appropriate response.
+func (c Client) TOTPValidate(ctx context.Context, req model.TOTPValidateRequest) (model.TOTPValidateResponse, model.Error, error) {
+	resp, errResp, err := request[model.TOTPValidateRequest, model.TOTPValidateResponse](ctx, c, http.StatusOK, network.PathTOTPValidate, req)
+	if err != nil {
+		return model.TOTPValidateResponse{}, errResp, fmt.Errorf("failed to validate TOTP: %w", err)
+	}
+	return resp, errResp, nil
+}
+
 // Ready calls the /ready endpoint. An error is returned if the service is not ready to accept requests.
 func (c Client) Ready(ctx context.Context) error {
 	u, err := c.baseURL.Parse(network.PathReady)
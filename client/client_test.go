@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"crypto/ed25519"
 	"encoding/json"
@@ -129,6 +130,49 @@ func TestEmailLinkCreate(t *testing.T) {
 	validateLinkResults(t, resp.MagicLinkEmailCreateResults.MagicLinkCreateResults)
 }
 
+func TestEmailLinkOTPCreate(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	req := model.MagicLinkOTPEmailCreateRequest{
+		MagicLinkEmailCreateParams: model.MagicLinkEmailCreateParams{
+			ButtonText:   "Test button text",
+			Greeting:     "Test greeting",
+			LogoClickURL: mldtest.LogoClickURL,
+			LogoImageURL: mldtest.LogoImageURL,
+			ServiceName:  mldtest.ServiceName,
+			Subject:      "Test subject",
+			SubTitle:     "Test subtitle",
+			Title:        "Test title",
+			ToEmail:      "customer@example.com",
+			ToName:       "Test name",
+		},
+		MagicLinkCreateParams: linkParams,
+		OTPCreateParams: model.OTPCreateParams{
+			CharSetNumeric: true,
+		},
+	}
+	resp, mldErr, err := c.MagicLinkOTPEmailCreate(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to create magic link and OTP email: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to create magic link and OTP email: %v.", mldErr)
+	}
+
+	validateMetadata(t, resp.RequestMetadata)
+	validateLinkResults(t, resp.MagicLinkOTPEmailCreateResults.MagicLinkCreateResults)
+
+	req.OTPCreateParams = model.OTPCreateParams{}
+	_, mldErr, err = c.MagicLinkOTPEmailCreate(ctx, req)
+	if err == nil {
+		t.Fatalf("Creating a magic link and OTP email without an OTP character set should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Missing OTP character set should have 400 status: %#v.", mldErr)
+	}
+}
+
 func TestJWTCreate(t *testing.T) {
 	ctx := createCtx(t)
 	c := newClient(ctx, t)
@@ -252,12 +296,255 @@ func TestLinkCreate(t *testing.T) {
 	validateLinkResults(t, resp.MagicLinkCreateResults)
 }
 
+func TestLinkCreateCodeChallenge(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	params := linkParams
+	params.CodeChallenge = "not a code challenge"
+	req := model.MagicLinkCreateRequest{
+		MagicLinkCreateParams: params,
+	}
+	_, mldErr, err := c.MagicLinkCreate(ctx, req)
+	if err == nil {
+		t.Fatalf("Creating a magic link with an invalid code challenge should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Creating a magic link with an invalid code challenge should have 400 status: %#v.", mldErr)
+	}
+
+	req.MagicLinkCreateParams.CodeChallenge = magiclink.CodeChallengeS256(strings.Repeat("a", magiclink.CodeVerifierMinLength))
+	_, mldErr, err = c.MagicLinkCreate(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to create magic link with a code challenge: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to create magic link with a code challenge. API error: %#v.", mldErr)
+	}
+}
+
+func TestLinkCreateCrossDevice(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	params := linkParams
+	params.CrossDevice = true
+	req := model.MagicLinkCreateRequest{
+		MagicLinkCreateParams: params,
+	}
+	resp, mldErr, err := c.MagicLinkCreate(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to create cross-device magic link: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to create cross-device magic link. API error: %#v.", mldErr)
+	}
+
+	validateLinkResults(t, resp.MagicLinkCreateResults)
+	if resp.MagicLinkCreateResults.CrossDeviceToken == "" {
+		t.Fatalf("Missing cross-device token.")
+	}
+	if resp.MagicLinkCreateResults.QRCodeToken != "" {
+		t.Fatalf("QR code token should only be present for magic links created with a QR code.")
+	}
+}
+
+func TestLinkCreateQRCode(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	params := linkParams
+	params.QRCode = true
+	req := model.MagicLinkCreateRequest{
+		MagicLinkCreateParams: params,
+	}
+	resp, mldErr, err := c.MagicLinkCreate(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to create magic link with QR code: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to create magic link with QR code. API error: %#v.", mldErr)
+	}
+
+	validateLinkResults(t, resp.MagicLinkCreateResults)
+	if !bytes.HasPrefix(resp.MagicLinkCreateResults.QRCodePNG, []byte("\x89PNG")) {
+		t.Fatalf("QR code PNG is not a PNG image.")
+	}
+	if !strings.HasPrefix(resp.MagicLinkCreateResults.QRCodeSVG, "<svg") {
+		t.Fatalf("QR code SVG is not an SVG image: %q.", resp.MagicLinkCreateResults.QRCodeSVG)
+	}
+	if resp.MagicLinkCreateResults.QRCodeToken == "" {
+		t.Fatalf("Missing QR code token.")
+	}
+}
+
+func TestLinkCreateRedirectAllowlist(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	params := linkParams
+	params.RedirectURL = "myapp://auth"
+	req := model.MagicLinkCreateRequest{
+		MagicLinkCreateParams: params,
+	}
+	_, mldErr, err := c.MagicLinkCreate(ctx, req)
+	if err == nil {
+		t.Fatalf("Creating a magic link with a redirect URL not in the redirect allowlist should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Redirect URL not in the redirect allowlist should have 400 status: %#v.", mldErr)
+	}
+}
+
+func TestLinkCreateState(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	params := linkParams
+	params.State = "/return/to"
+	params.StateQueryKey = magiclink.DefaultRedirectQueryKey
+	req := model.MagicLinkCreateRequest{
+		MagicLinkCreateParams: params,
+	}
+	_, mldErr, err := c.MagicLinkCreate(ctx, req)
+	if err == nil {
+		t.Fatalf("Creating a magic link with the same state query key and redirect query key should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Same state query key and redirect query key should have 400 status: %#v.", mldErr)
+	}
+
+	req.MagicLinkCreateParams.State = strings.Repeat("a", 1025)
+	req.MagicLinkCreateParams.StateQueryKey = ""
+	_, mldErr, err = c.MagicLinkCreate(ctx, req)
+	if err == nil {
+		t.Fatalf("Creating a magic link with a state that is too long should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("State that is too long should have 400 status: %#v.", mldErr)
+	}
+
+	req.MagicLinkCreateParams.State = "/return/to"
+	_, mldErr, err = c.MagicLinkCreate(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to create magic link with state: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to create magic link with state. API error: %#v.", mldErr)
+	}
+}
+
+func TestLinkExchange(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	req := model.MagicLinkExchangeRequest{
+		MagicLinkExchangeParams: model.MagicLinkExchangeParams{
+			Code:         uuid.New().String(),
+			CodeVerifier: strings.Repeat("a", magiclink.CodeVerifierMinLength),
+		},
+	}
+	_, mldErr, err := c.MagicLinkExchange(ctx, req)
+	if err == nil {
+		t.Fatalf("Exchanging a code for a magic link without a code challenge should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Exchanging a code for a magic link without a code challenge should have 400 status: %#v.", mldErr)
+	}
+
+	req.MagicLinkExchangeParams.CodeVerifier = "short"
+	_, mldErr, err = c.MagicLinkExchange(ctx, req)
+	if err == nil {
+		t.Fatalf("Exchanging a code with a short code verifier should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Exchanging a code with a short code verifier should have 400 status: %#v.", mldErr)
+	}
+
+	req.MagicLinkExchangeParams.ClientIP = "not an IP"
+	req.MagicLinkExchangeParams.CodeVerifier = strings.Repeat("a", magiclink.CodeVerifierMinLength)
+	_, mldErr, err = c.MagicLinkExchange(ctx, req)
+	if err == nil {
+		t.Fatalf("Exchanging a code with an invalid client IP should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Exchanging a code with an invalid client IP should have 400 status: %#v.", mldErr)
+	}
+}
+
+func TestLinkRevoke(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	req := model.MagicLinkRevokeRequest{
+		MagicLinkRevokeParams: model.MagicLinkRevokeParams{
+			ID: uuid.New().String(),
+		},
+	}
+	resp, mldErr, err := c.MagicLinkRevoke(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to revoke magic link: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to revoke magic link. API error: %#v.", mldErr)
+	}
+
+	validateMetadata(t, resp.RequestMetadata)
+
+	req.MagicLinkRevokeParams.Secret = uuid.New().String()
+	_, mldErr, err = c.MagicLinkRevoke(ctx, req)
+	if err == nil {
+		t.Fatalf("Revoking with both an ID and secret should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Revoking with both an ID and secret should have 400 status: %#v.", mldErr)
+	}
+}
+
+func TestLinkStatus(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	id := uuid.New().String()
+	req := model.MagicLinkStatusRequest{
+		MagicLinkStatusParams: model.MagicLinkStatusParams{
+			ID: id,
+		},
+	}
+	resp, mldErr, err := c.MagicLinkStatus(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to read magic link status: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to read magic link status. API error: %#v.", mldErr)
+	}
+
+	validateMetadata(t, resp.RequestMetadata)
+
+	if resp.MagicLinkStatusResults.ID != id {
+		t.Fatalf("Magic link status ID does not match: %q.", resp.MagicLinkStatusResults.ID)
+	}
+
+	req.MagicLinkStatusParams.ID = "not-a-uuid"
+	_, mldErr, err = c.MagicLinkStatus(ctx, req)
+	if err == nil {
+		t.Fatalf("Reading the status of an invalid ID should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Reading the status of an invalid ID should have 400 status: %#v.", mldErr)
+	}
+}
+
 func TestServiceAccountCreate(t *testing.T) {
 	ctx := createCtx(t)
 	c := newClient(ctx, t)
 
 	req := model.ServiceAccountCreateRequest{
-		ServiceAccountCreateParams: model.ServiceAccountCreateParams{},
+		ServiceAccountCreateParams: model.ServiceAccountCreateParams{
+			RedirectAllowlist: []model.RedirectAllowlistEntry{
+				{Scheme: "MyApp", Host: "auth"},
+			},
+		},
 	}
 	resp, mldErr, err := c.ServiceAccountCreate(ctx, req)
 	if err != nil {
@@ -281,6 +568,117 @@ func TestServiceAccountCreate(t *testing.T) {
 	if resp.ServiceAccountCreateResults.ServiceAccount.APIKey == uuid.Nil {
 		t.Fatalf("Created service account should have non-nil API key.")
 	}
+	allowlist := resp.ServiceAccountCreateResults.ServiceAccount.RedirectAllowlist
+	if len(allowlist) != 1 || allowlist[0].Scheme != "myapp" || allowlist[0].Host != "auth" {
+		t.Fatalf("Created service account should have normalized redirect allowlist: %#v.", allowlist)
+	}
+
+	req.ServiceAccountCreateParams.RedirectAllowlist = []model.RedirectAllowlistEntry{
+		{Scheme: "javascript"},
+	}
+	_, mldErr, err = c.ServiceAccountCreate(ctx, req)
+	if err == nil {
+		t.Fatalf("Creating a service account with a dangerous redirect allowlist scheme should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Dangerous redirect allowlist scheme should have 400 status: %#v.", mldErr)
+	}
+}
+
+func TestOTPResend(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	req := model.OTPResendRequest{
+		OTPResendParams: model.OTPResendParams{
+			ID: uuid.New().String(),
+		},
+	}
+	_, mldErr, err := c.OTPResend(ctx, req)
+	if err == nil {
+		t.Fatalf("Resending an unknown OTP should fail.")
+	}
+	if mldErr.Code != http.StatusNotFound {
+		t.Fatalf("Unknown OTP should have 404 status: %#v.", mldErr)
+	}
+
+	req.OTPResendParams.ID = "not-a-uuid"
+	_, mldErr, err = c.OTPResend(ctx, req)
+	if err == nil {
+		t.Fatalf("Resending an OTP with an invalid ID should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Invalid OTP ID should have 400 status: %#v.", mldErr)
+	}
+}
+
+func TestTOTPCreate(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	req := model.TOTPCreateRequest{
+		TOTPCreateParams: model.TOTPCreateParams{
+			AccountName: "customer@example.com",
+			Issuer:      mldtest.ServiceName,
+		},
+	}
+	resp, mldErr, err := c.TOTPCreate(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to create TOTP: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to create TOTP: %v.", mldErr)
+	}
+
+	validateMetadata(t, resp.RequestMetadata)
+	u, err := url.Parse(resp.TOTPCreateResults.URI)
+	if err != nil {
+		t.Fatalf("Failed to parse TOTP URI: %v.", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		t.Fatalf("TOTP URI should be an otpauth://totp URI: %s.", u)
+	}
+	if !bytes.HasPrefix(resp.TOTPCreateResults.QRCodePNG, []byte("\x89PNG")) {
+		t.Fatalf("TOTP QR code should be a PNG image.")
+	}
+
+	req.TOTPCreateParams.Issuer = "magic:links"
+	_, mldErr, err = c.TOTPCreate(ctx, req)
+	if err == nil {
+		t.Fatalf("Creating a TOTP with a colon in the issuer should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Colon in the issuer should have 400 status: %#v.", mldErr)
+	}
+}
+
+func TestTOTPValidate(t *testing.T) {
+	ctx := createCtx(t)
+	c := newClient(ctx, t)
+
+	req := model.TOTPValidateRequest{
+		TOTPValidateParams: model.TOTPValidateParams{
+			Code: "123456",
+			ID:   uuid.New().String(),
+		},
+	}
+	resp, mldErr, err := c.TOTPValidate(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to validate TOTP: %v.", err)
+	}
+	if mldErr.Code != 0 {
+		t.Fatalf("Failed to validate TOTP: %v.", mldErr)
+	}
+	validateMetadata(t, resp.RequestMetadata)
+
+	req.TOTPValidateParams.RecoveryCode = "abcde12345"
+	_, mldErr, err = c.TOTPValidate(ctx, req)
+	if err == nil {
+		t.Fatalf("Validating a TOTP with both a code and a recovery code should fail.")
+	}
+	if mldErr.Code != http.StatusBadRequest {
+		t.Fatalf("Both a code and a recovery code should have 400 status: %#v.", mldErr)
+	}
 }
 
 func createCtx(t *testing.T) context.Context {
@@ -422,6 +820,11 @@ func validateLinkResults(t *testing.T, results model.MagicLinkCreateResults) {
 		t.Fatalf("Magic link should have base URL prefix.")
 	}
 
+	_, err = uuid.Parse(results.ID)
+	if err != nil {
+		t.Fatalf("Failed to parse magic link ID as UUID: %v.", err)
+	}
+
 	secret := ml.Query().Get(magiclink.DefaultSecretQueryKey)
 	u, err := uuid.Parse(secret)
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 	mld "github.com/MicahParks/magiclinksdev"
 	"github.com/MicahParks/magiclinksdev/magiclink"
 	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/rlimit"
 )
 
 const (
@@ -26,17 +27,24 @@ type LogLevel string
 type Config struct {
 	AdminCreateParams   []model.AdminCreateParams   `json:"adminCreateParams"`
 	BaseURL             *jt.JSONType[*url.URL]      `json:"baseURL"`
+	ClientBinding       magiclink.BindingConfig     `json:"clientBinding"`
+	ErrorPage           magiclink.ErrorPageBranding `json:"errorPage"`
 	Iss                 string                      `json:"iss"`
 	JWKS                JWKS                        `json:"jwks"`
+	LinkFormat          magiclink.LinkFormat        `json:"linkFormat"`
 	LogJSON             bool                        `json:"logJSON"`
 	LogLevel            LogLevel                    `json:"logLevel"`
+	MagicLinkResend     MagicLinkResend             `json:"magicLinkResend"`
+	OTPResend           OTPResend                   `json:"otpResend"`
 	Port                uint16                      `json:"port"`
 	PreventRobots       PreventRobots               `json:"preventRobots"`
 	RelativeRedirectURL *jt.JSONType[*url.URL]      `json:"relativeRedirectURL"`
 	RequestTimeout      *jt.JSONType[time.Duration] `json:"requestTimeout"`
 	RequestMaxBodyBytes int64                       `json:"requestMaxBodyBytes"`
 	SecretQueryKey      string                      `json:"secretQueryKey"`
+	ShortCodes          bool                        `json:"shortCodes"`
 	ShutdownTimeout     *jt.JSONType[time.Duration] `json:"shutdownTimeout"`
+	TOTP                TOTP                        `json:"totp"`
 	Validation          model.Validation            `json:"validation"`
 }
 
@@ -70,6 +78,10 @@ func (c Config) DefaultsAndValidate() (Config, error) {
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to parse base relative URL path for magic links: %w", err)
 	}
+	c.ClientBinding, err = c.ClientBinding.DefaultsAndValidate()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to validate and apply defaults for client binding: %w", err)
+	}
 	if c.Iss == "" {
 		return Config{}, fmt.Errorf("iss is required: %w", jt.ErrDefaultsAndValidate)
 	}
@@ -80,6 +92,13 @@ func (c Config) DefaultsAndValidate() (Config, error) {
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to validate and apply defaults for JWKS: %w", err)
 	}
+	if c.LinkFormat == "" {
+		c.LinkFormat = magiclink.LinkFormatQuery
+	}
+	err = c.LinkFormat.Valid()
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid link format %q: %w", c.LinkFormat, jt.ErrDefaultsAndValidate)
+	}
 	if c.Port == 0 {
 		c.Port = 8080
 	}
@@ -93,6 +112,14 @@ func (c Config) DefaultsAndValidate() (Config, error) {
 	default:
 		return Config{}, fmt.Errorf("invalid log level %q: %w", c.LogLevel, jt.ErrDefaultsAndValidate)
 	}
+	c.MagicLinkResend, err = c.MagicLinkResend.DefaultsAndValidate()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to validate and apply defaults for magic link resend: %w", err)
+	}
+	c.OTPResend, err = c.OTPResend.DefaultsAndValidate()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to validate and apply defaults for OTP resend: %w", err)
+	}
 	c.PreventRobots, err = c.PreventRobots.DefaultsAndValidate()
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to validate and apply defaults for preventing robots: %w", err)
@@ -106,6 +133,10 @@ func (c Config) DefaultsAndValidate() (Config, error) {
 	if c.ShutdownTimeout.Get() == 0 {
 		c.ShutdownTimeout = jt.New(time.Second)
 	}
+	c.TOTP, err = c.TOTP.DefaultsAndValidate()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to validate and apply defaults for TOTP: %w", err)
+	}
 	c.Validation, err = c.Validation.DefaultsAndValidate()
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to validate and apply defaults for validation: %w", err)
@@ -123,9 +154,82 @@ func (j JWKS) DefaultsAndValidate() (JWKS, error) {
 	return j, nil
 }
 
+// MagicLinkResend is the configuration for resending the email of an expired magic link from its error page.
+type MagicLinkResend struct {
+	Enabled     bool          `json:"enabled"`
+	RateLimiter rlimit.Config `json:"rateLimiter"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (m MagicLinkResend) DefaultsAndValidate() (MagicLinkResend, error) {
+	if m.RateLimiter.Burst == 0 {
+		m.RateLimiter.Burst = 1
+	}
+	if m.RateLimiter.RefillRate == 0 {
+		m.RateLimiter.RefillRate = 1.0 / 300 // One per five minutes.
+	}
+	var err error
+	m.RateLimiter, err = m.RateLimiter.DefaultsAndValidate()
+	if err != nil {
+		return MagicLinkResend{}, fmt.Errorf("failed to validate and apply defaults for rate limiter: %w", err)
+	}
+	return m, nil
+}
+
+// OTPResend is the configuration for resending an OTP under the same ID.
+type OTPResend struct {
+	// Cooldown is the minimum time after an OTP is sent before it can be resent. The default is 30 seconds.
+	Cooldown *jt.JSONType[time.Duration] `json:"cooldown"`
+	// MaxResends is the maximum number of times an OTP can be resent. The default is 3.
+	MaxResends uint `json:"maxResends"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (o OTPResend) DefaultsAndValidate() (OTPResend, error) {
+	if o.Cooldown.Get() == 0 {
+		o.Cooldown = jt.New(30 * time.Second)
+	}
+	if o.Cooldown.Get() < 0 {
+		return OTPResend{}, fmt.Errorf("OTP resend cooldown must not be negative: %w", jt.ErrDefaultsAndValidate)
+	}
+	if o.MaxResends == 0 {
+		o.MaxResends = 3
+	}
+	return o, nil
+}
+
+// TOTP is the configuration for authenticator app codes.
+type TOTP struct {
+	// Window is the number of 30 second time steps before and after the current one that are accepted, to allow for
+	// clock drift between the server and the authenticator app. The default is 1.
+	Window uint `json:"window"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (t TOTP) DefaultsAndValidate() (TOTP, error) {
+	if t.Window == 0 {
+		t.Window = 1
+	}
+	if t.Window > 10 {
+		return TOTP{}, fmt.Errorf("TOTP window must be at most 10 time steps: %w", jt.ErrDefaultsAndValidate)
+	}
+	return t, nil
+}
+
 const (
+	// PreventRobotsHCaptcha indicates that hCaptcha should be used to prevent robots from following magic links.
+	PreventRobotsHCaptcha PreventRobotsMethod = "hcaptcha"
+	// PreventRobotsInterstitial indicates that a click-through interstitial page should be used to prevent robots from
+	// following magic links.
+	PreventRobotsInterstitial PreventRobotsMethod = "interstitial"
+	// PreventRobotsProofOfWork indicates that a self-hosted proof-of-work challenge should be used to prevent robots from
+	// following magic links.
+	PreventRobotsProofOfWork PreventRobotsMethod = "proofofwork"
 	// PreventRobotsReCAPTCHAV3 indicates that ReCAPTCHA V3 should be used to prevent robots from following magic links.
 	PreventRobotsReCAPTCHAV3 PreventRobotsMethod = "recaptchav3"
+	// PreventRobotsTurnstile indicates that Cloudflare Turnstile should be used to prevent robots from following magic
+	// links.
+	PreventRobotsTurnstile PreventRobotsMethod = "turnstile"
 )
 
 // PreventRobotsMethod is a set of string constants that indicate how to prevent robots from following magic links.
@@ -133,19 +237,43 @@ type PreventRobotsMethod string
 
 // PreventRobots is the configuration for preventing robots from following magic links.
 type PreventRobots struct {
-	Method      PreventRobotsMethod         `json:"method"`
-	ReCAPTCHAV3 magiclink.ReCAPTCHAV3Config `json:"recaptchav3"`
+	HCaptcha     magiclink.HCaptchaConfig     `json:"hcaptcha"`
+	Interstitial magiclink.InterstitialConfig `json:"interstitial"`
+	Method       PreventRobotsMethod          `json:"method"`
+	ProofOfWork  magiclink.ProofOfWorkConfig  `json:"proofOfWork"`
+	ReCAPTCHAV3  magiclink.ReCAPTCHAV3Config  `json:"recaptchav3"`
+	Turnstile    magiclink.TurnstileConfig    `json:"turnstile"`
 }
 
 // DefaultsAndValidate implements the jsontype.Config interface.
 func (p PreventRobots) DefaultsAndValidate() (PreventRobots, error) {
 	var err error
 	switch p.Method {
+	case PreventRobotsHCaptcha:
+		p.HCaptcha, err = p.HCaptcha.DefaultsAndValidate()
+		if err != nil {
+			return PreventRobots{}, fmt.Errorf("failed to validate and apply defaults for hCaptcha configuration: %w", err)
+		}
+	case PreventRobotsInterstitial:
+		p.Interstitial, err = p.Interstitial.DefaultsAndValidate()
+		if err != nil {
+			return PreventRobots{}, fmt.Errorf("failed to validate and apply defaults for interstitial configuration: %w", err)
+		}
+	case PreventRobotsProofOfWork:
+		p.ProofOfWork, err = p.ProofOfWork.DefaultsAndValidate()
+		if err != nil {
+			return PreventRobots{}, fmt.Errorf("failed to validate and apply defaults for proof-of-work configuration: %w", err)
+		}
 	case PreventRobotsReCAPTCHAV3:
 		p.ReCAPTCHAV3, err = p.ReCAPTCHAV3.DefaultsAndValidate()
 		if err != nil {
 			return PreventRobots{}, fmt.Errorf("failed to validate and apply defaults for ReCAPTCHA V3 configuration: %w", err)
 		}
+	case PreventRobotsTurnstile:
+		p.Turnstile, err = p.Turnstile.DefaultsAndValidate()
+		if err != nil {
+			return PreventRobots{}, fmt.Errorf("failed to validate and apply defaults for Cloudflare Turnstile configuration: %w", err)
+		}
 	}
 	return p, nil
 }
@@ -6,12 +6,22 @@ import (
 )
 
 const (
+	// ContentTypeEventStream is the content type for server-sent events.
+	ContentTypeEventStream = "text/event-stream"
+	// ContentTypeHTML is the content type for HTML.
+	ContentTypeHTML = "text/html; charset=utf-8"
 	// ContentTypeJSON is the content type for JSON.
 	ContentTypeJSON = "application/json"
+	// ContentTypePNG is the content type for PNG images.
+	ContentTypePNG = "image/png"
+	// ContentTypeSVG is the content type for SVG images.
+	ContentTypeSVG = "image/svg+xml"
 	// DefaultOTPLength is the default length for OTPs.
 	DefaultOTPLength = 6
 	// DefaultRelativePathRedirect is the default relative path for redirecting.
 	DefaultRelativePathRedirect = "redirect"
+	// HeaderAccept is the accept header.
+	HeaderAccept = "Accept"
 	// HeaderContentType is the content type header.
 	HeaderContentType = "Content-Type"
 	// LogFmt is the log format.
@@ -22,6 +32,10 @@ const (
 	LogRequestBody = "requestBody"
 	// LogResponseBody is key for logging the response body.
 	LogResponseBody = "responseBody"
+	// MaxOTPAlphabetLength is the maximum number of characters in a custom OTP alphabet.
+	MaxOTPAlphabetLength = 256
+	// MaxOTPLength is the maximum length for OTPs.
+	MaxOTPLength = 64
 	// Over250Years is the maximum duration for this project. Restriction derived from Golang's time.Duration.
 	Over250Years = 250 * 366 * 24 * time.Hour
 	// ResponseInternalServerError is the response for internal server errors.
@@ -0,0 +1,144 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	netMail "net/mail"
+	"os"
+	"path/filepath"
+	textTemplate "text/template"
+	"time"
+
+	jt "github.com/MicahParks/jsontype"
+
+	"github.com/MicahParks/magiclinksdev/email"
+)
+
+// Extension is the file extension for the RFC 5322 messages written by the filesystem provider.
+const Extension = ".eml"
+
+// Config is the configuration for the filesystem provider. By default, messages are written to a magiclinksdev-mail
+// directory in the operating system's temporary directory.
+type Config struct {
+	Dir       string                         `json:"dir"`
+	FromEmail *jt.JSONType[*netMail.Address] `json:"fromEmail"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (c Config) DefaultsAndValidate() (Config, error) {
+	if c.Dir == "" {
+		c.Dir = filepath.Join(os.TempDir(), "magiclinksdev-mail")
+	}
+	if c.FromEmail.Get() == nil {
+		c.FromEmail = jt.New(&netMail.Address{Name: "magiclinksdev", Address: "noreply@localhost"})
+	}
+	return c, nil
+}
+
+type filesystem struct {
+	dir                  string
+	from                 *netMail.Address
+	magicLinkHTMLTmpl    *template.Template
+	magicLinkTxtTmpl     *textTemplate.Template
+	magicLinkOTPHTMLTmpl *template.Template
+	magicLinkOTPTxtTmpl  *textTemplate.Template
+	oTPHTMLTmpl          *template.Template
+	oTPTxtTmpl           *textTemplate.Template
+}
+
+// NewProvider creates a new filesystem email provider. Instead of delivering emails, it writes each one as a complete
+// RFC 5322 message to the configured directory. It is meant for local development.
+func NewProvider(conf Config) (email.Provider, error) {
+	err := os.MkdirAll(conf.Dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email directory: %w", err)
+	}
+	magicLinkHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkHTMLTemplate))
+	magicLinkTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkTextTemplate))
+	magicLinkOTPHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkOTPHTMLTemplate))
+	magicLinkOTPTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkOTPTextTemplate))
+	otpHTMLTmpl := template.Must(template.New("").Parse(email.OTPHTMLTemplate))
+	otpTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.OTPTextTemplate))
+	f := filesystem{
+		dir:                  conf.Dir,
+		from:                 conf.FromEmail.Get(),
+		magicLinkHTMLTmpl:    magicLinkHTMLTmpl,
+		magicLinkTxtTmpl:     magicLinkTxtTmpl,
+		magicLinkOTPHTMLTmpl: magicLinkOTPHTMLTmpl,
+		magicLinkOTPTxtTmpl:  magicLinkOTPTxtTmpl,
+		oTPHTMLTmpl:          otpHTMLTmpl,
+		oTPTxtTmpl:           otpTxtTmpl,
+	}
+	return f, nil
+}
+
+func (f filesystem) SendMagicLink(ctx context.Context, e email.Email) error {
+	return f.sendEmail(ctx, e, f.magicLinkHTMLTmpl, f.magicLinkTxtTmpl)
+}
+func (f filesystem) SendMagicLinkOTP(ctx context.Context, e email.Email) error {
+	return f.sendEmail(ctx, e, f.magicLinkOTPHTMLTmpl, f.magicLinkOTPTxtTmpl)
+}
+func (f filesystem) SendOTP(ctx context.Context, e email.Email) error {
+	return f.sendEmail(ctx, e, f.oTPHTMLTmpl, f.oTPTxtTmpl)
+}
+
+func (f filesystem) sendEmail(_ context.Context, e email.Email, htmlTmpl *template.Template, txtTmpl *textTemplate.Template) error {
+	htmlBuf := bytes.NewBuffer(nil)
+	err := htmlTmpl.Execute(htmlBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for HTML email: %w", err)
+	}
+	textBuf := bytes.NewBuffer(nil)
+	err = txtTmpl.Execute(textBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for text email: %w", err)
+	}
+
+	message, err := email.MIMEMessage(f.from, e, htmlBuf.Bytes(), textBuf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to create MIME message: %w", err)
+	}
+
+	err = f.write(message)
+	if err != nil {
+		return fmt.Errorf("failed to write email to filesystem: %w: %w", email.ErrProvider, err)
+	}
+
+	return nil
+}
+
+// write atomically writes the message to the directory so a partially written message is never listed.
+func (f filesystem) write(message []byte) error {
+	b := make([]byte, 4)
+	_, err := rand.Read(b)
+	if err != nil {
+		return fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + hex.EncodeToString(b) + Extension
+
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(message)
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	err = os.Rename(tmp.Name(), filepath.Join(f.dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	netMail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	jt "github.com/MicahParks/jsontype"
+
+	"github.com/MicahParks/magiclinksdev/email"
+	"github.com/MicahParks/magiclinksdev/email/filesystem"
+)
+
+const magicLink = "http://localhost:8080/api/v2/magic-link?secret=abc"
+
+func TestProvider(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	conf, err := filesystem.Config{
+		Dir:       dir,
+		FromEmail: jt.New(&netMail.Address{Name: "magiclinksdev", Address: "sender@example.com"}),
+	}.DefaultsAndValidate()
+	if err != nil {
+		t.Fatalf("Failed to validate config: %v", err)
+	}
+	provider, err := filesystem.NewProvider(conf)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	e := email.Email{
+		Subject: "Your magic link",
+		TemplateData: email.MagicLinkTemplateData{
+			ButtonText: "Log in",
+			MagicLink:  magicLink,
+			Title:      "Log in to example",
+		},
+		To: &netMail.Address{Name: "Customer", Address: "customer@example.com"},
+	}
+	err = provider.SendMagicLink(context.Background(), e)
+	if err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read email directory: %v", err)
+	}
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), filesystem.Extension) {
+		t.Fatalf("Expected exactly one %s file, got %v", filesystem.Extension, entries)
+	}
+	id := entries[0].Name()
+
+	f, err := os.Open(filepath.Join(dir, id))
+	if err != nil {
+		t.Fatalf("Failed to open email: %v", err)
+	}
+	defer f.Close()
+	msg, err := netMail.ReadMessage(f)
+	if err != nil {
+		t.Fatalf("Failed to parse email: %v", err)
+	}
+	if msg.Header.Get("Message-ID") == "" || msg.Header.Get("Date") == "" {
+		t.Fatalf("Expected Message-ID and Date headers")
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative email, got %q: %v", mediaType, err)
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	parts := 0
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read email part: %v", err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Failed to read email part body: %v", err)
+		}
+		if !strings.Contains(string(body), "secret=abc") {
+			t.Fatalf("Expected %q part to contain the magic link", part.Header.Get("Content-Type"))
+		}
+		parts++
+	}
+	if parts != 2 {
+		t.Fatalf("Expected text and HTML parts, got %d parts", parts)
+	}
+
+	viewer := httptest.NewServer(filesystem.NewViewer(dir, filesystem.ViewerOptions{}))
+	defer viewer.Close()
+
+	body := get(t, viewer.URL, http.StatusOK)
+	if !strings.Contains(body, e.Subject) || !strings.Contains(body, "?"+filesystem.QueryMessage+"="+id) {
+		t.Fatalf("Expected the list page to link to the captured email")
+	}
+	body = get(t, viewer.URL+"?"+filesystem.QueryMessage+"="+id, http.StatusOK)
+	if !strings.Contains(body, `<a href="`+strings.ReplaceAll(magicLink, "&", "&amp;")+`"`) {
+		t.Fatalf("Expected the message page to contain a clickable magic link")
+	}
+	if !strings.Contains(body, "<iframe sandbox=") {
+		t.Fatalf("Expected the message page to render the HTML email in a sandboxed frame")
+	}
+
+	get(t, viewer.URL+"?"+filesystem.QueryMessage+"=..%2Fpasswd.eml", http.StatusNotFound)
+	get(t, viewer.URL+"?"+filesystem.QueryMessage+"=missing.eml", http.StatusNotFound)
+}
+
+func get(t *testing.T, u string, expectedStatus int) string {
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+	if resp.StatusCode != expectedStatus {
+		t.Fatalf("Expected status code %d, got %d", expectedStatus, resp.StatusCode)
+	}
+	return string(body)
+}
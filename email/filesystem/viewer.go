@@ -0,0 +1,263 @@
+package filesystem
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	netMail "net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+const (
+	// QueryMessage is the query parameter the viewer uses to select a message to render.
+	QueryMessage = "message"
+	// maxListed is the maximum number of messages listed by the viewer, newest first.
+	maxListed = 100
+)
+
+//go:embed viewer.gohtml
+var viewerTemplate string
+
+var (
+	linkRegex  = regexp.MustCompile(`https?://[^\s<>"]+`)
+	viewerTmpl = template.Must(template.New("").Parse(viewerTemplate))
+)
+
+// ViewerOptions are the options for the viewer.
+type ViewerOptions struct {
+	Logger *slog.Logger
+}
+
+type viewer struct {
+	dir    string
+	logger *slog.Logger
+}
+
+// NewViewer creates an HTTP handler that lists the messages written to the directory by the filesystem provider and
+// renders them with clickable links. Messages are selected with the QueryMessage query parameter. It exposes every
+// captured email, so it must only be used for local development.
+func NewViewer(dir string, options ViewerOptions) http.Handler {
+	if options.Logger == nil {
+		options.Logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	}
+	return viewer{
+		dir:    dir,
+		logger: options.Logger,
+	}
+}
+
+type viewerMessage struct {
+	Date    time.Time
+	From    string
+	HTML    string
+	ID      string
+	Links   []string
+	Subject string
+	Text    string
+	To      string
+}
+
+type viewerTemplateData struct {
+	Message  *viewerMessage
+	Messages []viewerMessage
+	Query    string
+}
+
+func (v viewer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	data := viewerTemplateData{
+		Query: QueryMessage,
+	}
+	id := r.URL.Query().Get(QueryMessage)
+	if id == "" {
+		messages, err := v.list()
+		if err != nil {
+			v.logger.ErrorContext(ctx, "Failed to list captured emails.",
+				mld.LogErr, err,
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		data.Messages = messages
+	} else {
+		if !validID(id) {
+			http.NotFound(w, r)
+			return
+		}
+		message, err := v.read(id, true)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				http.NotFound(w, r)
+				return
+			}
+			v.logger.ErrorContext(ctx, "Failed to read captured email.",
+				mld.LogErr, err,
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		data.Message = &message
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(mld.HeaderContentType, "text/html; charset=utf-8")
+	err := viewerTmpl.Execute(w, data)
+	if err != nil {
+		v.logger.ErrorContext(ctx, "Failed to execute email viewer template.",
+			mld.LogErr, err,
+		)
+	}
+}
+
+func (v viewer) list() ([]viewerMessage, error) {
+	entries, err := os.ReadDir(v.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read email directory: %w", err)
+	}
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && validID(entry.Name()) {
+			ids = append(ids, entry.Name())
+		}
+	}
+	slices.Sort(ids)
+	slices.Reverse(ids)
+	if len(ids) > maxListed {
+		ids = ids[:maxListed]
+	}
+
+	messages := make([]viewerMessage, 0, len(ids))
+	for _, id := range ids {
+		message, err := v.read(id, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read email %q: %w", id, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (v viewer) read(id string, withBody bool) (viewerMessage, error) {
+	f, err := os.Open(filepath.Join(v.dir, id))
+	if err != nil {
+		return viewerMessage{}, fmt.Errorf("failed to open email: %w", err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	msg, err := netMail.ReadMessage(f)
+	if err != nil {
+		return viewerMessage{}, fmt.Errorf("failed to parse email: %w", err)
+	}
+	dec := new(mime.WordDecoder)
+	header := func(key string) string {
+		value, err := dec.DecodeHeader(msg.Header.Get(key))
+		if err != nil {
+			return msg.Header.Get(key)
+		}
+		return value
+	}
+	message := viewerMessage{
+		From:    header("From"),
+		ID:      id,
+		Subject: header("Subject"),
+		To:      header("To"),
+	}
+	message.Date, err = msg.Header.Date()
+	if err != nil {
+		info, err := f.Stat()
+		if err != nil {
+			return viewerMessage{}, fmt.Errorf("failed to stat email: %w", err)
+		}
+		message.Date = info.ModTime()
+	}
+	if !withBody {
+		return message, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return viewerMessage{}, fmt.Errorf("failed to parse email content type: %w", err)
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(msg.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return viewerMessage{}, fmt.Errorf("failed to read email part: %w", err)
+			}
+			partType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if err != nil {
+				return viewerMessage{}, fmt.Errorf("failed to parse email part content type: %w", err)
+			}
+			err = message.setBody(partType, part)
+			if err != nil {
+				return viewerMessage{}, err
+			}
+		}
+	} else {
+		err = message.setBody(mediaType, msg.Body)
+		if err != nil {
+			return viewerMessage{}, err
+		}
+	}
+
+	for _, link := range linkRegex.FindAllString(message.Text, -1) {
+		link = strings.TrimRight(link, ".,;)")
+		if !slices.Contains(message.Links, link) {
+			message.Links = append(message.Links, link)
+		}
+	}
+	// Links in the rendered HTML open outside the sandboxed frame.
+	if message.HTML != "" {
+		message.HTML = `<base target="_blank">` + message.HTML
+	}
+
+	return message, nil
+}
+
+func (m *viewerMessage) setBody(mediaType string, r io.Reader) error {
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %q email body: %w", mediaType, err)
+	}
+	if mediaType == "text/html" {
+		m.HTML = string(body)
+	} else {
+		m.Text = string(body)
+	}
+	return nil
+}
+
+// validID prevents path traversal by only allowing the names of messages written by the filesystem provider.
+func validID(id string) bool {
+	return filepath.Base(id) == id && !strings.HasPrefix(id, ".") && strings.HasSuffix(id, Extension)
+}
@@ -12,6 +12,7 @@ var ErrProvider = errors.New("error with email provider")
 // Provider is the interface for an email provider.
 type Provider interface {
 	SendMagicLink(ctx context.Context, e Email) error
+	SendMagicLinkOTP(ctx context.Context, e Email) error
 	SendOTP(ctx context.Context, e Email) error
 }
 
@@ -0,0 +1,165 @@
+package mailgun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	netMail "net/mail"
+	"net/url"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	jt "github.com/MicahParks/jsontype"
+
+	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/email"
+)
+
+const (
+	// DefaultBaseURL is the default base URL for the Mailgun API. Domains in the EU region use
+	// https://api.eu.mailgun.net instead.
+	DefaultBaseURL = "https://api.mailgun.net"
+	// username is the HTTP basic authentication username for the Mailgun API.
+	username = "api"
+)
+
+// Config is the configuration for the Mailgun provider.
+type Config struct {
+	APIKey    string                         `json:"apiKey"`
+	BaseURL   *jt.JSONType[*url.URL]         `json:"baseURL"`
+	Domain    string                         `json:"domain"`
+	FromEmail *jt.JSONType[*netMail.Address] `json:"fromEmail"`
+	Timeout   *jt.JSONType[time.Duration]    `json:"timeout"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (c Config) DefaultsAndValidate() (Config, error) {
+	if c.APIKey == "" {
+		return Config{}, fmt.Errorf("Mailgun API key not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.Domain == "" {
+		return Config{}, fmt.Errorf("Mailgun domain not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.FromEmail.Get() == nil {
+		return Config{}, fmt.Errorf("Mailgun from email not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.BaseURL.Get() == nil {
+		u, err := url.Parse(DefaultBaseURL)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse default Mailgun base URL: %w", err)
+		}
+		c.BaseURL = jt.New(u)
+	}
+	if c.Timeout.Get() == 0 {
+		c.Timeout = jt.New(10 * time.Second)
+	}
+	return c, nil
+}
+
+type mailgun struct {
+	apiKey               string
+	endpoint             string
+	from                 *netMail.Address
+	http                 *http.Client
+	magicLinkHTMLTmpl    *template.Template
+	magicLinkTxtTmpl     *textTemplate.Template
+	magicLinkOTPHTMLTmpl *template.Template
+	magicLinkOTPTxtTmpl  *textTemplate.Template
+	oTPHTMLTmpl          *template.Template
+	oTPTxtTmpl           *textTemplate.Template
+}
+
+// NewProvider creates a new Mailgun email provider.
+func NewProvider(conf Config) (email.Provider, error) {
+	endpoint, err := url.JoinPath(conf.BaseURL.Get().String(), "v3", url.PathEscape(conf.Domain), "messages")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Mailgun API endpoint: %w", err)
+	}
+	magicLinkHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkHTMLTemplate))
+	magicLinkTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkTextTemplate))
+	magicLinkOTPHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkOTPHTMLTemplate))
+	magicLinkOTPTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkOTPTextTemplate))
+	otpHTMLTmpl := template.Must(template.New("").Parse(email.OTPHTMLTemplate))
+	otpTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.OTPTextTemplate))
+	m := mailgun{
+		apiKey:   conf.APIKey,
+		endpoint: endpoint,
+		from:     conf.FromEmail.Get(),
+		http: &http.Client{
+			Timeout: conf.Timeout.Get(),
+		},
+		magicLinkHTMLTmpl:    magicLinkHTMLTmpl,
+		magicLinkTxtTmpl:     magicLinkTxtTmpl,
+		magicLinkOTPHTMLTmpl: magicLinkOTPHTMLTmpl,
+		magicLinkOTPTxtTmpl:  magicLinkOTPTxtTmpl,
+		oTPHTMLTmpl:          otpHTMLTmpl,
+		oTPTxtTmpl:           otpTxtTmpl,
+	}
+	return m, nil
+}
+
+func (m mailgun) SendMagicLink(ctx context.Context, e email.Email) error {
+	return m.sendEmail(ctx, e, m.magicLinkHTMLTmpl, m.magicLinkTxtTmpl)
+}
+func (m mailgun) SendMagicLinkOTP(ctx context.Context, e email.Email) error {
+	return m.sendEmail(ctx, e, m.magicLinkOTPHTMLTmpl, m.magicLinkOTPTxtTmpl)
+}
+func (m mailgun) SendOTP(ctx context.Context, e email.Email) error {
+	return m.sendEmail(ctx, e, m.oTPHTMLTmpl, m.oTPTxtTmpl)
+}
+
+type sendResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func (m mailgun) sendEmail(ctx context.Context, e email.Email, htmlTmpl *template.Template, txtTmpl *textTemplate.Template) error {
+	htmlBuf := bytes.NewBuffer(nil)
+	err := htmlTmpl.Execute(htmlBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for HTML email: %w", err)
+	}
+	textBuf := bytes.NewBuffer(nil)
+	err = txtTmpl.Execute(textBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for text email: %w", err)
+	}
+
+	form := url.Values{
+		"from":              {m.from.String()},
+		"to":                {e.To.String()},
+		"subject":           {e.Subject},
+		"html":              {htmlBuf.String()},
+		"text":              {textBuf.String()},
+		"o:tracking":        {"no"},
+		"o:tracking-clicks": {"no"},
+		"o:tracking-opens":  {"no"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create Mailgun API request: %w", err)
+	}
+	req.Header.Set("Accept", mld.ContentTypeJSON)
+	req.Header.Set(mld.HeaderContentType, "application/x-www-form-urlencoded")
+	req.SetBasicAuth(username, m.apiKey)
+
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email with Mailgun API: %w: %w", email.ErrProvider, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var sendResp sendResponse
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&sendResp)
+		return fmt.Errorf("Mailgun API response status code %d, message %q: %w", resp.StatusCode, sendResp.Message, email.ErrProvider)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package mailgun_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	netMail "net/mail"
+	"net/url"
+	"strings"
+	"testing"
+
+	jt "github.com/MicahParks/jsontype"
+
+	"github.com/MicahParks/magiclinksdev/email"
+	"github.com/MicahParks/magiclinksdev/email/mailgun"
+)
+
+const (
+	apiKey = "api-key"
+	domain = "mg.example.com"
+)
+
+func TestProvider(t *testing.T) {
+	var received url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v3/"+domain+"/messages" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "api" || pass != apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Invalid private key"}`))
+			return
+		}
+		err := r.ParseForm()
+		if err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+		}
+		received = r.PostForm
+		_, _ = w.Write([]byte(`{"id":"<20240101000000.1@mg.example.com>","message":"Queued. Thank you."}`))
+	}))
+	defer server.Close()
+
+	e := email.Email{
+		Subject: "Your one-time password",
+		TemplateData: email.OTPTemplateData{
+			Expiration:        "1h0m0s",
+			OTP:               "ABC-DEF",
+			OTPGroups:         []string{"ABC", "DEF"},
+			OTPGroupSeparator: "-",
+		},
+		To: &netMail.Address{Name: "Customer", Address: "customer@example.com"},
+	}
+
+	provider := newProvider(t, server.URL, apiKey)
+	err := provider.SendOTP(context.Background(), e)
+	if err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if received.Get("to") != e.To.String() || received.Get("subject") != e.Subject || received.Get("o:tracking") != "no" {
+		t.Fatalf("Unexpected request form: %v", received)
+	}
+	for _, key := range []string{"html", "text"} {
+		body := received.Get(key)
+		if !strings.Contains(body, "ABC") || !strings.Contains(body, "DEF") {
+			t.Fatalf("Expected %s to contain the OTP", key)
+		}
+	}
+
+	provider = newProvider(t, server.URL, "wrong")
+	err = provider.SendOTP(context.Background(), e)
+	if !errors.Is(err, email.ErrProvider) {
+		t.Fatalf("Expected error %v, got %v", email.ErrProvider, err)
+	}
+}
+
+func newProvider(t *testing.T, baseURL, key string) email.Provider {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		t.Fatalf("Failed to parse base URL: %v", err)
+	}
+	conf, err := mailgun.Config{
+		APIKey:    key,
+		BaseURL:   jt.New(u),
+		Domain:    domain,
+		FromEmail: jt.New(&netMail.Address{Name: "magiclinksdev", Address: "sender@example.com"}),
+	}.DefaultsAndValidate()
+	if err != nil {
+		t.Fatalf("Failed to validate config: %v", err)
+	}
+	provider, err := mailgun.NewProvider(conf)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	return provider
+}
@@ -0,0 +1,93 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// MIMEMessage creates an RFC 5322 message with a multipart/alternative body containing the text and HTML versions of
+// an email. It is used by providers that deliver raw messages, such as SMTP.
+func MIMEMessage(from *mail.Address, e Email, htmlBody, textBody []byte) ([]byte, error) {
+	messageID, err := messageID(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message ID: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	body := multipart.NewWriter(buf)
+	header := []struct {
+		key   string
+		value string
+	}{
+		{"From", from.String()},
+		{"To", e.To.String()},
+		{"Subject", mime.QEncoding.Encode("UTF-8", e.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": body.Boundary()})},
+	}
+	for _, h := range header {
+		_, err = fmt.Fprintf(buf, "%s: %s\r\n", h.key, h.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %q header: %w", h.key, err)
+		}
+	}
+	_, err = buf.WriteString("\r\n")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write end of headers: %w", err)
+	}
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=UTF-8", textBody},
+		{"text/html; charset=UTF-8", htmlBody},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %q part: %w", part.contentType, err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		_, err = qp.Write(part.content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %q part: %w", part.contentType, err)
+		}
+		err = qp.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to close %q part: %w", part.contentType, err)
+		}
+	}
+	err = body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close multipart body: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func messageID(from *mail.Address) (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at != -1 {
+		domain = from.Address[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain), nil
+}
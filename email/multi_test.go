@@ -0,0 +1,116 @@
+package email_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	netMail "net/mail"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	jt "github.com/MicahParks/jsontype"
+
+	"github.com/MicahParks/magiclinksdev/email"
+	"github.com/MicahParks/magiclinksdev/email/mailgun"
+	"github.com/MicahParks/magiclinksdev/email/postmark"
+	"github.com/MicahParks/magiclinksdev/email/resend"
+)
+
+func TestMultiProvider(t *testing.T) {
+	from := jt.New(&netMail.Address{Address: "sender@example.com"})
+
+	var postmarkCalls, mailgunCalls, resendCalls atomic.Int64
+	postmarkServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		postmarkCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"ErrorCode":100,"Message":"Maintenance"}`))
+	}))
+	defer postmarkServer.Close()
+	mailgunServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mailgunCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mailgunServer.Close()
+	resendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		resendCalls.Add(1)
+		_, _ = w.Write([]byte(`{"id":"49a3999c-0ce1-4ea6-ab68-afcd6dc2e794"}`))
+	}))
+	defer resendServer.Close()
+
+	postmarkConf, err := postmark.Config{
+		BaseURL:     jt.New(mustParse(t, postmarkServer.URL)),
+		FromEmail:   from,
+		ServerToken: "server-token",
+	}.DefaultsAndValidate()
+	if err != nil {
+		t.Fatalf("Failed to validate Postmark config: %v", err)
+	}
+	postmarkProvider, err := postmark.NewProvider(postmarkConf)
+	if err != nil {
+		t.Fatalf("Failed to create Postmark provider: %v", err)
+	}
+	mailgunConf, err := mailgun.Config{
+		APIKey:    "api-key",
+		BaseURL:   jt.New(mustParse(t, mailgunServer.URL)),
+		Domain:    "mg.example.com",
+		FromEmail: from,
+	}.DefaultsAndValidate()
+	if err != nil {
+		t.Fatalf("Failed to validate Mailgun config: %v", err)
+	}
+	mailgunProvider, err := mailgun.NewProvider(mailgunConf)
+	if err != nil {
+		t.Fatalf("Failed to create Mailgun provider: %v", err)
+	}
+	resendConf, err := resend.Config{
+		APIKey:    "re_api_key",
+		BaseURL:   jt.New(mustParse(t, resendServer.URL)),
+		FromEmail: from,
+	}.DefaultsAndValidate()
+	if err != nil {
+		t.Fatalf("Failed to validate Resend config: %v", err)
+	}
+	resendProvider, err := resend.NewProvider(resendConf)
+	if err != nil {
+		t.Fatalf("Failed to create Resend provider: %v", err)
+	}
+
+	e := email.Email{
+		Subject: "Your magic link",
+		TemplateData: email.MagicLinkTemplateData{
+			MagicLink: "https://example.com/magic-link",
+		},
+		To: &netMail.Address{Address: "customer@example.com"},
+	}
+
+	multi, err := email.NewMultiProvider([]email.Provider{postmarkProvider, mailgunProvider, resendProvider}, email.MultiProviderOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create multi-provider: %v", err)
+	}
+	err = multi.SendMagicLink(context.Background(), e)
+	if err != nil {
+		t.Fatalf("Expected multi-provider to fall back to a working provider: %v", err)
+	}
+	if postmarkCalls.Load() != 1 || mailgunCalls.Load() != 1 || resendCalls.Load() != 1 {
+		t.Fatalf("Expected each provider to be called once, got %d, %d, %d", postmarkCalls.Load(), mailgunCalls.Load(), resendCalls.Load())
+	}
+
+	multi, err = email.NewMultiProvider([]email.Provider{postmarkProvider, mailgunProvider}, email.MultiProviderOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create multi-provider: %v", err)
+	}
+	err = multi.SendMagicLink(context.Background(), e)
+	if !errors.Is(err, email.ErrProvider) {
+		t.Fatalf("Expected error %v, got %v", email.ErrProvider, err)
+	}
+}
+
+func mustParse(t *testing.T, rawURL string) *url.URL {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	return u
+}
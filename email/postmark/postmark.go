@@ -0,0 +1,186 @@
+package postmark
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	netMail "net/mail"
+	"net/url"
+	textTemplate "text/template"
+	"time"
+
+	jt "github.com/MicahParks/jsontype"
+
+	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/email"
+)
+
+const (
+	// DefaultBaseURL is the default base URL for the Postmark API.
+	DefaultBaseURL = "https://api.postmarkapp.com"
+	// DefaultMessageStream is the default Postmark message stream for transactional email.
+	DefaultMessageStream = "outbound"
+	// HeaderServerToken is the header used to authenticate with the Postmark API.
+	HeaderServerToken = "X-Postmark-Server-Token"
+)
+
+// Config is the configuration for the Postmark provider.
+type Config struct {
+	BaseURL       *jt.JSONType[*url.URL]         `json:"baseURL"`
+	FromEmail     *jt.JSONType[*netMail.Address] `json:"fromEmail"`
+	MessageStream string                         `json:"messageStream"`
+	ServerToken   string                         `json:"serverToken"`
+	Timeout       *jt.JSONType[time.Duration]    `json:"timeout"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (c Config) DefaultsAndValidate() (Config, error) {
+	if c.ServerToken == "" {
+		return Config{}, fmt.Errorf("Postmark server token not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.FromEmail.Get() == nil {
+		return Config{}, fmt.Errorf("Postmark from email not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.BaseURL.Get() == nil {
+		u, err := url.Parse(DefaultBaseURL)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse default Postmark base URL: %w", err)
+		}
+		c.BaseURL = jt.New(u)
+	}
+	if c.MessageStream == "" {
+		c.MessageStream = DefaultMessageStream
+	}
+	if c.Timeout.Get() == 0 {
+		c.Timeout = jt.New(10 * time.Second)
+	}
+	return c, nil
+}
+
+type postmark struct {
+	endpoint             string
+	from                 *netMail.Address
+	http                 *http.Client
+	magicLinkHTMLTmpl    *template.Template
+	magicLinkTxtTmpl     *textTemplate.Template
+	magicLinkOTPHTMLTmpl *template.Template
+	magicLinkOTPTxtTmpl  *textTemplate.Template
+	messageStream        string
+	oTPHTMLTmpl          *template.Template
+	oTPTxtTmpl           *textTemplate.Template
+	serverToken          string
+}
+
+// NewProvider creates a new Postmark email provider.
+func NewProvider(conf Config) (email.Provider, error) {
+	endpoint, err := url.JoinPath(conf.BaseURL.Get().String(), "email")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Postmark API endpoint: %w", err)
+	}
+	magicLinkHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkHTMLTemplate))
+	magicLinkTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkTextTemplate))
+	magicLinkOTPHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkOTPHTMLTemplate))
+	magicLinkOTPTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkOTPTextTemplate))
+	otpHTMLTmpl := template.Must(template.New("").Parse(email.OTPHTMLTemplate))
+	otpTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.OTPTextTemplate))
+	p := postmark{
+		endpoint: endpoint,
+		from:     conf.FromEmail.Get(),
+		http: &http.Client{
+			Timeout: conf.Timeout.Get(),
+		},
+		magicLinkHTMLTmpl:    magicLinkHTMLTmpl,
+		magicLinkTxtTmpl:     magicLinkTxtTmpl,
+		magicLinkOTPHTMLTmpl: magicLinkOTPHTMLTmpl,
+		magicLinkOTPTxtTmpl:  magicLinkOTPTxtTmpl,
+		messageStream:        conf.MessageStream,
+		oTPHTMLTmpl:          otpHTMLTmpl,
+		oTPTxtTmpl:           otpTxtTmpl,
+		serverToken:          conf.ServerToken,
+	}
+	return p, nil
+}
+
+func (p postmark) SendMagicLink(ctx context.Context, e email.Email) error {
+	return p.sendEmail(ctx, e, p.magicLinkHTMLTmpl, p.magicLinkTxtTmpl)
+}
+func (p postmark) SendMagicLinkOTP(ctx context.Context, e email.Email) error {
+	return p.sendEmail(ctx, e, p.magicLinkOTPHTMLTmpl, p.magicLinkOTPTxtTmpl)
+}
+func (p postmark) SendOTP(ctx context.Context, e email.Email) error {
+	return p.sendEmail(ctx, e, p.oTPHTMLTmpl, p.oTPTxtTmpl)
+}
+
+type sendRequest struct {
+	From          string `json:"From"`
+	To            string `json:"To"`
+	Subject       string `json:"Subject"`
+	HTMLBody      string `json:"HtmlBody"`
+	TextBody      string `json:"TextBody"`
+	MessageStream string `json:"MessageStream"`
+	TrackOpens    bool   `json:"TrackOpens"`
+	TrackLinks    string `json:"TrackLinks"`
+}
+
+type sendResponse struct {
+	ErrorCode int    `json:"ErrorCode"`
+	Message   string `json:"Message"`
+	MessageID string `json:"MessageID"`
+}
+
+func (p postmark) sendEmail(ctx context.Context, e email.Email, htmlTmpl *template.Template, txtTmpl *textTemplate.Template) error {
+	htmlBuf := bytes.NewBuffer(nil)
+	err := htmlTmpl.Execute(htmlBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for HTML email: %w", err)
+	}
+	textBuf := bytes.NewBuffer(nil)
+	err = txtTmpl.Execute(textBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for text email: %w", err)
+	}
+
+	body, err := json.Marshal(sendRequest{
+		From:          p.from.String(),
+		To:            e.To.String(),
+		Subject:       e.Subject,
+		HTMLBody:      htmlBuf.String(),
+		TextBody:      textBuf.String(),
+		MessageStream: p.messageStream,
+		TrackOpens:    false,
+		TrackLinks:    "None",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to JSON marshal Postmark request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Postmark API request: %w", err)
+	}
+	req.Header.Set("Accept", mld.ContentTypeJSON)
+	req.Header.Set(mld.HeaderContentType, mld.ContentTypeJSON)
+	req.Header.Set(HeaderServerToken, p.serverToken)
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email with Postmark API: %w: %w", email.ErrProvider, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	var sendResp sendResponse
+	err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&sendResp)
+	if err != nil {
+		return fmt.Errorf("failed to decode Postmark API response with status code %d: %w: %w", resp.StatusCode, email.ErrProvider, err)
+	}
+	if resp.StatusCode != http.StatusOK || sendResp.ErrorCode != 0 {
+		return fmt.Errorf("Postmark API response status code %d, error code %d, message %q: %w", resp.StatusCode, sendResp.ErrorCode, sendResp.Message, email.ErrProvider)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package postmark_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	netMail "net/mail"
+	"net/url"
+	"strings"
+	"testing"
+
+	jt "github.com/MicahParks/jsontype"
+
+	"github.com/MicahParks/magiclinksdev/email"
+	"github.com/MicahParks/magiclinksdev/email/postmark"
+)
+
+const serverToken = "server-token"
+
+func TestProvider(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/email" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get(postmark.HeaderServerToken) != serverToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ErrorCode":10,"Message":"Bad or missing Server API token."}`))
+			return
+		}
+		err := json.NewDecoder(r.Body).Decode(&received)
+		if err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"ErrorCode":0,"Message":"OK","MessageID":"b7bc2f4a-e38e-4336-af7d-e6c392c2f817"}`))
+	}))
+	defer server.Close()
+
+	e := email.Email{
+		Subject: "Your one-time password",
+		TemplateData: email.OTPTemplateData{
+			Expiration:        "1h0m0s",
+			OTP:               "ABC-DEF",
+			OTPGroups:         []string{"ABC", "DEF"},
+			OTPGroupSeparator: "-",
+		},
+		To: &netMail.Address{Name: "Customer", Address: "customer@example.com"},
+	}
+
+	provider := newProvider(t, server.URL, serverToken)
+	err := provider.SendOTP(context.Background(), e)
+	if err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if received["To"] != e.To.String() || received["Subject"] != e.Subject || received["MessageStream"] != postmark.DefaultMessageStream {
+		t.Fatalf("Unexpected request body: %v", received)
+	}
+	for _, key := range []string{"HtmlBody", "TextBody"} {
+		body, _ := received[key].(string)
+		if !strings.Contains(body, "ABC") || !strings.Contains(body, "DEF") {
+			t.Fatalf("Expected %s to contain the OTP", key)
+		}
+	}
+
+	provider = newProvider(t, server.URL, "wrong")
+	err = provider.SendOTP(context.Background(), e)
+	if !errors.Is(err, email.ErrProvider) {
+		t.Fatalf("Expected error %v, got %v", email.ErrProvider, err)
+	}
+}
+
+func newProvider(t *testing.T, baseURL, token string) email.Provider {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		t.Fatalf("Failed to parse base URL: %v", err)
+	}
+	conf, err := postmark.Config{
+		BaseURL:     jt.New(u),
+		FromEmail:   jt.New(&netMail.Address{Name: "magiclinksdev", Address: "sender@example.com"}),
+		ServerToken: token,
+	}.DefaultsAndValidate()
+	if err != nil {
+		t.Fatalf("Failed to validate config: %v", err)
+	}
+	provider, err := postmark.NewProvider(conf)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	return provider
+}
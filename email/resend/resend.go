@@ -0,0 +1,163 @@
+package resend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	netMail "net/mail"
+	"net/url"
+	textTemplate "text/template"
+	"time"
+
+	jt "github.com/MicahParks/jsontype"
+
+	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/email"
+)
+
+// DefaultBaseURL is the default base URL for the Resend API.
+const DefaultBaseURL = "https://api.resend.com"
+
+// Config is the configuration for the Resend provider.
+type Config struct {
+	APIKey    string                         `json:"apiKey"`
+	BaseURL   *jt.JSONType[*url.URL]         `json:"baseURL"`
+	FromEmail *jt.JSONType[*netMail.Address] `json:"fromEmail"`
+	Timeout   *jt.JSONType[time.Duration]    `json:"timeout"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (c Config) DefaultsAndValidate() (Config, error) {
+	if c.APIKey == "" {
+		return Config{}, fmt.Errorf("Resend API key not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.FromEmail.Get() == nil {
+		return Config{}, fmt.Errorf("Resend from email not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.BaseURL.Get() == nil {
+		u, err := url.Parse(DefaultBaseURL)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse default Resend base URL: %w", err)
+		}
+		c.BaseURL = jt.New(u)
+	}
+	if c.Timeout.Get() == 0 {
+		c.Timeout = jt.New(10 * time.Second)
+	}
+	return c, nil
+}
+
+type resend struct {
+	apiKey               string
+	endpoint             string
+	from                 *netMail.Address
+	http                 *http.Client
+	magicLinkHTMLTmpl    *template.Template
+	magicLinkTxtTmpl     *textTemplate.Template
+	magicLinkOTPHTMLTmpl *template.Template
+	magicLinkOTPTxtTmpl  *textTemplate.Template
+	oTPHTMLTmpl          *template.Template
+	oTPTxtTmpl           *textTemplate.Template
+}
+
+// NewProvider creates a new Resend email provider.
+func NewProvider(conf Config) (email.Provider, error) {
+	endpoint, err := url.JoinPath(conf.BaseURL.Get().String(), "emails")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Resend API endpoint: %w", err)
+	}
+	magicLinkHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkHTMLTemplate))
+	magicLinkTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkTextTemplate))
+	magicLinkOTPHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkOTPHTMLTemplate))
+	magicLinkOTPTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkOTPTextTemplate))
+	otpHTMLTmpl := template.Must(template.New("").Parse(email.OTPHTMLTemplate))
+	otpTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.OTPTextTemplate))
+	r := resend{
+		apiKey:   conf.APIKey,
+		endpoint: endpoint,
+		from:     conf.FromEmail.Get(),
+		http: &http.Client{
+			Timeout: conf.Timeout.Get(),
+		},
+		magicLinkHTMLTmpl:    magicLinkHTMLTmpl,
+		magicLinkTxtTmpl:     magicLinkTxtTmpl,
+		magicLinkOTPHTMLTmpl: magicLinkOTPHTMLTmpl,
+		magicLinkOTPTxtTmpl:  magicLinkOTPTxtTmpl,
+		oTPHTMLTmpl:          otpHTMLTmpl,
+		oTPTxtTmpl:           otpTxtTmpl,
+	}
+	return r, nil
+}
+
+func (r resend) SendMagicLink(ctx context.Context, e email.Email) error {
+	return r.sendEmail(ctx, e, r.magicLinkHTMLTmpl, r.magicLinkTxtTmpl)
+}
+func (r resend) SendMagicLinkOTP(ctx context.Context, e email.Email) error {
+	return r.sendEmail(ctx, e, r.magicLinkOTPHTMLTmpl, r.magicLinkOTPTxtTmpl)
+}
+func (r resend) SendOTP(ctx context.Context, e email.Email) error {
+	return r.sendEmail(ctx, e, r.oTPHTMLTmpl, r.oTPTxtTmpl)
+}
+
+type sendRequest struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	HTML    string   `json:"html"`
+	Text    string   `json:"text"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+	Name    string `json:"name"`
+}
+
+func (r resend) sendEmail(ctx context.Context, e email.Email, htmlTmpl *template.Template, txtTmpl *textTemplate.Template) error {
+	htmlBuf := bytes.NewBuffer(nil)
+	err := htmlTmpl.Execute(htmlBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for HTML email: %w", err)
+	}
+	textBuf := bytes.NewBuffer(nil)
+	err = txtTmpl.Execute(textBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for text email: %w", err)
+	}
+
+	body, err := json.Marshal(sendRequest{
+		From:    r.from.String(),
+		To:      []string{e.To.String()},
+		Subject: e.Subject,
+		HTML:    htmlBuf.String(),
+		Text:    textBuf.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to JSON marshal Resend request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Resend API request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set(mld.HeaderContentType, mld.ContentTypeJSON)
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email with Resend API: %w: %w", email.ErrProvider, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&errResp)
+		return fmt.Errorf("Resend API response status code %d, error %q, message %q: %w", resp.StatusCode, errResp.Name, errResp.Message, email.ErrProvider)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package resend_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	netMail "net/mail"
+	"net/url"
+	"strings"
+	"testing"
+
+	jt "github.com/MicahParks/jsontype"
+
+	"github.com/MicahParks/magiclinksdev/email"
+	"github.com/MicahParks/magiclinksdev/email/resend"
+)
+
+const apiKey = "re_api_key"
+
+func TestProvider(t *testing.T) {
+	var received struct {
+		From    string   `json:"from"`
+		To      []string `json:"to"`
+		Subject string   `json:"subject"`
+		HTML    string   `json:"html"`
+		Text    string   `json:"text"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/emails" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer "+apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"statusCode":401,"name":"validation_error","message":"API key is invalid"}`))
+			return
+		}
+		err := json.NewDecoder(r.Body).Decode(&received)
+		if err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"id":"49a3999c-0ce1-4ea6-ab68-afcd6dc2e794"}`))
+	}))
+	defer server.Close()
+
+	e := email.Email{
+		Subject: "Your one-time password",
+		TemplateData: email.OTPTemplateData{
+			Expiration:        "1h0m0s",
+			OTP:               "ABC-DEF",
+			OTPGroups:         []string{"ABC", "DEF"},
+			OTPGroupSeparator: "-",
+		},
+		To: &netMail.Address{Name: "Customer", Address: "customer@example.com"},
+	}
+
+	provider := newProvider(t, server.URL, apiKey)
+	err := provider.SendOTP(context.Background(), e)
+	if err != nil {
+		t.Fatalf("Failed to send email: %v", err)
+	}
+	if len(received.To) != 1 || received.To[0] != e.To.String() || received.Subject != e.Subject {
+		t.Fatalf("Unexpected request body: %+v", received)
+	}
+	for key, body := range map[string]string{"html": received.HTML, "text": received.Text} {
+		if !strings.Contains(body, "ABC") || !strings.Contains(body, "DEF") {
+			t.Fatalf("Expected %s to contain the OTP", key)
+		}
+	}
+
+	provider = newProvider(t, server.URL, "wrong")
+	err = provider.SendOTP(context.Background(), e)
+	if !errors.Is(err, email.ErrProvider) {
+		t.Fatalf("Expected error %v, got %v", email.ErrProvider, err)
+	}
+}
+
+func newProvider(t *testing.T, baseURL, key string) email.Provider {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		t.Fatalf("Failed to parse base URL: %v", err)
+	}
+	conf, err := resend.Config{
+		APIKey:    key,
+		BaseURL:   jt.New(u),
+		FromEmail: jt.New(&netMail.Address{Name: "magiclinksdev", Address: "sender@example.com"}),
+	}.DefaultsAndValidate()
+	if err != nil {
+		t.Fatalf("Failed to validate config: %v", err)
+	}
+	provider, err := resend.NewProvider(conf)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	return provider
+}
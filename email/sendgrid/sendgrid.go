@@ -34,12 +34,14 @@ func (s Config) DefaultsAndValidate() (Config, error) {
 }
 
 type sendGrid struct {
-	client            *sendgrid.Client
-	from              *netMail.Address
-	magicLinkHTMLTmpl *template.Template
-	magicLinkTxtTmpl  *textTemplate.Template
-	oTPHTMLTmpl       *template.Template
-	oTPTxtTmpl        *textTemplate.Template
+	client               *sendgrid.Client
+	from                 *netMail.Address
+	magicLinkHTMLTmpl    *template.Template
+	magicLinkTxtTmpl     *textTemplate.Template
+	magicLinkOTPHTMLTmpl *template.Template
+	magicLinkOTPTxtTmpl  *textTemplate.Template
+	oTPHTMLTmpl          *template.Template
+	oTPTxtTmpl           *textTemplate.Template
 }
 
 // NewProvider creates a new SendGrid email provider.
@@ -47,15 +49,19 @@ func NewProvider(conf Config) (email.Provider, error) {
 	client := sendgrid.NewSendClient(conf.APIKey)
 	magicLinkHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkHTMLTemplate))
 	magicLinkTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkTextTemplate))
+	magicLinkOTPHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkOTPHTMLTemplate))
+	magicLinkOTPTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkOTPTextTemplate))
 	otpHTMLTmpl := template.Must(template.New("").Parse(email.OTPHTMLTemplate))
 	otpTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.OTPTextTemplate))
 	s := sendGrid{
-		client:            client,
-		from:              conf.FromEmail.Get(),
-		magicLinkHTMLTmpl: magicLinkHTMLTmpl,
-		magicLinkTxtTmpl:  magicLinkTxtTmpl,
-		oTPHTMLTmpl:       otpHTMLTmpl,
-		oTPTxtTmpl:        otpTxtTmpl,
+		client:               client,
+		from:                 conf.FromEmail.Get(),
+		magicLinkHTMLTmpl:    magicLinkHTMLTmpl,
+		magicLinkTxtTmpl:     magicLinkTxtTmpl,
+		magicLinkOTPHTMLTmpl: magicLinkOTPHTMLTmpl,
+		magicLinkOTPTxtTmpl:  magicLinkOTPTxtTmpl,
+		oTPHTMLTmpl:          otpHTMLTmpl,
+		oTPTxtTmpl:           otpTxtTmpl,
 	}
 	return s, nil
 }
@@ -63,6 +69,9 @@ func NewProvider(conf Config) (email.Provider, error) {
 func (s sendGrid) SendMagicLink(ctx context.Context, e email.Email) error {
 	return s.sendEmail(ctx, e, s.magicLinkHTMLTmpl, s.magicLinkTxtTmpl)
 }
+func (s sendGrid) SendMagicLinkOTP(ctx context.Context, e email.Email) error {
+	return s.sendEmail(ctx, e, s.magicLinkOTPHTMLTmpl, s.magicLinkOTPTxtTmpl)
+}
 func (s sendGrid) SendOTP(ctx context.Context, e email.Email) error {
 	return s.sendEmail(ctx, e, s.oTPHTMLTmpl, s.oTPTxtTmpl)
 }
@@ -55,12 +55,14 @@ func (c Config) DefaultsAndValidate() (Config, error) {
 
 // SES is an email provider that uses AWS SES.
 type SES struct {
-	from              *netMail.Address
-	magicLinkHTMLTmpl *template.Template
-	magicLinkTxtTmpl  *textTemplate.Template
-	oTPHTMLTmpl       *template.Template
-	oTPTxtTmpl        *textTemplate.Template
-	ses               *sesv2.Client
+	from                 *netMail.Address
+	magicLinkHTMLTmpl    *template.Template
+	magicLinkTxtTmpl     *textTemplate.Template
+	magicLinkOTPHTMLTmpl *template.Template
+	magicLinkOTPTxtTmpl  *textTemplate.Template
+	oTPHTMLTmpl          *template.Template
+	oTPTxtTmpl           *textTemplate.Template
+	ses                  *sesv2.Client
 }
 
 // NewProvider creates a new SES provider. It will create an AWS session using the provided configuration.
@@ -89,15 +91,19 @@ func NewProvider(ctx context.Context, conf Config) (SES, error) {
 func NewProviderInitialized(conf InitializedConfig, svc *sesv2.Client) (SES, error) {
 	magicLinkHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkHTMLTemplate))
 	magicLinkTxtTML := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkTextTemplate))
+	magicLinkOTPHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkOTPHTMLTemplate))
+	magicLinkOTPTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkOTPTextTemplate))
 	otpHTMLTmpl := template.Must(template.New("").Parse(email.OTPHTMLTemplate))
 	otpTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.OTPTextTemplate))
 	s := SES{
-		from:              conf.FromEmail,
-		magicLinkHTMLTmpl: magicLinkHTMLTmpl,
-		magicLinkTxtTmpl:  magicLinkTxtTML,
-		oTPHTMLTmpl:       otpHTMLTmpl,
-		oTPTxtTmpl:        otpTxtTmpl,
-		ses:               svc,
+		from:                 conf.FromEmail,
+		magicLinkHTMLTmpl:    magicLinkHTMLTmpl,
+		magicLinkTxtTmpl:     magicLinkTxtTML,
+		magicLinkOTPHTMLTmpl: magicLinkOTPHTMLTmpl,
+		magicLinkOTPTxtTmpl:  magicLinkOTPTxtTmpl,
+		oTPHTMLTmpl:          otpHTMLTmpl,
+		oTPTxtTmpl:           otpTxtTmpl,
+		ses:                  svc,
 	}
 	return s, nil
 }
@@ -105,6 +111,9 @@ func NewProviderInitialized(conf InitializedConfig, svc *sesv2.Client) (SES, err
 func (s SES) SendMagicLink(ctx context.Context, e email.Email) error {
 	return s.sendEmail(ctx, e, s.magicLinkHTMLTmpl, s.magicLinkTxtTmpl)
 }
+func (s SES) SendMagicLinkOTP(ctx context.Context, e email.Email) error {
+	return s.sendEmail(ctx, e, s.magicLinkOTPHTMLTmpl, s.magicLinkOTPTxtTmpl)
+}
 func (s SES) SendOTP(ctx context.Context, e email.Email) error {
 	return s.sendEmail(ctx, e, s.oTPHTMLTmpl, s.oTPTxtTmpl)
 }
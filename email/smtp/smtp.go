@@ -0,0 +1,329 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	netMail "net/mail"
+	netSMTP "net/smtp"
+	"strconv"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	jt "github.com/MicahParks/jsontype"
+
+	"github.com/MicahParks/magiclinksdev/email"
+)
+
+const (
+	// AuthLogin indicates the LOGIN SASL mechanism should be used to authenticate with the SMTP server.
+	AuthLogin AuthMechanism = "login"
+	// AuthNone indicates no authentication should be used with the SMTP server.
+	AuthNone AuthMechanism = "none"
+	// AuthPlain indicates the PLAIN SASL mechanism should be used to authenticate with the SMTP server.
+	AuthPlain AuthMechanism = "plain"
+)
+
+// AuthMechanism is a set of string constants for the SASL mechanisms used to authenticate with the SMTP server.
+type AuthMechanism string
+
+const (
+	// TLSModeImplicit indicates the connection to the SMTP server should use TLS from the start, typically on port 465.
+	TLSModeImplicit TLSMode = "implicit"
+	// TLSModeNone indicates the connection to the SMTP server should not be encrypted. Credentials are only sent over
+	// an unencrypted connection to localhost.
+	TLSModeNone TLSMode = "none"
+	// TLSModeSTARTTLS indicates the connection to the SMTP server should be upgraded to TLS with the STARTTLS command,
+	// typically on port 587.
+	TLSModeSTARTTLS TLSMode = "starttls"
+)
+
+// TLSMode is a set of string constants for how the connection to the SMTP server is encrypted.
+type TLSMode string
+
+// Config is the configuration for the SMTP provider. By default, the connection is upgraded with STARTTLS on port 587
+// and PLAIN authentication is used if a username is provided. InsecureSkipVerify disables verification of the SMTP
+// server's certificate and is only meant for internal relays with self-signed certificates.
+type Config struct {
+	Auth               AuthMechanism                  `json:"auth"`
+	FromEmail          *jt.JSONType[*netMail.Address] `json:"fromEmail"`
+	Host               string                         `json:"host"`
+	InsecureSkipVerify bool                           `json:"insecureSkipVerify"`
+	LocalName          string                         `json:"localName"`
+	Password           string                         `json:"password"`
+	Port               uint16                         `json:"port"`
+	Timeout            *jt.JSONType[time.Duration]    `json:"timeout"`
+	TLS                TLSMode                        `json:"tls"`
+	Username           string                         `json:"username"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (c Config) DefaultsAndValidate() (Config, error) {
+	if c.FromEmail.Get() == nil {
+		return Config{}, fmt.Errorf("SMTP from email not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.Host == "" {
+		return Config{}, fmt.Errorf("SMTP host not provided in configuration: %w", jt.ErrDefaultsAndValidate)
+	}
+	if c.TLS == "" {
+		c.TLS = TLSModeSTARTTLS
+	}
+	if c.Port == 0 {
+		switch c.TLS {
+		case TLSModeImplicit:
+			c.Port = 465
+		case TLSModeNone:
+			c.Port = 25
+		default:
+			c.Port = 587
+		}
+	}
+	switch c.TLS {
+	case TLSModeImplicit, TLSModeNone, TLSModeSTARTTLS:
+	default:
+		return Config{}, fmt.Errorf("invalid SMTP TLS mode %q: %w", c.TLS, jt.ErrDefaultsAndValidate)
+	}
+	if c.Auth == "" {
+		c.Auth = AuthNone
+		if c.Username != "" {
+			c.Auth = AuthPlain
+		}
+	}
+	switch c.Auth {
+	case AuthNone:
+	case AuthLogin, AuthPlain:
+		if c.Username == "" {
+			return Config{}, fmt.Errorf("SMTP username required for %q authentication: %w", c.Auth, jt.ErrDefaultsAndValidate)
+		}
+	default:
+		return Config{}, fmt.Errorf("invalid SMTP authentication mechanism %q: %w", c.Auth, jt.ErrDefaultsAndValidate)
+	}
+	if c.LocalName == "" {
+		c.LocalName = "localhost"
+	}
+	if c.Timeout.Get() == 0 {
+		c.Timeout = jt.New(10 * time.Second)
+	}
+	return c, nil
+}
+
+type smtp struct {
+	addr                 string
+	auth                 netSMTP.Auth
+	from                 *netMail.Address
+	host                 string
+	localName            string
+	magicLinkHTMLTmpl    *template.Template
+	magicLinkTxtTmpl     *textTemplate.Template
+	magicLinkOTPHTMLTmpl *template.Template
+	magicLinkOTPTxtTmpl  *textTemplate.Template
+	oTPHTMLTmpl          *template.Template
+	oTPTxtTmpl           *textTemplate.Template
+	timeout              time.Duration
+	tlsConfig            *tls.Config
+	tlsMode              TLSMode
+}
+
+// NewProvider creates a new SMTP email provider.
+func NewProvider(conf Config) (email.Provider, error) {
+	var auth netSMTP.Auth
+	switch conf.Auth {
+	case AuthLogin:
+		auth = loginAuth{
+			host:     conf.Host,
+			password: conf.Password,
+			username: conf.Username,
+		}
+	case AuthPlain:
+		auth = netSMTP.PlainAuth("", conf.Username, conf.Password, conf.Host)
+	}
+	magicLinkHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkHTMLTemplate))
+	magicLinkTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkTextTemplate))
+	magicLinkOTPHTMLTmpl := template.Must(template.New("").Parse(email.MagicLinkOTPHTMLTemplate))
+	magicLinkOTPTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.MagicLinkOTPTextTemplate))
+	otpHTMLTmpl := template.Must(template.New("").Parse(email.OTPHTMLTemplate))
+	otpTxtTmpl := textTemplate.Must(textTemplate.New("").Parse(email.OTPTextTemplate))
+	s := smtp{
+		addr:                 net.JoinHostPort(conf.Host, strconv.Itoa(int(conf.Port))),
+		auth:                 auth,
+		from:                 conf.FromEmail.Get(),
+		host:                 conf.Host,
+		localName:            conf.LocalName,
+		magicLinkHTMLTmpl:    magicLinkHTMLTmpl,
+		magicLinkTxtTmpl:     magicLinkTxtTmpl,
+		magicLinkOTPHTMLTmpl: magicLinkOTPHTMLTmpl,
+		magicLinkOTPTxtTmpl:  magicLinkOTPTxtTmpl,
+		oTPHTMLTmpl:          otpHTMLTmpl,
+		oTPTxtTmpl:           otpTxtTmpl,
+		timeout:              conf.Timeout.Get(),
+		tlsConfig: &tls.Config{
+			InsecureSkipVerify: conf.InsecureSkipVerify,
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         conf.Host,
+		},
+		tlsMode: conf.TLS,
+	}
+	return s, nil
+}
+
+func (s smtp) SendMagicLink(ctx context.Context, e email.Email) error {
+	return s.sendEmail(ctx, e, s.magicLinkHTMLTmpl, s.magicLinkTxtTmpl)
+}
+func (s smtp) SendMagicLinkOTP(ctx context.Context, e email.Email) error {
+	return s.sendEmail(ctx, e, s.magicLinkOTPHTMLTmpl, s.magicLinkOTPTxtTmpl)
+}
+func (s smtp) SendOTP(ctx context.Context, e email.Email) error {
+	return s.sendEmail(ctx, e, s.oTPHTMLTmpl, s.oTPTxtTmpl)
+}
+
+func (s smtp) sendEmail(ctx context.Context, e email.Email, htmlTmpl *template.Template, txtTmpl *textTemplate.Template) error {
+	htmlBuf := bytes.NewBuffer(nil)
+	err := htmlTmpl.Execute(htmlBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for HTML email: %w", err)
+	}
+	textBuf := bytes.NewBuffer(nil)
+	err = txtTmpl.Execute(textBuf, e.TemplateData)
+	if err != nil {
+		return fmt.Errorf("failed to execute template for text email: %w", err)
+	}
+
+	message, err := email.MIMEMessage(s.from, e, htmlBuf.Bytes(), textBuf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to create MIME message: %w", err)
+	}
+
+	err = s.send(ctx, e.To.Address, message)
+	if err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w: %w", email.ErrProvider, err)
+	}
+
+	return nil
+}
+
+func (s smtp) send(ctx context.Context, to string, message []byte) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(s.timeout)
+	}
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return fmt.Errorf("failed to set SMTP connection deadline: %w", err)
+	}
+
+	c, err := netSMTP.NewClient(conn, s.host)
+	if err != nil {
+		return fmt.Errorf("failed to create SMTP client: %w", err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer c.Close()
+
+	err = c.Hello(s.localName)
+	if err != nil {
+		return fmt.Errorf("failed to greet SMTP server: %w", err)
+	}
+	if s.tlsMode == TLSModeSTARTTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		err = c.StartTLS(s.tlsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("SMTP server does not support authentication")
+		}
+		err = c.Auth(s.auth)
+		if err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	err = c.Mail(s.from.Address)
+	if err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	err = c.Rcpt(to)
+	if err != nil {
+		return fmt.Errorf("failed to set recipient: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message data: %w", err)
+	}
+	_, err = w.Write(message)
+	if err != nil {
+		return fmt.Errorf("failed to write message data: %w", err)
+	}
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("failed to finish message data: %w", err)
+	}
+
+	err = c.Quit()
+	if err != nil {
+		return fmt.Errorf("failed to quit SMTP session: %w", err)
+	}
+
+	return nil
+}
+
+func (s smtp) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout: s.timeout,
+	}
+	if s.tlsMode == TLSModeImplicit {
+		tlsDialer := &tls.Dialer{
+			NetDialer: dialer,
+			Config:    s.tlsConfig,
+		}
+		return tlsDialer.DialContext(ctx, "tcp", s.addr)
+	}
+	return dialer.DialContext(ctx, "tcp", s.addr)
+}
+
+// loginAuth implements the LOGIN SASL mechanism, which the standard library does not.
+type loginAuth struct {
+	host     string
+	password string
+	username string
+}
+
+func (l loginAuth) Start(server *netSMTP.ServerInfo) (proto string, toServer []byte, err error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != l.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+func (l loginAuth) Next(fromServer []byte, more bool) (toServer []byte, err error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(l.username), nil
+	case "password:":
+		return []byte(l.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN challenge from SMTP server: %q", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
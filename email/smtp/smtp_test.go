@@ -0,0 +1,423 @@
+package smtp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	netMail "net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	jt "github.com/MicahParks/jsontype"
+
+	"github.com/MicahParks/magiclinksdev/email"
+	"github.com/MicahParks/magiclinksdev/email/smtp"
+)
+
+const (
+	password = "password"
+	username = "username"
+)
+
+func TestProvider(t *testing.T) {
+	tlsConfig := stubTLSConfig(t)
+	for _, tc := range []struct {
+		name        string
+		auth        smtp.AuthMechanism
+		password    string
+		tlsMode     smtp.TLSMode
+		expectedErr error
+	}{
+		{
+			name:     "STARTTLSPlain",
+			auth:     smtp.AuthPlain,
+			password: password,
+			tlsMode:  smtp.TLSModeSTARTTLS,
+		},
+		{
+			name:     "ImplicitTLSLogin",
+			auth:     smtp.AuthLogin,
+			password: password,
+			tlsMode:  smtp.TLSModeImplicit,
+		},
+		{
+			name:    "NoTLSNoAuth",
+			auth:    smtp.AuthNone,
+			tlsMode: smtp.TLSModeNone,
+		},
+		{
+			name:        "WrongPassword",
+			auth:        smtp.AuthPlain,
+			password:    "wrong",
+			tlsMode:     smtp.TLSModeSTARTTLS,
+			expectedErr: email.ErrProvider,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			stub := newStubServer(t, tlsConfig, tc.tlsMode == smtp.TLSModeImplicit)
+			host, port, err := net.SplitHostPort(stub.listener.Addr().String())
+			if err != nil {
+				t.Fatalf("Failed to split stub server address: %v", err)
+			}
+			p, err := strconv.ParseUint(port, 10, 16)
+			if err != nil {
+				t.Fatalf("Failed to parse stub server port: %v", err)
+			}
+
+			conf := smtp.Config{
+				Auth:               tc.auth,
+				FromEmail:          jt.New(&netMail.Address{Name: "magiclinksdev", Address: "sender@example.com"}),
+				Host:               host,
+				InsecureSkipVerify: true,
+				Password:           tc.password,
+				Port:               uint16(p),
+				TLS:                tc.tlsMode,
+			}
+			if tc.auth != smtp.AuthNone {
+				conf.Username = username
+			}
+			conf, err = conf.DefaultsAndValidate()
+			if err != nil {
+				t.Fatalf("Failed to validate config: %v", err)
+			}
+			provider, err := smtp.NewProvider(conf)
+			if err != nil {
+				t.Fatalf("Failed to create provider: %v", err)
+			}
+
+			e := email.Email{
+				Subject: "Your one-time password ✨",
+				TemplateData: email.OTPTemplateData{
+					Expiration:        "1h0m0s",
+					OTP:               "ABC-DEF",
+					OTPGroups:         []string{"ABC", "DEF"},
+					OTPGroupSeparator: "-",
+				},
+				To: &netMail.Address{Name: "Customer", Address: "customer@example.com"},
+			}
+			err = provider.SendOTP(ctx, e)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+
+			var received stubMessage
+			select {
+			case received = <-stub.messages:
+			case <-ctx.Done():
+				t.Fatalf("Stub server did not receive a message")
+			}
+			if received.from != "sender@example.com" || received.to != "customer@example.com" {
+				t.Fatalf("Unexpected envelope: from %q, to %q", received.from, received.to)
+			}
+			if received.tls != (tc.tlsMode != smtp.TLSModeNone) {
+				t.Fatalf("Expected TLS to be %t", tc.tlsMode != smtp.TLSModeNone)
+			}
+			checkMessage(t, received.data, e)
+		})
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		conf         smtp.Config
+		expectedAuth smtp.AuthMechanism
+		expectedPort uint16
+	}{
+		{
+			name:         "STARTTLS",
+			conf:         smtp.Config{Username: username},
+			expectedAuth: smtp.AuthPlain,
+			expectedPort: 587,
+		},
+		{
+			name:         "Implicit",
+			conf:         smtp.Config{TLS: smtp.TLSModeImplicit},
+			expectedAuth: smtp.AuthNone,
+			expectedPort: 465,
+		},
+		{
+			name:         "None",
+			conf:         smtp.Config{TLS: smtp.TLSModeNone},
+			expectedAuth: smtp.AuthNone,
+			expectedPort: 25,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.conf.FromEmail = jt.New(&netMail.Address{Address: "sender@example.com"})
+			tc.conf.Host = "smtp.example.com"
+			conf, err := tc.conf.DefaultsAndValidate()
+			if err != nil {
+				t.Fatalf("Failed to validate config: %v", err)
+			}
+			if conf.Auth != tc.expectedAuth || conf.Port != tc.expectedPort {
+				t.Fatalf("Expected auth %q and port %d, got %q and %d", tc.expectedAuth, tc.expectedPort, conf.Auth, conf.Port)
+			}
+		})
+	}
+
+	_, err := smtp.Config{
+		Auth:      smtp.AuthLogin,
+		FromEmail: jt.New(&netMail.Address{Address: "sender@example.com"}),
+		Host:      "smtp.example.com",
+	}.DefaultsAndValidate()
+	if !errors.Is(err, jt.ErrDefaultsAndValidate) {
+		t.Fatalf("Expected LOGIN authentication without a username to fail validation, got %v", err)
+	}
+}
+
+func checkMessage(t *testing.T, data []byte, e email.Email) {
+	msg, err := netMail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("Failed to decode subject: %v", err)
+	}
+	if subject != e.Subject {
+		t.Fatalf("Expected subject %q, got %q", e.Subject, subject)
+	}
+	to, err := msg.Header.AddressList("To")
+	if err != nil || len(to) != 1 || to[0].Address != e.To.Address {
+		t.Fatalf("Unexpected To header %q: %v", msg.Header.Get("To"), err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative message, got %q: %v", mediaType, err)
+	}
+
+	contentTypes := make([]string, 0, 2)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read message part: %v", err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Failed to read message part body: %v", err)
+		}
+		contentType := part.Header.Get("Content-Type")
+		contentTypes = append(contentTypes, contentType)
+		if !strings.Contains(string(body), "ABC") || !strings.Contains(string(body), "DEF") {
+			t.Fatalf("Expected %q part to contain the OTP", contentType)
+		}
+	}
+	if len(contentTypes) != 2 || !strings.HasPrefix(contentTypes[0], "text/plain") || !strings.HasPrefix(contentTypes[1], "text/html") {
+		t.Fatalf("Expected text and HTML parts, got %v", contentTypes)
+	}
+}
+
+type stubMessage struct {
+	data []byte
+	from string
+	tls  bool
+	to   string
+}
+
+// stubServer is a minimal in-process SMTP server that accepts a single message per connection.
+type stubServer struct {
+	implicit  bool
+	listener  net.Listener
+	messages  chan stubMessage
+	tlsConfig *tls.Config
+}
+
+func newStubServer(t *testing.T, tlsConfig *tls.Config, implicit bool) *stubServer {
+	var listener net.Listener
+	var err error
+	if implicit {
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	s := &stubServer{
+		implicit:  implicit,
+		listener:  listener,
+		messages:  make(chan stubMessage, 1),
+		tlsConfig: tlsConfig,
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *stubServer) serve(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	text := textproto.NewConn(conn)
+	isTLS := s.implicit
+	var msg stubMessage
+
+	reply := func(format string, args ...any) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 stub ESMTP") {
+		return
+	}
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"stub"}
+			if !isTLS {
+				lines = append(lines, "STARTTLS")
+			}
+			lines = append(lines, "AUTH PLAIN LOGIN")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				if !reply("250%s%s", sep, l) {
+					return
+				}
+			}
+		case "STARTTLS":
+			if !reply("220 Ready to start TLS") {
+				return
+			}
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			err = tlsConn.Handshake()
+			if err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(tlsConn)
+			isTLS = true
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			var user, pass string
+			switch strings.ToUpper(mechanism) {
+			case "PLAIN":
+				decoded, err := base64.StdEncoding.DecodeString(initial)
+				if err != nil {
+					return
+				}
+				parts := strings.Split(string(decoded), "\x00")
+				if len(parts) == 3 {
+					user, pass = parts[1], parts[2]
+				}
+			case "LOGIN":
+				user = s.challenge(text, "Username:")
+				pass = s.challenge(text, "Password:")
+			}
+			if user != username || pass != password {
+				reply("535 5.7.8 Authentication credentials invalid")
+				continue
+			}
+			if !reply("235 2.7.0 Authentication successful") {
+				return
+			}
+		case "MAIL":
+			msg.from = envelopeAddress(arg)
+			reply("250 OK")
+		case "RCPT":
+			msg.to = envelopeAddress(arg)
+			reply("250 OK")
+		case "DATA":
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			msg.data, err = io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			msg.tls = isTLS
+			s.messages <- msg
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *stubServer) challenge(text *textproto.Conn, prompt string) string {
+	err := text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+	if err != nil {
+		return ""
+	}
+	line, err := text.ReadLine()
+	if err != nil {
+		return ""
+	}
+	decoded, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return ""
+	}
+	return string(decoded)
+}
+
+func envelopeAddress(arg string) string {
+	_, after, _ := strings.Cut(arg, "<")
+	address, _, _ := strings.Cut(after, ">")
+	return address
+}
+
+func stubTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "stub"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
@@ -54,6 +54,34 @@ type MagicLinkTemplateData struct {
 	ReCATPTCHA   bool
 }
 
+// MagicLinkOTPHTMLTemplate is the HTML template for the email containing both a magic link and an OTP.
+//
+//go:embed magic_link_otp_html.gohtml
+var MagicLinkOTPHTMLTemplate string
+
+// MagicLinkOTPTextTemplate is the text template for the email containing both a magic link and an OTP.
+//
+//go:embed magic_link_otp_text.gotxt
+var MagicLinkOTPTextTemplate string
+
+// MagicLinkOTPTemplateData is the data for the email template containing both a magic link and an OTP.
+type MagicLinkOTPTemplateData struct {
+	ButtonText        string
+	Expiration        string
+	Greeting          string
+	MagicLink         string
+	Meta              TemplateMetadata
+	OTP               string
+	OTPGroups         []string
+	OTPGroupSeparator string
+	Subtitle          string
+	Title             string
+	LogoImageURL      string
+	LogoClickURL      string
+	LogoAltText       string
+	ReCATPTCHA        bool
+}
+
 // OTPHTMLTemplate is the HTML template for the OTP email.
 //
 //go:embed otp_html.gohtml
@@ -66,16 +94,18 @@ var OTPTextTemplate string
 
 // OTPTemplateData is the data for the OTP email template.
 type OTPTemplateData struct {
-	Expiration   string
-	Greeting     string
-	MagicLink    string
-	Meta         TemplateMetadata
-	OTP          string
-	Subtitle     string
-	Title        string
-	LogoImageURL string
-	LogoClickURL string
-	LogoAltText  string
+	Expiration        string
+	Greeting          string
+	MagicLink         string
+	Meta              TemplateMetadata
+	OTP               string
+	OTPGroups         []string
+	OTPGroupSeparator string
+	Subtitle          string
+	Title             string
+	LogoImageURL      string
+	LogoClickURL      string
+	LogoAltText       string
 }
 
 // TemplateMetadata contains non-configurable metadata for the email templates.
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/client"
+	"github.com/MicahParks/magiclinksdev/mldtest"
+	"github.com/MicahParks/magiclinksdev/model"
+)
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logger := slog.Default()
+
+	var (
+		clientIP     string
+		code         string
+		codeVerifier string
+		userAgent    string
+	)
+	flag.StringVar(&clientIP, "ip", "", "The IP address of the client that clicked the magic link.")
+	flag.StringVar(&code, "code", "", "The code delivered to the redirect URL when the magic link was clicked.")
+	flag.StringVar(&codeVerifier, "verifier", "", "The code verifier used to create the magic link's code challenge.")
+	flag.StringVar(&userAgent, "user-agent", "", "The User-Agent header of the client that clicked the magic link.")
+	flag.Parse()
+	if code == "" || codeVerifier == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	c, err := client.New(mldtest.APIKey, mldtest.Aud, mldtest.BaseURL, mldtest.Iss, client.Options{})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create client.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	req := model.MagicLinkExchangeRequest{
+		MagicLinkExchangeParams: model.MagicLinkExchangeParams{
+			ClientIP:     clientIP,
+			Code:         code,
+			CodeVerifier: codeVerifier,
+			UserAgent:    userAgent,
+		},
+	}
+	resp, mldErr, err := c.MagicLinkExchange(ctx, req)
+	if err != nil {
+		if mldErr.Code != 0 {
+			logger = logger.With(
+				"code", mldErr.Code,
+				"message", mldErr.Message,
+				"requestUUID", mldErr.RequestMetadata.UUID,
+			)
+		}
+		logger.ErrorContext(ctx, "Failed to exchange magic link code.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to marshal response.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	println(string(data))
+}
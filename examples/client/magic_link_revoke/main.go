@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/client"
+	"github.com/MicahParks/magiclinksdev/mldtest"
+	"github.com/MicahParks/magiclinksdev/model"
+)
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logger := slog.Default()
+
+	var (
+		id     string
+		secret string
+	)
+	flag.StringVar(&id, "id", "", "The ID of the magic link to revoke.")
+	flag.StringVar(&secret, "secret", "", "The secret of the magic link to revoke.")
+	flag.Parse()
+	if (id == "") == (secret == "") {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	c, err := client.New(mldtest.APIKey, mldtest.Aud, mldtest.BaseURL, mldtest.Iss, client.Options{})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create client.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	req := model.MagicLinkRevokeRequest{
+		MagicLinkRevokeParams: model.MagicLinkRevokeParams{
+			ID:     id,
+			Secret: secret,
+		},
+	}
+	resp, mldErr, err := c.MagicLinkRevoke(ctx, req)
+	if err != nil {
+		if mldErr.Code != 0 {
+			logger = logger.With(
+				"code", mldErr.Code,
+				"message", mldErr.Message,
+				"requestUUID", mldErr.RequestMetadata.UUID,
+			)
+		}
+		logger.ErrorContext(ctx, "Failed to revoke magic link.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to marshal response.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	println(string(data))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/client"
+	"github.com/MicahParks/magiclinksdev/mldtest"
+	"github.com/MicahParks/magiclinksdev/model"
+)
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logger := slog.Default()
+
+	var id string
+	flag.StringVar(&id, "id", "", "The ID of the magic link.")
+	flag.Parse()
+	if id == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	c, err := client.New(mldtest.APIKey, mldtest.Aud, mldtest.BaseURL, mldtest.Iss, client.Options{})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create client.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	req := model.MagicLinkStatusRequest{
+		MagicLinkStatusParams: model.MagicLinkStatusParams{
+			ID: id,
+		},
+	}
+	resp, mldErr, err := c.MagicLinkStatus(ctx, req)
+	if err != nil {
+		if mldErr.Code != 0 {
+			logger = logger.With(
+				"code", mldErr.Code,
+				"message", mldErr.Message,
+				"requestUUID", mldErr.RequestMetadata.UUID,
+			)
+		}
+		logger.ErrorContext(ctx, "Failed to read magic link status.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to marshal response.",
+			mld.LogErr, err,
+		)
+		os.Exit(1)
+	}
+
+	println(string(data))
+}
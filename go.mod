@@ -21,6 +21,7 @@ require (
 	github.com/tidwall/sjson v1.2.5
 	golang.org/x/mod v0.21.0
 	golang.org/x/time v0.9.0
+	rsc.io/qr v0.2.0
 )
 
 require (
@@ -138,9 +138,36 @@ func (s *Server) addRegisteredClaims(ctx context.Context, args model.ValidJWTCre
 func (s *Server) createLinkParams(ctx context.Context, args model.ValidMagicLinkCreateParams) (magiclink.CreateParams, error) {
 	var createParams magiclink.CreateParams
 
-	edited, err := s.addRegisteredClaims(ctx, args.JWTCreateParams)
+	claims, kID, err := s.signingClaims(ctx, args.JWTCreateParams)
 	if err != nil {
-		return createParams, fmt.Errorf("failed to add registered claims to JWT claims: %w", err)
+		return createParams, err
+	}
+
+	createParams = magiclink.CreateParams{
+		Binding:          args.Binding,
+		CodeChallenge:    args.CodeChallenge,
+		CrossDevice:      args.CrossDevice,
+		Expires:          time.Now().Add(args.Lifespan),
+		JWTClaims:        claims,
+		JWTKeyID:         &kID,
+		MaxVisits:        args.MaxVisits,
+		QRCode:           args.QRCode,
+		RedirectQueryKey: args.RedirectQueryKey,
+		RedirectURL:      args.RedirectURL,
+		ResponseMode:     args.ResponseMode,
+		State:            args.State,
+		StateQueryKey:    args.StateQueryKey,
+	}
+
+	return createParams, nil
+}
+
+// signingClaims prepares the JWT claims and signing key ID for a JWT that is signed later, such as when a magic link is
+// visited or an OTP is validated.
+func (s *Server) signingClaims(ctx context.Context, args model.ValidJWTCreateParams) (magiclinksdev.SigningBytesClaims, string, error) {
+	edited, err := s.addRegisteredClaims(ctx, args)
+	if err != nil {
+		return magiclinksdev.SigningBytesClaims{}, "", fmt.Errorf("failed to add registered claims to JWT claims: %w", err)
 	}
 
 	claims := magiclinksdev.SigningBytesClaims{
@@ -148,24 +175,15 @@ func (s *Server) createLinkParams(ctx context.Context, args model.ValidMagicLink
 	}
 
 	options := storage.ReadSigningKeyOptions{
-		JWTAlg: args.JWTCreateParams.Alg,
+		JWTAlg: args.Alg,
 	}
 	jwk, err := s.Store.SigningKeyRead(ctx, options)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return createParams, fmt.Errorf("could not fing signing key with specified JWT alg: %w", ErrJWTAlgNotFound)
+			return magiclinksdev.SigningBytesClaims{}, "", fmt.Errorf("could not fing signing key with specified JWT alg: %w", ErrJWTAlgNotFound)
 		}
-		return createParams, fmt.Errorf("failed to get JWT signing key: %w", err)
+		return magiclinksdev.SigningBytesClaims{}, "", fmt.Errorf("failed to get JWT signing key: %w", err)
 	}
 
-	kID := jwk.Marshal().KID
-	createParams = magiclink.CreateParams{
-		Expires:          time.Now().Add(args.Lifespan),
-		JWTClaims:        claims,
-		JWTKeyID:         &kID,
-		RedirectQueryKey: args.RedirectQueryKey,
-		RedirectURL:      args.RedirectURL,
-	}
-
-	return createParams, nil
+	return claims, jwk.Marshal().KID, nil
 }
@@ -22,14 +22,29 @@ func (s *Server) HandleMagicLinkCreate(ctx context.Context, req model.ValidMagic
 
 	resp := model.MagicLinkCreateResponse{
 		MagicLinkCreateResults: model.MagicLinkCreateResults{
-			MagicLink: magicLinkRes.MagicLink.String(),
-			Secret:    magicLinkRes.Secret,
+			CrossDeviceToken: magicLinkRes.CrossDeviceToken,
+			ID:               magicLinkRes.ID,
+			MagicLink:        magicLinkRes.MagicLink.String(),
+			Secret:           magicLinkRes.Secret,
 		},
 		RequestMetadata: model.RequestMetadata{
 			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
 		},
 	}
 
+	if linkParams.QRCode {
+		resp.MagicLinkCreateResults.QRCodePNG, err = magiclink.QRCodePNG(magicLinkRes.MagicLink)
+		if err != nil {
+			return model.MagicLinkCreateResponse{}, fmt.Errorf("failed to create QR code PNG: %w", err)
+		}
+		svg, err := magiclink.QRCodeSVG(magicLinkRes.MagicLink)
+		if err != nil {
+			return model.MagicLinkCreateResponse{}, fmt.Errorf("failed to create QR code SVG: %w", err)
+		}
+		resp.MagicLinkCreateResults.QRCodeSVG = string(svg)
+		resp.MagicLinkCreateResults.QRCodeToken = magicLinkRes.QRCodeToken
+	}
+
 	return resp, nil
 }
 
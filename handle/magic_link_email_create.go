@@ -21,6 +21,13 @@ func (s *Server) HandleMagicLinkEmailCreate(ctx context.Context, req model.Valid
 		return model.MagicLinkEmailCreateResponse{}, fmt.Errorf("failed to create magic link: %w", err)
 	}
 
+	if s.Config.MagicLinkResend.Enabled {
+		err = s.Store.MagicLinkResendCreate(ctx, magicLinkRes.Secret, req.Resend)
+		if err != nil {
+			return model.MagicLinkEmailCreateResponse{}, fmt.Errorf("failed to save magic link resend request: %w", err)
+		}
+	}
+
 	meta := email.TemplateMetadata{
 		HTMLInstruction: fmt.Sprintf("Magic link from %s.", emailParams.ServiceName),
 		HTMLTitle:       fmt.Sprintf("Magic link from %s", emailParams.ServiceName),
@@ -52,8 +59,10 @@ func (s *Server) HandleMagicLinkEmailCreate(ctx context.Context, req model.Valid
 	}
 
 	linkCreateResponse := model.MagicLinkCreateResults{
-		MagicLink: magicLinkRes.MagicLink.String(),
-		Secret:    magicLinkRes.Secret,
+		CrossDeviceToken: magicLinkRes.CrossDeviceToken,
+		ID:               magicLinkRes.ID,
+		MagicLink:        magicLinkRes.MagicLink.String(),
+		Secret:           magicLinkRes.Secret,
 	}
 	resp := model.MagicLinkEmailCreateResponse{
 		MagicLinkEmailCreateResults: model.MagicLinkEmailCreateResults{
@@ -0,0 +1,34 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/magiclink"
+	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+)
+
+func (s *Server) HandleMagicLinkExchange(ctx context.Context, req model.ValidMagicLinkExchangeRequest) (model.MagicLinkExchangeResponse, error) {
+	exchangeParams := magiclink.ExchangeParams{
+		ClientIP:     req.MagicLinkExchangeParams.ClientIP,
+		Code:         req.MagicLinkExchangeParams.Code,
+		CodeVerifier: req.MagicLinkExchangeParams.CodeVerifier,
+		UserAgent:    req.MagicLinkExchangeParams.UserAgent,
+	}
+	jwtB64, _, err := s.MagicLink.Exchange(ctx, exchangeParams)
+	if err != nil {
+		return model.MagicLinkExchangeResponse{}, fmt.Errorf("failed to exchange magic link code: %w", err)
+	}
+	resp := model.MagicLinkExchangeResponse{
+		MagicLinkExchangeResults: model.MagicLinkExchangeResults{
+			JWT: jwtB64,
+		},
+		RequestMetadata: model.RequestMetadata{
+			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
+		},
+	}
+	return resp, nil
+}
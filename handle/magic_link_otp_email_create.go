@@ -0,0 +1,92 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/config"
+	"github.com/MicahParks/magiclinksdev/email"
+	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+	"github.com/MicahParks/magiclinksdev/otp"
+)
+
+func (s *Server) HandleMagicLinkOTPEmailCreate(ctx context.Context, req model.ValidMagicLinkOTPEmailCreateRequest) (model.MagicLinkOTPEmailCreateResponse, error) {
+	emailParams := req.MagicLinkEmailCreateParams
+	linkParams := req.MagicLinkCreateParams
+
+	magicLinkRes, err := s.createLink(ctx, linkParams)
+	if err != nil {
+		return model.MagicLinkOTPEmailCreateResponse{}, fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	otpParams, err := s.createOTPParams(ctx, req.OTPCreateParams)
+	if err != nil {
+		return model.MagicLinkOTPEmailCreateResponse{}, fmt.Errorf("failed to create OTP create args: %w", err)
+	}
+
+	otpRes, err := s.Store.OTPCreate(ctx, otpParams)
+	if err != nil {
+		return model.MagicLinkOTPEmailCreateResponse{}, fmt.Errorf("failed to create OTP: %w", err)
+	}
+
+	err = s.Store.MagicLinkOTPLink(ctx, magicLinkRes.ID, otpRes.ID)
+	if err != nil {
+		return model.MagicLinkOTPEmailCreateResponse{}, fmt.Errorf("failed to link OTP to magic link: %w", err)
+	}
+
+	meta := email.TemplateMetadata{
+		HTMLInstruction: fmt.Sprintf("Magic link and one-time password from %s.", emailParams.ServiceName),
+		HTMLTitle:       fmt.Sprintf("Magic link and one-time password from %s", emailParams.ServiceName),
+		MSOButtonStop:   email.MSOButtonStop,
+		MSOButtonStart:  email.MSOButtonStart,
+		MSOHead:         email.MSOHead,
+	}
+	tData := email.MagicLinkOTPTemplateData{
+		ButtonText:        emailParams.ButtonText,
+		Expiration:        linkParams.Lifespan.String(),
+		Greeting:          emailParams.Greeting,
+		MagicLink:         magicLinkRes.MagicLink.String(),
+		Meta:              meta,
+		OTP:               otp.Group(otpRes.OTP, otpParams.GroupSize),
+		OTPGroups:         otp.Groups(otpRes.OTP, otpParams.GroupSize),
+		OTPGroupSeparator: otp.GroupSeparator,
+		Subtitle:          emailParams.SubTitle,
+		Title:             emailParams.Title,
+		LogoImageURL:      emailParams.LogoImageURL,
+		LogoClickURL:      emailParams.LogoClickURL,
+		LogoAltText:       "logo",
+		ReCATPTCHA:        s.Config.PreventRobots.Method == config.PreventRobotsReCAPTCHAV3,
+	}
+	e := email.Email{
+		Subject:      emailParams.Subject,
+		TemplateData: tData,
+		To:           emailParams.ToEmail,
+	}
+	err = s.EmailProvider.SendMagicLinkOTP(ctx, e)
+	if err != nil {
+		return model.MagicLinkOTPEmailCreateResponse{}, fmt.Errorf("failed to send email: %w", err)
+	}
+
+	resp := model.MagicLinkOTPEmailCreateResponse{
+		MagicLinkOTPEmailCreateResults: model.MagicLinkOTPEmailCreateResults{
+			MagicLinkCreateResults: model.MagicLinkCreateResults{
+				CrossDeviceToken: magicLinkRes.CrossDeviceToken,
+				ID:               magicLinkRes.ID,
+				MagicLink:        magicLinkRes.MagicLink.String(),
+				Secret:           magicLinkRes.Secret,
+			},
+			OTPCreateResults: model.OTPCreateResults{
+				ID:  otpRes.ID,
+				OTP: otp.Group(otpRes.OTP, otpParams.GroupSize),
+			},
+		},
+		RequestMetadata: model.RequestMetadata{
+			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
+		},
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,79 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+	"github.com/MicahParks/magiclinksdev/storage"
+)
+
+var (
+	// ErrResendNotFound is returned when the email for a magic link cannot be resent.
+	ErrResendNotFound = errors.New("magic link cannot be resent")
+	// ErrResendRateLimit is returned when the recipient of a magic link email has exceeded the resend rate limit.
+	ErrResendRateLimit = errors.New("magic link resend rate limit exceeded")
+)
+
+// HandleMagicLinkResend resends the email for an expired magic link created by HandleMagicLinkEmailCreate. The same
+// email parameters, redirect, and JWT claims are used for the new magic link. The email for each magic link can only be
+// resent once. The response does not include the new magic link, because the request is not authenticated.
+func (s *Server) HandleMagicLinkResend(ctx context.Context, secret string) (model.MagicLinkResendResponse, error) {
+	if !s.Config.MagicLinkResend.Enabled {
+		return model.MagicLinkResendResponse{}, fmt.Errorf("magic link resend is disabled: %w", ErrResendNotFound)
+	}
+
+	resend, err := s.Store.MagicLinkResendRead(ctx, secret)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return model.MagicLinkResendResponse{}, fmt.Errorf("failed to find magic link to resend: %w", ErrResendNotFound)
+		}
+		return model.MagicLinkResendResponse{}, fmt.Errorf("failed to read magic link resend request: %w", err)
+	}
+
+	// The rate limit is checked before the resend request is taken, so a limited request does not hold the row lock or
+	// use up the resend.
+	address, err := mail.ParseAddress(resend.Request.MagicLinkEmailCreateParams.ToEmail)
+	if err != nil {
+		return model.MagicLinkResendResponse{}, fmt.Errorf("failed to parse magic link resend email address: %w", err)
+	}
+	if !s.ResendLimiter.Allow(strings.ToLower(address.Address)) {
+		return model.MagicLinkResendResponse{}, ErrResendRateLimit
+	}
+
+	resend, err = s.Store.MagicLinkResendTake(ctx, secret)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return model.MagicLinkResendResponse{}, fmt.Errorf("failed to find magic link to resend: %w", ErrResendNotFound)
+		}
+		return model.MagicLinkResendResponse{}, fmt.Errorf("failed to take magic link resend request: %w", err)
+	}
+
+	sa, err := s.Store.SARead(ctx, resend.SAUUID)
+	if err != nil {
+		return model.MagicLinkResendResponse{}, fmt.Errorf("failed to read service account for magic link resend: %w", err)
+	}
+	ctx = context.WithValue(ctx, ctxkey.ServiceAccount, sa)
+
+	validation := s.Config.Validation
+	validation.RedirectAllowlist = sa.RedirectAllowlist
+	validated, err := resend.Request.Validate(validation)
+	if err != nil {
+		return model.MagicLinkResendResponse{}, fmt.Errorf("failed to validate magic link resend request: %w", err)
+	}
+
+	created, err := s.HandleMagicLinkEmailCreate(ctx, validated)
+	if err != nil {
+		return model.MagicLinkResendResponse{}, fmt.Errorf("failed to resend magic link email: %w", err)
+	}
+
+	resp := model.MagicLinkResendResponse{
+		RequestMetadata: created.RequestMetadata,
+	}
+
+	return resp, nil
+}
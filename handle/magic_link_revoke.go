@@ -0,0 +1,30 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/magiclink"
+	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+)
+
+func (s *Server) HandleMagicLinkRevoke(ctx context.Context, req model.ValidMagicLinkRevokeRequest) (response model.MagicLinkRevokeResponse, err error) {
+	revokeParams := magiclink.RevokeParams{
+		ID:     req.MagicLinkRevokeParams.ID,
+		Secret: req.MagicLinkRevokeParams.Secret,
+	}
+	err = s.MagicLink.Revoke(ctx, revokeParams)
+	if err != nil {
+		return model.MagicLinkRevokeResponse{}, fmt.Errorf("failed to revoke magic link: %w", err)
+	}
+	resp := model.MagicLinkRevokeResponse{
+		MagicLinkRevokeResults: model.MagicLinkRevokeResults{},
+		RequestMetadata: model.RequestMetadata{
+			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
+		},
+	}
+	return resp, nil
+}
@@ -0,0 +1,33 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+)
+
+func (s *Server) HandleMagicLinkStatus(ctx context.Context, req model.ValidMagicLinkStatusRequest) (model.MagicLinkStatusResponse, error) {
+	status, err := s.MagicLink.Status(ctx, req.MagicLinkStatusParams.ID)
+	if err != nil {
+		return model.MagicLinkStatusResponse{}, fmt.Errorf("failed to read magic link status: %w", err)
+	}
+	resp := model.MagicLinkStatusResponse{
+		MagicLinkStatusResults: model.MagicLinkStatusResults{
+			Created:   status.Created,
+			Expires:   status.Expires,
+			ID:        status.ID,
+			MaxVisits: status.MaxVisits,
+			Revoked:   status.Revoked,
+			Visited:   status.Visited,
+			Visits:    status.Visits,
+		},
+		RequestMetadata: model.RequestMetadata{
+			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
+		},
+	}
+	return resp, nil
+}
@@ -10,20 +10,32 @@ import (
 	"github.com/MicahParks/magiclinksdev/model"
 	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
 	"github.com/MicahParks/magiclinksdev/otp"
+	"github.com/MicahParks/magiclinksdev/storage"
 )
 
 func (s *Server) HandleOTPCreate(ctx context.Context, req model.ValidOTPCreateRequest) (response model.OTPCreateResponse, err error) {
-	otpParams := createOTPParams(req.OTPCreateParams)
+	otpParams, err := s.createOTPParams(ctx, req.OTPCreateParams)
+	if err != nil {
+		return model.OTPCreateResponse{}, fmt.Errorf("failed to create OTP create args: %w", err)
+	}
 
 	otpRes, err := s.Store.OTPCreate(ctx, otpParams)
 	if err != nil {
 		return model.OTPCreateResponse{}, fmt.Errorf("failed to create OTP: %w", err)
 	}
 
+	resend := storage.OTPResend{
+		OTPCreateParams: req.Resend.OTPCreateParams,
+	}
+	err = s.Store.OTPResendCreate(ctx, otpRes.ID, resend)
+	if err != nil {
+		return model.OTPCreateResponse{}, fmt.Errorf("failed to save OTP resend request: %w", err)
+	}
+
 	resp := model.OTPCreateResponse{
 		OTPCreateResults: model.OTPCreateResults{
 			ID:  otpRes.ID,
-			OTP: otpRes.OTP,
+			OTP: otp.Group(otpRes.OTP, otpParams.GroupSize),
 		},
 		RequestMetadata: model.RequestMetadata{
 			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
@@ -33,13 +45,29 @@ func (s *Server) HandleOTPCreate(ctx context.Context, req model.ValidOTPCreateRe
 	return resp, nil
 }
 
-func createOTPParams(otpParams model.ValidOTPCreateParams) otp.CreateParams {
-	params := otp.CreateParams{
+func (s *Server) createOTPParams(ctx context.Context, otpParams model.ValidOTPCreateParams) (otp.CreateParams, error) {
+	params := generateOTPParams(otpParams)
+	params.Expires = time.Now().Add(otpParams.Lifespan)
+	if otpParams.JWTCreateParams != nil {
+		claims, kID, err := s.signingClaims(ctx, *otpParams.JWTCreateParams)
+		if err != nil {
+			return otp.CreateParams{}, err
+		}
+		params.JWTClaims = claims
+		params.JWTKeyID = &kID
+	}
+	return params, nil
+}
+
+func generateOTPParams(otpParams model.ValidOTPCreateParams) otp.CreateParams {
+	return otp.CreateParams{
+		Alphabet:          otpParams.Alphabet,
+		CaseInsensitive:   otpParams.CaseInsensitive,
 		CharSetAlphaLower: otpParams.CharSetAlphaLower,
 		CharSetAlphaUpper: otpParams.CharSetAlphaUpper,
 		CharSetNumeric:    otpParams.CharSetNumeric,
-		Expires:           time.Now().Add(otpParams.Lifespan),
+		ExcludeLookAlikes: otpParams.ExcludeLookAlikes,
+		GroupSize:         otpParams.GroupSize,
 		Length:            otpParams.Length,
 	}
-	return params
 }
@@ -3,47 +3,38 @@ package handle
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/MicahParks/magiclinksdev/email"
 	"github.com/MicahParks/magiclinksdev/model"
 	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+	"github.com/MicahParks/magiclinksdev/otp"
+	"github.com/MicahParks/magiclinksdev/storage"
 )
 
 func (s *Server) HandleOTPEmailCreate(ctx context.Context, req model.ValidOTPEmailCreateRequest) (response model.OTPEmailCreateResponse, err error) {
-	emailParams := req.OTPEmailCreateParams
-	otpParams := createOTPParams(req.OTPCreateParams)
+	otpParams, err := s.createOTPParams(ctx, req.OTPCreateParams)
+	if err != nil {
+		return model.OTPEmailCreateResponse{}, fmt.Errorf("failed to create OTP create args: %w", err)
+	}
 
 	otpRes, err := s.Store.OTPCreate(ctx, otpParams)
 	if err != nil {
 		return model.OTPEmailCreateResponse{}, fmt.Errorf("failed to create OTP: %w", err)
 	}
 
-	meta := email.TemplateMetadata{
-		HTMLInstruction: fmt.Sprintf("One-time password from %s.", emailParams.ServiceName),
-		HTMLTitle:       fmt.Sprintf("One-time password from %s", emailParams.ServiceName),
-		MSOButtonStop:   email.MSOButtonStop,
-		MSOButtonStart:  email.MSOButtonStart,
-		MSOHead:         email.MSOHead,
+	resend := storage.OTPResend{
+		OTPCreateParams:      req.Resend.OTPCreateParams,
+		OTPEmailCreateParams: &req.Resend.OTPEmailCreateParams,
 	}
-	tData := email.OTPTemplateData{
-		Expiration:   req.OTPCreateParams.Lifespan.String(),
-		Greeting:     emailParams.Greeting,
-		Meta:         meta,
-		OTP:          otpRes.OTP,
-		Subtitle:     emailParams.SubTitle,
-		Title:        emailParams.Title,
-		LogoImageURL: emailParams.LogoImageURL,
-		LogoClickURL: emailParams.LogoClickURL,
-		LogoAltText:  "logo",
-	}
-	e := email.Email{
-		Subject:      emailParams.Subject,
-		TemplateData: tData,
-		To:           emailParams.ToEmail,
+	err = s.Store.OTPResendCreate(ctx, otpRes.ID, resend)
+	if err != nil {
+		return model.OTPEmailCreateResponse{}, fmt.Errorf("failed to save OTP resend request: %w", err)
 	}
-	err = s.EmailProvider.SendOTP(ctx, e)
+
+	err = s.sendOTPEmail(ctx, req.OTPEmailCreateParams, otpRes, req.OTPCreateParams.Lifespan)
 	if err != nil {
 		return model.OTPEmailCreateResponse{}, fmt.Errorf("failed to send email: %w", err)
 	}
@@ -52,7 +43,7 @@ func (s *Server) HandleOTPEmailCreate(ctx context.Context, req model.ValidOTPEma
 		OTPEmailCreateResults: model.OTPEmailCreateResults{
 			OTPCreateResults: model.OTPCreateResults{
 				ID:  otpRes.ID,
-				OTP: otpRes.OTP,
+				OTP: otp.Group(otpRes.OTP, otpParams.GroupSize),
 			},
 		},
 		RequestMetadata: model.RequestMetadata{
@@ -62,3 +53,32 @@ func (s *Server) HandleOTPEmailCreate(ctx context.Context, req model.ValidOTPEma
 
 	return resp, nil
 }
+
+func (s *Server) sendOTPEmail(ctx context.Context, emailParams model.ValidOTPEmailCreateParams, otpRes otp.CreateResult, expiration time.Duration) error {
+	meta := email.TemplateMetadata{
+		HTMLInstruction: fmt.Sprintf("One-time password from %s.", emailParams.ServiceName),
+		HTMLTitle:       fmt.Sprintf("One-time password from %s", emailParams.ServiceName),
+		MSOButtonStop:   email.MSOButtonStop,
+		MSOButtonStart:  email.MSOButtonStart,
+		MSOHead:         email.MSOHead,
+	}
+	tData := email.OTPTemplateData{
+		Expiration:        expiration.String(),
+		Greeting:          emailParams.Greeting,
+		Meta:              meta,
+		OTP:               otp.Group(otpRes.OTP, otpRes.CreateParams.GroupSize),
+		OTPGroups:         otp.Groups(otpRes.OTP, otpRes.CreateParams.GroupSize),
+		OTPGroupSeparator: otp.GroupSeparator,
+		Subtitle:          emailParams.SubTitle,
+		Title:             emailParams.Title,
+		LogoImageURL:      emailParams.LogoImageURL,
+		LogoClickURL:      emailParams.LogoClickURL,
+		LogoAltText:       "logo",
+	}
+	e := email.Email{
+		Subject:      emailParams.Subject,
+		TemplateData: tData,
+		To:           emailParams.ToEmail,
+	}
+	return s.EmailProvider.SendOTP(ctx, e)
+}
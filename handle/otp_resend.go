@@ -0,0 +1,86 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+	"github.com/MicahParks/magiclinksdev/otp"
+	"github.com/MicahParks/magiclinksdev/storage"
+)
+
+var (
+	// ErrOTPResendNotFound is returned when an OTP cannot be resent because it does not exist, has expired, has been used,
+	// or was created along with a magic link.
+	ErrOTPResendNotFound = errors.New("OTP cannot be resent")
+	// ErrOTPResendCooldown is returned when an OTP is resent before the cooldown since it was last sent has elapsed.
+	ErrOTPResendCooldown = errors.New("OTP resend cooldown has not elapsed")
+	// ErrOTPResendLimit is returned when an OTP has already been resent the maximum number of times.
+	ErrOTPResendLimit = errors.New("OTP resend limit reached")
+)
+
+// HandleOTPResend replaces the code for an existing OTP under the same ID, which invalidates the previous code. If the
+// OTP was created by HandleOTPEmailCreate, the new code is emailed with the same email parameters. The expiration of the
+// OTP does not change.
+func (s *Server) HandleOTPResend(ctx context.Context, req model.ValidOTPResendRequest) (model.OTPResendResponse, error) {
+	id := req.OTPResendParams.ID
+
+	resend, err := s.Store.OTPResendRead(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return model.OTPResendResponse{}, fmt.Errorf("failed to find OTP to resend: %w", ErrOTPResendNotFound)
+		}
+		return model.OTPResendResponse{}, fmt.Errorf("failed to read OTP resend request: %w", err)
+	}
+
+	conf := s.Config.OTPResend
+	if resend.ResendCount >= conf.MaxResends {
+		return model.OTPResendResponse{}, fmt.Errorf("OTP has been resent %d times: %w", resend.ResendCount, ErrOTPResendLimit)
+	}
+	if time.Since(resend.Sent) < conf.Cooldown.Get() {
+		return model.OTPResendResponse{}, fmt.Errorf("OTP was sent at %s: %w", resend.Sent, ErrOTPResendCooldown)
+	}
+
+	validated, err := resend.OTPCreateParams.Validate(s.Config.Validation)
+	if err != nil {
+		return model.OTPResendResponse{}, fmt.Errorf("failed to validate OTP resend create params: %w", err)
+	}
+
+	otpRes, err := s.Store.OTPRegenerate(ctx, id, generateOTPParams(validated))
+	if err != nil {
+		if errors.Is(err, otp.ErrOTPInvalid) {
+			return model.OTPResendResponse{}, fmt.Errorf("failed to regenerate OTP: %w", ErrOTPResendNotFound)
+		}
+		return model.OTPResendResponse{}, fmt.Errorf("failed to regenerate OTP: %w", err)
+	}
+
+	if resend.OTPEmailCreateParams != nil {
+		emailParams, err := resend.OTPEmailCreateParams.Validate(s.Config.Validation)
+		if err != nil {
+			return model.OTPResendResponse{}, fmt.Errorf("failed to validate OTP resend email params: %w", err)
+		}
+		err = s.sendOTPEmail(ctx, emailParams, otpRes, time.Until(otpRes.CreateParams.Expires).Round(time.Second))
+		if err != nil {
+			return model.OTPResendResponse{}, fmt.Errorf("failed to resend OTP email: %w", err)
+		}
+	}
+
+	resp := model.OTPResendResponse{
+		OTPResendResults: model.OTPResendResults{
+			Emailed:          resend.OTPEmailCreateParams != nil,
+			ID:               otpRes.ID,
+			OTP:              otp.Group(otpRes.OTP, validated.GroupSize),
+			ResendsRemaining: conf.MaxResends - resend.ResendCount - 1,
+		},
+		RequestMetadata: model.RequestMetadata{
+			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
+		},
+	}
+
+	return resp, nil
+}
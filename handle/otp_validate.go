@@ -3,20 +3,41 @@ package handle
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/MicahParks/magiclinksdev/magiclink"
 	"github.com/MicahParks/magiclinksdev/model"
 	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
 )
 
 func (s *Server) HandleOTPValidate(ctx context.Context, req model.ValidOTPValidateRequest) (response model.OTPValidateResponse, err error) {
-	err = s.Store.OTPValidate(ctx, req.OTPValidateParams.ID, req.OTPValidateParams.OTP)
+	otpRes, err := s.Store.OTPValidate(ctx, req.OTPValidateParams.ID, req.OTPValidateParams.OTP)
 	if err != nil {
 		return model.OTPValidateResponse{}, fmt.Errorf("failed to validate OTP: %w", err)
 	}
+
+	var jwtB64 string
+	if otpRes.CreateParams.JWTClaims != nil {
+		// The OTP may be entered long after it was created, so the JWT's lifespan starts when it is validated.
+		claims := otpRes.CreateParams.JWTClaims
+		if renewable, ok := claims.(magiclink.RenewableClaims); ok {
+			claims, err = renewable.Renew(time.Now())
+			if err != nil {
+				return model.OTPValidateResponse{}, fmt.Errorf("failed to renew JWT claims: %w", err)
+			}
+		}
+		jwtB64, err = s.MagicLink.SignJWT(ctx, claims, otpRes.CreateParams.JWTKeyID, "")
+		if err != nil {
+			return model.OTPValidateResponse{}, fmt.Errorf("failed to sign JWT: %w", err)
+		}
+	}
+
 	resp := model.OTPValidateResponse{
-		OTPValidateResults: model.OTPValidateResults{},
+		OTPValidateResults: model.OTPValidateResults{
+			JWT: jwtB64,
+		},
 		RequestMetadata: model.RequestMetadata{
 			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
 		},
@@ -26,13 +26,17 @@ type Server struct {
 	Store          storage.Storage
 	Logger         *slog.Logger
 	MiddlewareHook MiddlewareHook
+	ResendLimiter  rlimit.RateLimiter
 }
 
 // MiddlewareToggle contains fields to turn middleware on and off.
 type MiddlewareToggle struct {
-	Admin     bool
-	Authn     bool
-	CommitTx  bool
+	Admin    bool
+	Authn    bool
+	CommitTx bool
+	// NoTx skips the request's transaction, so handlers must begin their own. It cannot be combined with the other
+	// toggles, because they use the request's transaction.
+	NoTx      bool
 	RateLimit bool
 }
 
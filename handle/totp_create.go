@@ -0,0 +1,52 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/magiclink"
+	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+	"github.com/MicahParks/magiclinksdev/otp"
+)
+
+func (s *Server) HandleTOTPCreate(ctx context.Context, req model.ValidTOTPCreateRequest) (response model.TOTPCreateResponse, err error) {
+	totpParams := otp.TOTPCreateParams{
+		AccountName:   req.TOTPCreateParams.AccountName,
+		Issuer:        req.TOTPCreateParams.Issuer,
+		RecoveryCodes: req.TOTPCreateParams.RecoveryCodes,
+	}
+
+	totpRes, err := s.Store.TOTPCreate(ctx, totpParams)
+	if err != nil {
+		return model.TOTPCreateResponse{}, fmt.Errorf("failed to create TOTP: %w", err)
+	}
+
+	uri := totpRes.URI()
+	png, err := magiclink.QRCodePNG(uri)
+	if err != nil {
+		return model.TOTPCreateResponse{}, fmt.Errorf("failed to create QR code PNG: %w", err)
+	}
+	svg, err := magiclink.QRCodeSVG(uri)
+	if err != nil {
+		return model.TOTPCreateResponse{}, fmt.Errorf("failed to create QR code SVG: %w", err)
+	}
+
+	resp := model.TOTPCreateResponse{
+		TOTPCreateResults: model.TOTPCreateResults{
+			ID:            totpRes.ID,
+			QRCodePNG:     png,
+			QRCodeSVG:     string(svg),
+			RecoveryCodes: totpRes.RecoveryCodes,
+			Secret:        otp.TOTPBase32.EncodeToString(totpRes.Secret),
+			URI:           uri.String(),
+		},
+		RequestMetadata: model.RequestMetadata{
+			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
+		},
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,32 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/model"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+	"github.com/MicahParks/magiclinksdev/otp"
+)
+
+func (s *Server) HandleTOTPValidate(ctx context.Context, req model.ValidTOTPValidateRequest) (response model.TOTPValidateResponse, err error) {
+	params := otp.TOTPValidateParams{
+		Code:         req.TOTPValidateParams.Code,
+		ID:           req.TOTPValidateParams.ID,
+		RecoveryCode: req.TOTPValidateParams.RecoveryCode,
+		Window:       s.Config.TOTP.Window,
+	}
+	err = s.Store.TOTPValidate(ctx, params)
+	if err != nil {
+		return model.TOTPValidateResponse{}, fmt.Errorf("failed to validate TOTP: %w", err)
+	}
+	resp := model.TOTPValidateResponse{
+		TOTPValidateResults: model.TOTPValidateResults{},
+		RequestMetadata: model.RequestMetadata{
+			UUID: ctx.Value(ctxkey.RequestUUID).(uuid.UUID),
+		},
+	}
+	return resp, nil
+}
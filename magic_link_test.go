@@ -6,10 +6,12 @@ import (
 	"crypto/ed25519"
 	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,10 +20,12 @@ import (
 	"github.com/google/uuid"
 
 	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/magiclink"
 	"github.com/MicahParks/magiclinksdev/model"
 	"github.com/MicahParks/magiclinksdev/network"
 	"github.com/MicahParks/magiclinksdev/network/middleware"
 	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+	"github.com/MicahParks/magiclinksdev/storage"
 )
 
 type testClaims struct {
@@ -207,3 +211,175 @@ func confirmUUID(t *testing.T, u string) {
 		t.Fatalf("Failed to parse UUID: %v", err)
 	}
 }
+
+func TestMagicLinkUnusableVisitNotRecorded(t *testing.T) {
+	ctx, tx := storeCtx(t)
+
+	for _, tc := range []struct {
+		name        string
+		expires     time.Time
+		revoke      bool
+		expectedErr error
+	}{
+		{
+			name:        "Expired",
+			expires:     time.Now().Add(-time.Minute),
+			expectedErr: magiclink.ErrLinkExpired,
+		},
+		{
+			name:        "Revoked",
+			expires:     time.Now().Add(time.Hour),
+			revoke:      true,
+			expectedErr: magiclink.ErrLinkRevoked,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := server.Store.MagicLinkCreate(ctx, magiclink.CreateParams{
+				Expires:     tc.expires,
+				JWTClaims:   jwt.RegisteredClaims{},
+				RedirectURL: must(url.Parse("https://github.com/MicahParks/magiclinksdev")),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create magic link: %v", err)
+			}
+			if tc.revoke {
+				err = server.Store.MagicLinkRevoke(ctx, magiclink.RevokeParams{ID: result.ID})
+				if err != nil {
+					t.Fatalf("Failed to revoke magic link: %v", err)
+				}
+			}
+
+			_, err = server.Store.MagicLinkRead(ctx, result.Secret)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tc.expectedErr, err)
+			}
+
+			status, err := server.Store.MagicLinkStatus(ctx, result.ID)
+			if err != nil {
+				t.Fatalf("Failed to read magic link status: %v", err)
+			}
+			if status.Visited != nil || status.Visits != 0 {
+				t.Fatalf("Expected failed visit to not be recorded, got %d visits", status.Visits)
+			}
+		})
+	}
+
+	err := tx.Commit(ctx)
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+}
+
+func TestMagicLinkResendUnlimited(t *testing.T) {
+	ctx, tx := storeCtx(t)
+
+	result, err := server.Store.MagicLinkCreate(ctx, magiclink.CreateParams{
+		Expires:     time.Now().Add(-time.Minute),
+		JWTClaims:   jwt.RegisteredClaims{},
+		MaxVisits:   magiclink.VisitsUnlimited,
+		RedirectURL: must(url.Parse("https://github.com/MicahParks/magiclinksdev")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %v", err)
+	}
+	err = server.Store.MagicLinkResendCreate(ctx, result.Secret, model.MagicLinkEmailCreateRequest{})
+	if err != nil {
+		t.Fatalf("Failed to store magic link resend request: %v", err)
+	}
+
+	_, err = server.Store.MagicLinkResendRead(ctx, result.Secret)
+	if err != nil {
+		t.Fatalf("Expired magic links with unlimited visits should be resendable: %v", err)
+	}
+	resend, err := server.Store.MagicLinkResendTake(ctx, result.Secret)
+	if err != nil {
+		t.Fatalf("Failed to take magic link resend request: %v", err)
+	}
+	if resend.SAUUID != assets.sa.UUID {
+		t.Fatalf("Expected resend request for service account %s, got %s", assets.sa.UUID, resend.SAUUID)
+	}
+	_, err = server.Store.MagicLinkResendRead(ctx, result.Secret)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Expected error %v after the resend request was taken, got %v", storage.ErrNotFound, err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+}
+
+func TestMagicLinkCode(t *testing.T) {
+	ctx, tx := storeCtx(t)
+
+	result, err := server.Store.MagicLinkCreate(ctx, magiclink.CreateParams{
+		CodeChallenge: magiclink.CodeChallengeS256(strings.Repeat("v", magiclink.CodeVerifierMinLength)),
+		Expires:       time.Now().Add(time.Hour),
+		JWTClaims:     jwt.RegisteredClaims{},
+		RedirectURL:   must(url.Parse("https://github.com/MicahParks/magiclinksdev")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %v", err)
+	}
+
+	replaced, err := server.Store.MagicLinkCodeCreate(ctx, result.Secret, time.Now().Add(magiclink.CodeLifespan))
+	if err != nil {
+		t.Fatalf("Failed to create code: %v", err)
+	}
+	code, err := server.Store.MagicLinkCodeCreate(ctx, result.Secret, time.Now().Add(magiclink.CodeLifespan))
+	if err != nil {
+		t.Fatalf("Failed to create code: %v", err)
+	}
+	_, err = server.Store.MagicLinkCodeTake(ctx, replaced)
+	if !errors.Is(err, magiclink.ErrLinkNotFound) {
+		t.Fatalf("Expected error %v for a replaced code, got %v", magiclink.ErrLinkNotFound, err)
+	}
+	secret, err := server.Store.MagicLinkCodeTake(ctx, code)
+	if err != nil {
+		t.Fatalf("Failed to take code: %v", err)
+	}
+	if secret != result.Secret {
+		t.Fatalf("Expected secret %s, got %s", result.Secret, secret)
+	}
+	_, err = server.Store.MagicLinkCodeTake(ctx, code)
+	if !errors.Is(err, magiclink.ErrLinkNotFound) {
+		t.Fatalf("Expected error %v for a used code, got %v", magiclink.ErrLinkNotFound, err)
+	}
+
+	expired, err := server.Store.MagicLinkCodeCreate(ctx, result.Secret, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("Failed to create code: %v", err)
+	}
+	_, err = server.Store.MagicLinkCodeTake(ctx, expired)
+	if !errors.Is(err, magiclink.ErrLinkExpired) {
+		t.Fatalf("Expected error %v for an expired code, got %v", magiclink.ErrLinkExpired, err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+}
+
+// storeCtx begins a transaction and returns a context for calling the storage directly as the test service account.
+func storeCtx(t *testing.T) (context.Context, storage.Tx) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	tx, err := server.Store.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = tx.Rollback(context.Background())
+	})
+	ctx = context.WithValue(ctx, ctxkey.Tx, tx)
+	ctx = context.WithValue(ctx, ctxkey.ServiceAccount, assets.sa)
+	return ctx, tx
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,189 @@
+package magiclink
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	jt "github.com/MicahParks/jsontype"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+const (
+	// BindingModeStrict indicates that a magic link visited by a client that does not match the magic link's
+	// ClientBinding is not redeemed.
+	BindingModeStrict BindingMode = "strict"
+	// BindingModeWarn indicates that a magic link visited by a client that does not match the magic link's
+	// ClientBinding is still redeemed, but the mismatch is logged as a warning.
+	BindingModeWarn BindingMode = "warn"
+)
+
+// ErrClientBindingMismatch is a possible error for an ErrorHandler implementation to handle. It is wrapped by
+// ClientBindingError.
+var ErrClientBindingMismatch = errors.New("client does not match the magic link's client binding")
+
+// BindingMode is a set of string constants that indicate how a magic link's ClientBinding is enforced.
+type BindingMode string
+
+// Valid confirms the BindingMode is a known value.
+func (b BindingMode) Valid() error {
+	switch b {
+	case BindingModeStrict, BindingModeWarn:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown binding mode %q", mld.ErrParams, b)
+	}
+}
+
+// BindingConfig is the configuration for enforcing client bindings on magic links.
+type BindingConfig struct {
+	// ClientIPHeader is the HTTP header containing the client's IP address, such as X-Forwarded-For. The first IP address
+	// in the header is used. Only set this when the service is behind a reverse proxy that overwrites the header. If this
+	// is empty, the IP address of the TCP connection is used.
+	ClientIPHeader string `json:"clientIPHeader"`
+	// Mode determines how mismatches are handled. If this is empty, BindingModeStrict is used.
+	Mode BindingMode `json:"mode"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (b BindingConfig) DefaultsAndValidate() (BindingConfig, error) {
+	if b.Mode == "" {
+		b.Mode = BindingModeStrict
+	}
+	err := b.Mode.Valid()
+	if err != nil {
+		return b, fmt.Errorf("%w: %s", jt.ErrDefaultsAndValidate, err)
+	}
+	return b, nil
+}
+
+// ClientIP returns the IP address of the client that sent the request, using ClientIPHeader if it is set. The boolean
+// is false if the IP address could not be determined.
+func (b BindingConfig) ClientIP(r *http.Request) (netip.Addr, bool) {
+	return clientIP(r, b.ClientIPHeader)
+}
+
+// ClientBinding restricts which clients can redeem a magic link. Empty fields are not enforced.
+type ClientBinding struct {
+	// IP is an IP address or CIDR prefix the visiting client's IP address must be within. Use of this field is
+	// OPTIONAL.
+	IP string
+	// UserAgentFingerprint is the result of UserAgentFingerprint for the User-Agent header the visiting client must
+	// send. Use of this field is OPTIONAL.
+	UserAgentFingerprint string
+}
+
+// Valid confirms the ClientBinding is valid.
+func (c ClientBinding) Valid() error {
+	if c.IP != "" {
+		_, err := parseBindingIP(c.IP)
+		if err != nil {
+			return fmt.Errorf("%w: IP must be an IP address or CIDR prefix", mld.ErrParams)
+		}
+	}
+	return nil
+}
+
+// ClientBindingError is the error given to an ErrorHandler when a magic link is visited by a client that does not
+// match the magic link's ClientBinding. In BindingModeWarn, the ErrorHandler is not used. The mismatch is logged with
+// the Config's Logger and the magic link is redeemed.
+type ClientBindingError struct {
+	// IP indicates the client's IP address did not match.
+	IP bool
+	// Mode is the BindingMode that was enforced.
+	Mode BindingMode
+	// UserAgent indicates the client's User-Agent header did not match.
+	UserAgent bool
+}
+
+// Error implements the error interface.
+func (c *ClientBindingError) Error() string {
+	var mismatched []string
+	if c.IP {
+		mismatched = append(mismatched, "IP address")
+	}
+	if c.UserAgent {
+		mismatched = append(mismatched, "user agent")
+	}
+	return fmt.Sprintf("%s: %s", ErrClientBindingMismatch, strings.Join(mismatched, " and "))
+}
+
+// Unwrap returns ErrClientBindingMismatch.
+func (c *ClientBindingError) Unwrap() error {
+	return ErrClientBindingMismatch
+}
+
+// UserAgentFingerprint creates the fingerprint of a User-Agent header for a ClientBinding. Only the fingerprint is
+// stored.
+func UserAgentFingerprint(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+func checkClientBinding(binding ClientBinding, config BindingConfig, r *http.Request) error {
+	ip, ok := clientIP(r, config.ClientIPHeader)
+	return checkBinding(binding, config.Mode, ip, ok, r.UserAgent())
+}
+
+func checkBinding(binding ClientBinding, mode BindingMode, ip netip.Addr, ipOK bool, userAgent string) error {
+	bindingErr := &ClientBindingError{
+		Mode: mode,
+	}
+	if binding.IP != "" {
+		prefix, err := parseBindingIP(binding.IP)
+		if err != nil {
+			bindingErr.IP = true
+		} else {
+			bindingErr.IP = !ipOK || !prefix.Contains(ip)
+		}
+	}
+	if binding.UserAgentFingerprint != "" {
+		fingerprint := UserAgentFingerprint(userAgent)
+		bindingErr.UserAgent = subtle.ConstantTimeCompare([]byte(binding.UserAgentFingerprint), []byte(fingerprint)) != 1
+	}
+	if !bindingErr.IP && !bindingErr.UserAgent {
+		return nil
+	}
+	return bindingErr
+}
+
+func clientIP(r *http.Request, header string) (netip.Addr, bool) {
+	raw := r.RemoteAddr
+	if header != "" {
+		if value := r.Header.Get(header); value != "" {
+			raw, _, _ = strings.Cut(value, ",")
+			raw = strings.TrimSpace(raw)
+		}
+	}
+	if host, _, err := net.SplitHostPort(raw); err == nil {
+		raw = host
+	}
+	ip, err := netip.ParseAddr(raw)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+func parseBindingIP(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	ip = ip.Unmap()
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
@@ -0,0 +1,115 @@
+package magiclink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// CaptchaQueryTokenKey is the URL query parameter key for the token produced by a CAPTCHA widget.
+const CaptchaQueryTokenKey = "token"
+
+var (
+	// ErrCaptchaCheck indicates that a CAPTCHA verification response did not pass the configured checks.
+	ErrCaptchaCheck = errors.New("CAPTCHA check failed")
+	// ErrCaptchaVerifyInputs indicates that the inputs for a CAPTCHA verification request are invalid.
+	ErrCaptchaVerifyInputs = errors.New("CAPTCHA verify inputs are invalid")
+)
+
+// CaptchaVerifierOptions are the options for the hCaptcha and Cloudflare Turnstile verifiers.
+type CaptchaVerifierOptions struct {
+	// HTTPClient is the HTTP client used to call the verify endpoint. http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// VerifyURL overrides the default verify endpoint for the CAPTCHA provider.
+	VerifyURL string
+}
+
+type captchaVerifier struct {
+	httpClient *http.Client
+	secret     string
+	verifyURL  string
+}
+
+func newCaptchaVerifier(secret, defaultVerifyURL string, options CaptchaVerifierOptions) captchaVerifier {
+	httpClient := http.DefaultClient
+	if options.HTTPClient != nil {
+		httpClient = options.HTTPClient
+	}
+	verifyURL := defaultVerifyURL
+	if options.VerifyURL != "" {
+		verifyURL = options.VerifyURL
+	}
+	return captchaVerifier{
+		httpClient: httpClient,
+		secret:     secret,
+		verifyURL:  verifyURL,
+	}
+}
+
+func (c captchaVerifier) verify(ctx context.Context, form url.Values, v any) error {
+	if form.Get("response") == "" {
+		return fmt.Errorf("%w: response from client is an empty string", ErrCaptchaVerifyInputs)
+	}
+	form.Set("secret", c.secret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create CAPTCHA verify request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform CAPTCHA verify request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("failed to parse CAPTCHA verify JSON response: %w", err)
+	}
+	return nil
+}
+
+func checkCaptcha(success bool, errorCodes []string, hostname string, allowedHostnames []string) error {
+	if len(errorCodes) > 0 {
+		return fmt.Errorf("%w: error codes: %v", ErrCaptchaCheck, errorCodes)
+	}
+	if !success {
+		return fmt.Errorf("%w: response success is false", ErrCaptchaCheck)
+	}
+	if len(allowedHostnames) != 0 && !slices.Contains(allowedHostnames, hostname) {
+		return fmt.Errorf("%w: hostname %q not in set", ErrCaptchaCheck, hostname)
+	}
+	return nil
+}
+
+// captchaRedirect implements the Redirector flow shared by CAPTCHA widgets. The template is rendered until its
+// JavaScript sends a POST request with the widget's token. The token is then verified before the magic link is visited.
+func captchaRedirect(args RedirectorParams, tmpl *template.Template, tmplData any, verify func(ctx context.Context, token string) error) {
+	ctx := args.Request.Context()
+
+	token := args.Request.URL.Query().Get(CaptchaQueryTokenKey)
+	if args.Request.Method == http.MethodPost && token != "" {
+		err := verify(ctx, token)
+		if err != nil {
+			args.Writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		jwtB64, response, err := args.ReadAndExpireLink(ctx, args.Secret)
+		if err != nil {
+			args.Writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeRedemption(args.Writer, args.Request, jwtB64, response, true)
+		return
+	}
+
+	_ = tmpl.Execute(args.Writer, tmplData)
+}
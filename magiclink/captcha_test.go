@@ -0,0 +1,219 @@
+package magiclink_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MicahParks/magiclinksdev/magiclink"
+	"github.com/MicahParks/magiclinksdev/mldtest"
+)
+
+func TestCaptchaRedirectors_Redirect(t *testing.T) {
+	const (
+		jwtB64FromBackend = "jwtB64"
+		magicLinkTarget   = "https://magiclinks.dev/"
+		siteKey           = "siteKey"
+	)
+
+	magicLinkTargetWithJWT := fmt.Sprintf("%s?%s=%s", magicLinkTarget, magiclink.DefaultRedirectQueryKey, jwtB64FromBackend)
+
+	hCaptcha := func(resp magiclink.HCaptchaResponse, err error) magiclink.Redirector {
+		conf, confErr := magiclink.HCaptchaConfig{
+			Hostname:  []string{"example.com"},
+			SecretKey: "secret",
+			TemplateData: magiclink.HCaptchaTemplateData{
+				SiteKey: siteKey,
+			},
+			Verifier: hCaptchaVerifier{resp: resp, err: err},
+		}.DefaultsAndValidate()
+		if confErr != nil {
+			t.Fatalf("Failed to apply defaults and validate hCaptcha config: %v.", confErr)
+		}
+		return magiclink.NewHCaptchaRedirector(conf)
+	}
+	turnstile := func(resp magiclink.TurnstileResponse, err error) magiclink.Redirector {
+		conf, confErr := magiclink.TurnstileConfig{
+			Action:    []string{"login"},
+			SecretKey: "secret",
+			TemplateData: magiclink.TurnstileTemplateData{
+				SiteKey: siteKey,
+			},
+			Verifier: turnstileVerifier{resp: resp, err: err},
+		}.DefaultsAndValidate()
+		if confErr != nil {
+			t.Fatalf("Failed to apply defaults and validate Cloudflare Turnstile config: %v.", confErr)
+		}
+		return magiclink.NewTurnstileRedirector(conf)
+	}
+
+	tc := []struct {
+		name       string
+		method     string
+		redirector magiclink.Redirector
+		respCode   int
+		url        string
+		visited    bool
+	}{
+		{
+			name:       "HCaptchaFrontend",
+			method:     http.MethodGet,
+			redirector: hCaptcha(magiclink.HCaptchaResponse{}, nil),
+			respCode:   http.StatusOK,
+		},
+		{
+			name:       "HCaptchaBackendError",
+			method:     http.MethodPost,
+			redirector: hCaptcha(magiclink.HCaptchaResponse{}, errors.New("error")),
+			respCode:   http.StatusBadRequest,
+			url:        "?token=error",
+		},
+		{
+			name:       "HCaptchaBackendFailure",
+			method:     http.MethodPost,
+			redirector: hCaptcha(magiclink.HCaptchaResponse{Hostname: "example.org", Success: true}, nil),
+			respCode:   http.StatusBadRequest,
+			url:        "?token=bad",
+		},
+		{
+			name:       "HCaptchaBackendSuccess",
+			method:     http.MethodPost,
+			redirector: hCaptcha(magiclink.HCaptchaResponse{Hostname: "example.com", Success: true}, nil),
+			respCode:   http.StatusOK,
+			url:        "?token=good",
+			visited:    true,
+		},
+		{
+			name:       "TurnstileFrontend",
+			method:     http.MethodGet,
+			redirector: turnstile(magiclink.TurnstileResponse{}, nil),
+			respCode:   http.StatusOK,
+		},
+		{
+			name:       "TurnstileBackendError",
+			method:     http.MethodPost,
+			redirector: turnstile(magiclink.TurnstileResponse{}, errors.New("error")),
+			respCode:   http.StatusBadRequest,
+			url:        "?token=error",
+		},
+		{
+			name:       "TurnstileBackendFailure",
+			method:     http.MethodPost,
+			redirector: turnstile(magiclink.TurnstileResponse{Action: "other", Success: true}, nil),
+			respCode:   http.StatusBadRequest,
+			url:        "?token=bad",
+		},
+		{
+			name:       "TurnstileBackendSuccess",
+			method:     http.MethodPost,
+			redirector: turnstile(magiclink.TurnstileResponse{Action: "login", Success: true}, nil),
+			respCode:   http.StatusOK,
+			url:        "?token=good",
+			visited:    true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v.", err)
+			}
+			recorder := httptest.NewRecorder()
+			visited := false
+			args := magiclink.RedirectorParams{
+				ReadAndExpireLink: func(ctx context.Context, secret string) (jwtB64 string, response magiclink.ReadResult, err error) {
+					visited = true
+					return jwtB64FromBackend, magiclink.ReadResult{
+						CreateParams: magiclink.CreateParams{
+							Expires:          time.Now().Add(mldtest.LinksExpireAfter),
+							RedirectQueryKey: magiclink.DefaultRedirectQueryKey,
+							RedirectURL:      must(url.Parse(magicLinkTarget)),
+						},
+					}, nil
+				},
+				Request: r,
+				Writer:  recorder,
+			}
+
+			tt.redirector.Redirect(args)
+
+			if recorder.Code != tt.respCode {
+				t.Fatalf("Expected status code %v, but got %v.", tt.respCode, recorder.Code)
+			}
+			if visited != tt.visited {
+				t.Fatalf("Expected magic link visited to be %v, but got %v.", tt.visited, visited)
+			}
+			body := recorder.Body.String()
+			switch {
+			case tt.visited:
+				if body != magicLinkTargetWithJWT {
+					t.Fatalf("Expected body %v, but got %v.", magicLinkTargetWithJWT, body)
+				}
+			case tt.method == http.MethodGet:
+				if !strings.Contains(body, fmt.Sprintf(`data-sitekey="%s"`, siteKey)) {
+					t.Fatalf("Expected body to contain the site key, but got %v.", body)
+				}
+			default:
+				if body != "" {
+					t.Fatalf("Expected no body, but got %v.", body)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHCaptchaVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			t.Errorf("Failed to parse form: %v.", err)
+		}
+		if r.PostForm.Get("secret") != "secret" || r.PostForm.Get("response") != "token" || r.PostForm.Get("sitekey") != "siteKey" {
+			t.Errorf("Unexpected form: %v.", r.PostForm)
+		}
+		_, _ = w.Write([]byte(`{"success":true,"hostname":"example.com"}`))
+	}))
+	defer server.Close()
+
+	verifier := magiclink.NewHCaptchaVerifier("secret", "siteKey", magiclink.CaptchaVerifierOptions{
+		HTTPClient: server.Client(),
+		VerifyURL:  server.URL,
+	})
+	resp, err := verifier.Verify(context.Background(), "token", "")
+	if err != nil {
+		t.Fatalf("Failed to verify: %v.", err)
+	}
+	err = resp.Check(magiclink.HCaptchaCheckOptions{Hostname: []string{"example.com"}})
+	if err != nil {
+		t.Fatalf("Failed to check response: %v.", err)
+	}
+
+	_, err = verifier.Verify(context.Background(), "", "")
+	if !errors.Is(err, magiclink.ErrCaptchaVerifyInputs) {
+		t.Fatalf("Expected error %v, but got %v.", magiclink.ErrCaptchaVerifyInputs, err)
+	}
+}
+
+type hCaptchaVerifier struct {
+	resp magiclink.HCaptchaResponse
+	err  error
+}
+
+func (h hCaptchaVerifier) Verify(_ context.Context, _ string, _ string) (magiclink.HCaptchaResponse, error) {
+	return h.resp, h.err
+}
+
+type turnstileVerifier struct {
+	resp magiclink.TurnstileResponse
+	err  error
+}
+
+func (t turnstileVerifier) Verify(_ context.Context, _ string, _ string) (magiclink.TurnstileResponse, error) {
+	return t.resp, t.err
+}
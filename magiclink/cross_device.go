@@ -0,0 +1,111 @@
+package magiclink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+const (
+	// CrossDeviceQueryTokenKey is the URL query parameter key for the cross-device token when collecting a
+	// cross-device JWT.
+	CrossDeviceQueryTokenKey = "token"
+
+	crossDeviceEventInterval = 500 * time.Millisecond
+)
+
+var crossDeviceTmpl = template.Must(template.New("").Parse(crossDeviceTemplate))
+
+type crossDeviceTemplateData struct {
+	CSS         template.CSS
+	Code        string
+	HTMLTitle   string
+	Instruction string
+	Title       string
+}
+
+// CrossDeviceHandler is an HTTP handler that allows the device that requested a cross-device magic link to collect the
+// JWT once the magic link has been visited on any device. The cross-device token returned when the magic link was
+// created is given with the CrossDeviceQueryTokenKey URL query parameter. The response is a JSON CrossDeviceResult. If
+// the request's Accept header includes server-sent events, an event is written each time the status is checked until
+// the status is no longer CrossDeviceStatusPending or the request's context is done. Each check is a separate call to
+// Storage, so a JWT collected during the stream does not depend on the rest of the stream succeeding. Clients should
+// reconnect if the stream ends while the status is still pending, such as when the request times out.
+func (m MagicLink) CrossDeviceHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		token := r.URL.Query().Get(CrossDeviceQueryTokenKey)
+		if token == "" {
+			m.handleError(ErrCrossDeviceMissingToken, http.StatusBadRequest, r, w)
+			return
+		}
+
+		result, err := m.Store.MagicLinkCrossDeviceCollect(ctx, token)
+		if err != nil {
+			if errors.Is(err, ErrLinkNotFound) {
+				m.handleError(err, http.StatusNotFound, r, w)
+				return
+			}
+			m.handleError(fmt.Errorf("%w: %s", ErrCrossDeviceCollect, err), http.StatusInternalServerError, r, w)
+			return
+		}
+
+		if !strings.Contains(r.Header.Get(mld.HeaderAccept), mld.ContentTypeEventStream) {
+			w.Header().Set(mld.HeaderContentType, mld.ContentTypeJSON)
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(result)
+			return
+		}
+
+		w.Header().Set(mld.HeaderContentType, mld.ContentTypeEventStream)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		rc := http.NewResponseController(w)
+		ticker := time.NewTicker(crossDeviceEventInterval)
+		defer ticker.Stop()
+		for {
+			data, err := json.Marshal(result)
+			if err != nil {
+				return
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", result.Status, data)
+			if err != nil {
+				return
+			}
+			_ = rc.Flush()
+			if result.Status != CrossDeviceStatusPending {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			result, err = m.Store.MagicLinkCrossDeviceCollect(ctx, token)
+			if err != nil {
+				return
+			}
+		}
+	})
+}
+
+func writeCrossDeviceConfirmation(w http.ResponseWriter) {
+	data := crossDeviceTemplateData{
+		CSS:         template.CSS(defaultCSS),
+		Code:        "SIGNED IN",
+		HTMLTitle:   "Magic Link - Signed In",
+		Instruction: "You can close this page and continue on the device where you requested the magic link.",
+		Title:       "Sign in complete",
+	}
+	w.Header().Set(mld.HeaderContentType, mld.ContentTypeHTML)
+	w.WriteHeader(http.StatusOK)
+	_ = crossDeviceTmpl.Execute(w, data)
+}
@@ -4,8 +4,32 @@ import (
 	_ "embed"
 )
 
+//go:embed frontend/cross_device.gohtml
+var crossDeviceTemplate string
+
+//go:embed frontend/error_page.gohtml
+var errorPageTemplate string
+
+//go:embed frontend/form_post.gohtml
+var formPostTemplate string
+
+//go:embed frontend/hcaptcha.gohtml
+var hCaptchaTemplate string
+
+//go:embed frontend/interstitial.gohtml
+var interstitialTemplate string
+
+//go:embed frontend/open_app.gohtml
+var openAppTemplate string
+
+//go:embed frontend/proof_of_work.gohtml
+var proofOfWorkTemplate string
+
 //go:embed frontend/recaptchav3.gohtml
 var recaptchav3Template string
 
+//go:embed frontend/turnstile.gohtml
+var turnstileTemplate string
+
 //go:embed frontend/default.css
 var defaultCSS string
@@ -0,0 +1,208 @@
+package magiclink
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"strings"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+const (
+	// ErrorPageKindExpired indicates the magic link expired before it was visited.
+	ErrorPageKindExpired ErrorPageKind = "expired"
+	// ErrorPageKindUsed indicates the magic link was already visited the maximum number of times.
+	ErrorPageKindUsed ErrorPageKind = "used"
+	// ErrorPageKindMalformed indicates the magic link was incomplete or otherwise malformed.
+	ErrorPageKindMalformed ErrorPageKind = "malformed"
+	// ErrorPageKindInvalid indicates the magic link does not exist, was revoked, or cannot be used by the client.
+	ErrorPageKindInvalid ErrorPageKind = "invalid"
+	// ErrorPageKindError indicates an unexpected error occurred.
+	ErrorPageKindError ErrorPageKind = "error"
+)
+
+var errorPageTmpl = template.Must(template.New("").Parse(errorPageTemplate))
+
+// ErrorPageKind is a set of string constants that describe why a magic link could not be used.
+type ErrorPageKind string
+
+// ErrorPageKindFromError determines the ErrorPageKind for an error given to an ErrorHandler.
+func ErrorPageKindFromError(err error) ErrorPageKind {
+	switch {
+	case errors.Is(err, ErrLinkExpired):
+		return ErrorPageKindExpired
+	case errors.Is(err, ErrLinkUsed):
+		return ErrorPageKindUsed
+	case errors.Is(err, ErrLinkMalformed), errors.Is(err, ErrMagicLinkMissingSecret), errors.Is(err, ErrRedeemRequest):
+		return ErrorPageKindMalformed
+	case errors.Is(err, ErrLinkNotFound), errors.Is(err, ErrClientBindingMismatch):
+		return ErrorPageKindInvalid
+	default:
+		return ErrorPageKindError
+	}
+}
+
+// ErrorPageBranding customizes the error page shown when a magic link cannot be used.
+type ErrorPageBranding struct {
+	// LogoURL is the URL of an image shown at the top of the error page.
+	LogoURL string `json:"logoURL,omitempty"`
+	// RequestNewLinkURL is a URL where the user can request a new magic link. It is not shown for
+	// ErrorPageKindError.
+	RequestNewLinkURL string `json:"requestNewLinkURL,omitempty"`
+	// ServiceName is the name of the service the magic link was for.
+	ServiceName string `json:"serviceName,omitempty"`
+	// Text is additional text shown below the description of the error.
+	Text string `json:"text,omitempty"`
+}
+
+// Override returns a copy of the ErrorPageBranding with the non-empty fields of the given ErrorPageBranding applied.
+func (b ErrorPageBranding) Override(o ErrorPageBranding) ErrorPageBranding {
+	if o.LogoURL != "" {
+		b.LogoURL = o.LogoURL
+	}
+	if o.RequestNewLinkURL != "" {
+		b.RequestNewLinkURL = o.RequestNewLinkURL
+	}
+	if o.ServiceName != "" {
+		b.ServiceName = o.ServiceName
+	}
+	if o.Text != "" {
+		b.Text = o.Text
+	}
+	return b
+}
+
+// ErrorPageConfig is the configuration for an ErrorHandler created by NewErrorPageHandler.
+type ErrorPageConfig struct {
+	// Branding is the default branding for all error pages.
+	Branding ErrorPageBranding
+	// BrandingLookup finds the branding for the magic link that caused the error, such as the branding for the owner of
+	// the magic link. Non-empty fields of the result override Branding. If it returns an error, Branding is used. Use of
+	// this field is OPTIONAL.
+	BrandingLookup func(args ErrorHandlerParams) (ErrorPageBranding, error)
+	// CSS is the CSS for the error page. If this is empty, the default CSS is used.
+	CSS template.CSS
+	// ResendURL finds the URL a form is submitted to in order to resend the email for the expired magic link. It is only
+	// called for ErrorPageKindExpired. If it returns an empty string, resending is not offered. Use of this field is
+	// OPTIONAL.
+	ResendURL func(args ErrorHandlerParams) string
+}
+
+// ErrorPageJSON is the JSON response written by an ErrorHandler created by NewErrorPageHandler for clients that do not
+// accept HTML.
+type ErrorPageJSON struct {
+	Code              int           `json:"code"`
+	Kind              ErrorPageKind `json:"kind"`
+	Message           string        `json:"message"`
+	RequestNewLinkURL string        `json:"requestNewLinkURL,omitempty"`
+	ResendURL         string        `json:"resendURL,omitempty"`
+}
+
+type errorPageTemplateData struct {
+	CSS               template.CSS
+	Code              string
+	HTMLTitle         string
+	Instruction       string
+	LogoURL           string
+	RequestNewLinkURL string
+	ResendURL         string
+	ServiceName       string
+	Text              string
+	Title             string
+}
+
+type errorPageMessage struct {
+	code        string
+	instruction string
+	title       string
+}
+
+var errorPageMessages = map[ErrorPageKind]errorPageMessage{
+	ErrorPageKindExpired: {
+		code:        "EXPIRED",
+		instruction: "This magic link has expired. Please request a new magic link.",
+		title:       "Magic link expired",
+	},
+	ErrorPageKindUsed: {
+		code:        "ALREADY USED",
+		instruction: "This magic link has already been used. Please request a new magic link.",
+		title:       "Magic link already used",
+	},
+	ErrorPageKindMalformed: {
+		code:        "MALFORMED",
+		instruction: "This magic link is incomplete. Make sure the entire link was copied or request a new magic link.",
+		title:       "Malformed magic link",
+	},
+	ErrorPageKindInvalid: {
+		code:        "INVALID",
+		instruction: "This magic link does not exist, was revoked, or cannot be used from this device. Please request a new magic link.",
+		title:       "Invalid magic link",
+	},
+	ErrorPageKindError: {
+		code:        "ERROR",
+		instruction: "Something went wrong while using this magic link. Please try again later.",
+		title:       "Something went wrong",
+	},
+}
+
+// NewErrorPageHandler creates an ErrorHandler that writes an HTML error page describing why the magic link could not
+// be used. Clients that do not accept HTML, such as API clients, are sent an ErrorPageJSON instead.
+func NewErrorPageHandler(config ErrorPageConfig) ErrorHandler {
+	if config.CSS == "" {
+		config.CSS = template.CSS(defaultCSS)
+	}
+	return ErrorHandlerFunc(func(args ErrorHandlerParams) {
+		kind := ErrorPageKindFromError(args.Err)
+		message := errorPageMessages[kind]
+
+		branding := config.Branding
+		if config.BrandingLookup != nil {
+			found, err := config.BrandingLookup(args)
+			if err == nil {
+				branding = branding.Override(found)
+			}
+		}
+		if kind == ErrorPageKindError {
+			branding.RequestNewLinkURL = ""
+		}
+		var resendURL string
+		if kind == ErrorPageKindExpired && config.ResendURL != nil {
+			resendURL = config.ResendURL(args)
+		}
+
+		args.Writer.Header().Set("Cache-Control", "no-store")
+		if !strings.Contains(args.Request.Header.Get(mld.HeaderAccept), "text/html") {
+			args.Writer.Header().Set(mld.HeaderContentType, mld.ContentTypeJSON)
+			args.Writer.WriteHeader(args.SuggestedResponseCode)
+			_ = json.NewEncoder(args.Writer).Encode(ErrorPageJSON{
+				Code:              args.SuggestedResponseCode,
+				Kind:              kind,
+				Message:           message.instruction,
+				RequestNewLinkURL: branding.RequestNewLinkURL,
+				ResendURL:         resendURL,
+			})
+			return
+		}
+
+		htmlTitle := "Magic Link - " + message.title
+		if branding.ServiceName != "" {
+			htmlTitle = branding.ServiceName + " - " + message.title
+		}
+		data := errorPageTemplateData{
+			CSS:               config.CSS,
+			Code:              message.code,
+			HTMLTitle:         htmlTitle,
+			Instruction:       message.instruction,
+			LogoURL:           branding.LogoURL,
+			RequestNewLinkURL: branding.RequestNewLinkURL,
+			ResendURL:         resendURL,
+			ServiceName:       branding.ServiceName,
+			Text:              branding.Text,
+			Title:             message.title,
+		}
+		args.Writer.Header().Set(mld.HeaderContentType, mld.ContentTypeHTML)
+		args.Writer.WriteHeader(args.SuggestedResponseCode)
+		_ = errorPageTmpl.Execute(args.Writer, data)
+	})
+}
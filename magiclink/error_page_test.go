@@ -0,0 +1,171 @@
+package magiclink_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/magiclink"
+	"github.com/MicahParks/magiclinksdev/mldtest"
+)
+
+func TestNewErrorPageHandler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	const requestNewLinkURL = "https://example.com/login"
+	const resendURL = "https://example.com/magic-link/resend"
+
+	errorHandler := magiclink.NewErrorPageHandler(magiclink.ErrorPageConfig{
+		Branding: magiclink.ErrorPageBranding{
+			RequestNewLinkURL: requestNewLinkURL,
+			ServiceName:       "Default Service",
+		},
+		BrandingLookup: func(args magiclink.ErrorHandlerParams) (magiclink.ErrorPageBranding, error) {
+			return magiclink.ErrorPageBranding{
+				ServiceName: "Branded Service",
+			}, nil
+		},
+		ResendURL: func(args magiclink.ErrorHandlerParams) string {
+			return resendURL
+		},
+	})
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{
+		errorHandler: errorHandler,
+	})
+	defer magicServer.Close()
+
+	newLink := func(expires time.Duration) magiclink.CreateResponse {
+		createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+			Expires:     time.Now().Add(expires),
+			RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create magic link: %s", err)
+		}
+		return createRes
+	}
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	used := newLink(mldtest.LinksExpireAfter)
+	resp, err := noRedirect.Get(used.MagicLink.String())
+	if err != nil {
+		t.Fatalf("Failed to GET magic link: %s", err)
+	}
+	_ = resp.Body.Close()
+
+	expired := newLink(-time.Second)
+	malformed := newLink(mldtest.LinksExpireAfter)
+	malformedQuery := malformed.MagicLink.Query()
+	malformedQuery.Set(magiclink.DefaultSecretQueryKey, malformed.Secret[:10])
+	malformed.MagicLink.RawQuery = malformedQuery.Encode()
+
+	tc := []struct {
+		name      string
+		link      *url.URL
+		kind      magiclink.ErrorPageKind
+		resendURL string
+	}{
+		{
+			name:      "Expired",
+			link:      expired.MagicLink,
+			kind:      magiclink.ErrorPageKindExpired,
+			resendURL: resendURL,
+		},
+		{
+			name: "Used",
+			link: used.MagicLink,
+			kind: magiclink.ErrorPageKindUsed,
+		},
+		{
+			name: "Malformed",
+			link: malformed.MagicLink,
+			kind: magiclink.ErrorPageKindMalformed,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name+"HTML", func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, tt.link.String(), nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %s", err)
+			}
+			req.Header.Set(mld.HeaderAccept, "text/html,application/xhtml+xml")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to GET magic link: %s", err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+			if resp.StatusCode != http.StatusNotFound || resp.Header.Get(mld.HeaderContentType) != mld.ContentTypeHTML {
+				t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get(mld.HeaderContentType))
+			}
+			if !strings.Contains(string(body), "Branded Service") {
+				t.Fatalf("Error page does not contain the looked up branding: %s", body)
+			}
+			if !strings.Contains(string(body), `href="`+requestNewLinkURL+`"`) {
+				t.Fatalf("Error page does not link to request a new magic link: %s", body)
+			}
+			if strings.Contains(string(body), `action="`+resendURL+`"`) != (tt.resendURL != "") {
+				t.Fatalf("Error page has unexpected resend form: %s", body)
+			}
+		})
+		t.Run(tt.name+"JSON", func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, tt.link.String(), nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %s", err)
+			}
+			req.Header.Set(mld.HeaderAccept, mld.ContentTypeJSON)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to GET magic link: %s", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusNotFound || resp.Header.Get(mld.HeaderContentType) != mld.ContentTypeJSON {
+				t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get(mld.HeaderContentType))
+			}
+			var errorPage magiclink.ErrorPageJSON
+			err = json.NewDecoder(resp.Body).Decode(&errorPage)
+			if err != nil {
+				t.Fatalf("Failed to decode JSON response: %s", err)
+			}
+			if errorPage.Kind != tt.kind || errorPage.Code != http.StatusNotFound || errorPage.RequestNewLinkURL != requestNewLinkURL || errorPage.ResendURL != tt.resendURL {
+				t.Fatalf("Unexpected JSON response: %+v", errorPage)
+			}
+		})
+	}
+}
+
+func TestErrorPageKindFromError(t *testing.T) {
+	tc := []struct {
+		err  error
+		kind magiclink.ErrorPageKind
+	}{
+		{err: magiclink.ErrLinkExpired, kind: magiclink.ErrorPageKindExpired},
+		{err: magiclink.ErrLinkUsed, kind: magiclink.ErrorPageKindUsed},
+		{err: magiclink.ErrLinkMalformed, kind: magiclink.ErrorPageKindMalformed},
+		{err: magiclink.ErrMagicLinkMissingSecret, kind: magiclink.ErrorPageKindMalformed},
+		{err: magiclink.ErrLinkRevoked, kind: magiclink.ErrorPageKindInvalid},
+		{err: magiclink.ErrLinkNotFound, kind: magiclink.ErrorPageKindInvalid},
+		{err: &magiclink.ClientBindingError{IP: true}, kind: magiclink.ErrorPageKindInvalid},
+		{err: magiclink.ErrJWTSign, kind: magiclink.ErrorPageKindError},
+	}
+	for _, tt := range tc {
+		kind := magiclink.ErrorPageKindFromError(tt.err)
+		if kind != tt.kind {
+			t.Fatalf("Expected kind %q for error %q, got %q", tt.kind, tt.err, kind)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package magiclink
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	jt "github.com/MicahParks/jsontype"
+)
+
+// HCaptchaVerifier verifies hCaptcha tokens. Implementations other than the one returned by NewHCaptchaVerifier are
+// useful for testing.
+type HCaptchaVerifier interface {
+	Verify(ctx context.Context, response string, remoteIP string) (HCaptchaResponse, error)
+}
+
+// HCaptchaResponse is the response from the hCaptcha verify endpoint.
+type HCaptchaResponse struct {
+	ChallengeTS time.Time `json:"challenge_ts"`
+	Credit      bool      `json:"credit"`
+	ErrorCodes  []string  `json:"error-codes"`
+	Hostname    string    `json:"hostname"`
+	Success     bool      `json:"success"`
+}
+
+// HCaptchaCheckOptions are the options for checking an HCaptchaResponse.
+type HCaptchaCheckOptions struct {
+	Hostname []string
+}
+
+// Check confirms the HCaptchaResponse was successful and matches the given options.
+func (h HCaptchaResponse) Check(options HCaptchaCheckOptions) error {
+	return checkCaptcha(h.Success, h.ErrorCodes, h.Hostname, options.Hostname)
+}
+
+type hCaptchaVerifier struct {
+	captchaVerifier
+	siteKey string
+}
+
+// NewHCaptchaVerifier creates a new HCaptchaVerifier that calls the hCaptcha verify endpoint.
+func NewHCaptchaVerifier(secret, siteKey string, options CaptchaVerifierOptions) HCaptchaVerifier {
+	return hCaptchaVerifier{
+		captchaVerifier: newCaptchaVerifier(secret, "https://api.hcaptcha.com/siteverify", options),
+		siteKey:         siteKey,
+	}
+}
+
+func (h hCaptchaVerifier) Verify(ctx context.Context, response string, remoteIP string) (HCaptchaResponse, error) {
+	form := url.Values{
+		"response": {response},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	if h.siteKey != "" {
+		form.Set("sitekey", h.siteKey)
+	}
+	var r HCaptchaResponse
+	err := h.verify(ctx, form, &r)
+	if err != nil {
+		return HCaptchaResponse{}, fmt.Errorf("failed to verify hCaptcha response: %w", err)
+	}
+	return r, nil
+}
+
+// HCaptchaConfig is the configuration for hCaptcha.
+type HCaptchaConfig struct {
+	Hostname     []string             `json:"hostname"`
+	SecretKey    string               `json:"secretKey"`
+	TemplateData HCaptchaTemplateData `json:"templateData"`
+
+	Verifier HCaptchaVerifier `json:"-"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (h HCaptchaConfig) DefaultsAndValidate() (HCaptchaConfig, error) {
+	if h.SecretKey == "" {
+		return h, fmt.Errorf("%w: hCaptcha secret key is required", jt.ErrDefaultsAndValidate)
+	}
+	var err error
+	h.TemplateData, err = h.TemplateData.DefaultsAndValidate()
+	if err != nil {
+		return h, fmt.Errorf("failed to validate hCaptcha template data: %w", err)
+	}
+	return h, nil
+}
+
+// HCaptchaRedirector is a Redirector that uses hCaptcha to verify the user.
+type HCaptchaRedirector struct {
+	checkOpts HCaptchaCheckOptions
+	tmpl      *template.Template
+	tmplData  HCaptchaTemplateData
+	verifier  HCaptchaVerifier
+}
+
+// NewHCaptchaRedirector creates a new HCaptchaRedirector with the given config.
+func NewHCaptchaRedirector(config HCaptchaConfig) Redirector {
+	tmpl := template.Must(template.New("").Parse(hCaptchaTemplate))
+	verifier := config.Verifier
+	if verifier == nil {
+		verifier = NewHCaptchaVerifier(config.SecretKey, config.TemplateData.SiteKey, CaptchaVerifierOptions{})
+	}
+	h := HCaptchaRedirector{
+		checkOpts: HCaptchaCheckOptions{
+			Hostname: config.Hostname,
+		},
+		tmpl:     tmpl,
+		tmplData: config.TemplateData,
+		verifier: verifier,
+	}
+	return h
+}
+
+func (h HCaptchaRedirector) Redirect(args RedirectorParams) {
+	captchaRedirect(args, h.tmpl, h.tmplData, func(ctx context.Context, token string) error {
+		resp, err := h.verifier.Verify(ctx, token, "") // remoteIP left blank because reverse-proxies are a common use case.
+		if err != nil {
+			return err
+		}
+		return resp.Check(h.checkOpts)
+	})
+}
+
+// HCaptchaTemplateData is the configuration for the HTML template for hCaptcha.
+type HCaptchaTemplateData struct {
+	CSS         template.CSS `json:"css"`
+	Code        string       `json:"code"`
+	HTMLTitle   string       `json:"htmlTitle"`
+	Instruction string       `json:"instruction"`
+	SiteKey     string       `json:"siteKey"`
+	Title       string       `json:"title"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (h HCaptchaTemplateData) DefaultsAndValidate() (HCaptchaTemplateData, error) {
+	if h.CSS == "" {
+		h.CSS = template.CSS(defaultCSS)
+	}
+	if h.Code == "" {
+		h.Code = "BROWSER CHECK"
+	}
+	if h.HTMLTitle == "" {
+		h.HTMLTitle = "Magic Link - Browser Check"
+	}
+	if h.Instruction == "" {
+		h.Instruction = "This page helps prevent robots from using magic links. Complete the check below to continue."
+	}
+	if h.SiteKey == "" {
+		return h, fmt.Errorf("%w: SiteKey is required", jt.ErrDefaultsAndValidate)
+	}
+	if h.Title == "" {
+		h.Title = "Checking your browser..."
+	}
+	return h, nil
+}
@@ -0,0 +1,94 @@
+package magiclink
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// InterstitialConfig is the configuration for the click-through interstitial page.
+type InterstitialConfig struct {
+	TemplateData InterstitialTemplateData `json:"templateData"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (i InterstitialConfig) DefaultsAndValidate() (InterstitialConfig, error) {
+	var err error
+	i.TemplateData, err = i.TemplateData.DefaultsAndValidate()
+	if err != nil {
+		return i, err
+	}
+	return i, nil
+}
+
+// InterstitialRedirector is a Redirector that shows a confirmation page for GET requests and only visits the magic link
+// when the confirmation page's form is submitted with a POST request. Email security scanners and link-rewriting
+// gateways that prefetch links with GET requests will not use the magic link. No third-party service is required.
+type InterstitialRedirector struct {
+	tmpl     *template.Template
+	tmplData InterstitialTemplateData
+}
+
+// NewInterstitialRedirector creates a new InterstitialRedirector with the given config.
+func NewInterstitialRedirector(config InterstitialConfig) Redirector {
+	tmpl := template.Must(template.New("").Parse(interstitialTemplate))
+	i := InterstitialRedirector{
+		tmpl:     tmpl,
+		tmplData: config.TemplateData,
+	}
+	return i
+}
+
+func (i InterstitialRedirector) Redirect(args RedirectorParams) {
+	ctx := args.Request.Context()
+
+	if args.Request.Method == http.MethodPost {
+		jwtB64, response, err := args.ReadAndExpireLink(ctx, args.Secret)
+		if err != nil {
+			args.HandleError(err)
+			return
+		}
+		writeRedemption(args.Writer, args.Request, jwtB64, response, false)
+		return
+	}
+
+	tData := i.tmplData
+	tData.FormAction = args.Request.URL.String()
+	args.Writer.Header().Set("Cache-Control", "no-store")
+	args.Writer.Header().Set("Referrer-Policy", "no-referrer")
+	_ = i.tmpl.Execute(args.Writer, tData)
+}
+
+// InterstitialTemplateData is the configuration for the HTML template for the click-through interstitial page.
+type InterstitialTemplateData struct {
+	ButtonText  string       `json:"buttonText"`
+	CSS         template.CSS `json:"css"`
+	Code        string       `json:"code"`
+	HTMLTitle   string       `json:"htmlTitle"`
+	Instruction string       `json:"instruction"`
+	Title       string       `json:"title"`
+
+	FormAction string `json:"-"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (i InterstitialTemplateData) DefaultsAndValidate() (InterstitialTemplateData, error) {
+	if i.ButtonText == "" {
+		i.ButtonText = "Continue"
+	}
+	if i.CSS == "" {
+		i.CSS = template.CSS(defaultCSS)
+	}
+	if i.Code == "" {
+		i.Code = "MAGIC LINK"
+	}
+	if i.HTMLTitle == "" {
+		i.HTMLTitle = "Magic Link - Continue"
+	}
+	if i.Instruction == "" {
+		i.Instruction = "Press the button below to continue. This helps prevent automated link scanners from using your magic link."
+	}
+	if i.Title == "" {
+		i.Title = "Continue to sign in"
+	}
+	return i, nil
+}
@@ -0,0 +1,139 @@
+package magiclink_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MicahParks/magiclinksdev/magiclink"
+	"github.com/MicahParks/magiclinksdev/mldtest"
+)
+
+func TestInterstitialRedirector_Redirect(t *testing.T) {
+	const (
+		jwtB64FromBackend = "jwtB64"
+		magicLinkTarget   = "https://magiclinks.dev/"
+		requestURL        = "/redirect?msk=secret"
+	)
+
+	magicLinkTargetWithJWT := fmt.Sprintf("%s?%s=%s", magicLinkTarget, magiclink.DefaultRedirectQueryKey, jwtB64FromBackend)
+
+	tc := []struct {
+		name     string
+		method   string
+		readErr  error
+		respCode int
+		visited  bool
+	}{
+		{
+			name:     "Get",
+			method:   http.MethodGet,
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "Head",
+			method:   http.MethodHead,
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "Post",
+			method:   http.MethodPost,
+			respCode: http.StatusSeeOther,
+			visited:  true,
+		},
+		{
+			name:     "PostBindingMismatch",
+			method:   http.MethodPost,
+			readErr:  &magiclink.ClientBindingError{IP: true},
+			respCode: http.StatusForbidden,
+		},
+		{
+			name:     "PostNotFound",
+			method:   http.MethodPost,
+			readErr:  magiclink.ErrLinkUsed,
+			respCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := magiclink.InterstitialConfig{}.DefaultsAndValidate()
+			if err != nil {
+				t.Fatalf("Failed to apply defaults and validate: %v.", err)
+			}
+			redirector := magiclink.NewInterstitialRedirector(conf)
+
+			r, err := http.NewRequest(tt.method, requestURL, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v.", err)
+			}
+			recorder := httptest.NewRecorder()
+			visited := false
+			var handled error
+			args := magiclink.RedirectorParams{
+				HandleError: func(err error) {
+					handled = err
+					code := http.StatusInternalServerError
+					switch {
+					case errors.Is(err, magiclink.ErrLinkNotFound):
+						code = http.StatusNotFound
+					case errors.Is(err, magiclink.ErrClientBindingMismatch):
+						code = http.StatusForbidden
+					}
+					recorder.WriteHeader(code)
+				},
+				ReadAndExpireLink: func(ctx context.Context, secret string) (jwtB64 string, response magiclink.ReadResult, err error) {
+					if tt.readErr != nil {
+						return "", magiclink.ReadResult{}, tt.readErr
+					}
+					visited = true
+					return jwtB64FromBackend, magiclink.ReadResult{
+						CreateParams: magiclink.CreateParams{
+							Expires:          time.Now().Add(mldtest.LinksExpireAfter),
+							RedirectQueryKey: magiclink.DefaultRedirectQueryKey,
+							RedirectURL:      must(url.Parse(magicLinkTarget)),
+						},
+					}, nil
+				},
+				Request: r,
+				Writer:  recorder,
+			}
+
+			redirector.Redirect(args)
+
+			if recorder.Code != tt.respCode {
+				t.Fatalf("Expected status code %v, but got %v.", tt.respCode, recorder.Code)
+			}
+			if visited != tt.visited {
+				t.Fatalf("Expected magic link visited to be %v, but got %v.", tt.visited, visited)
+			}
+			if handled != tt.readErr {
+				t.Fatalf("Expected error %v to be handled, but got %v.", tt.readErr, handled)
+			}
+			if tt.readErr != nil {
+				return
+			}
+
+			if tt.visited {
+				redirectTo := recorder.Header().Get("Location")
+				if redirectTo != magicLinkTargetWithJWT {
+					t.Fatalf("Expected redirect to %v, but got %v.", magicLinkTargetWithJWT, redirectTo)
+				}
+				return
+			}
+
+			body := recorder.Body.String()
+			if !strings.Contains(body, `method="post"`) {
+				t.Fatalf("Expected body to contain a POST form, but got %v.", body)
+			}
+			if !strings.Contains(body, `action="/redirect?msk=secret"`) {
+				t.Fatalf("Expected form action to be the magic link, but got %v.", body)
+			}
+		})
+	}
+}
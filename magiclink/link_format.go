@@ -0,0 +1,92 @@
+package magiclink
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+const (
+	// LinkFormatQuery puts the magic link secret in a URL query parameter of the service URL. This is the default.
+	LinkFormatQuery LinkFormat = "query"
+	// LinkFormatPath appends the magic link secret to the path of the service URL, such as /redirect/<code>. Some
+	// email clients and SMS apps mangle query parameters, but leave paths intact.
+	LinkFormatPath LinkFormat = "path"
+
+	// PathValueCode is the name of the path wildcard that holds the magic link secret for LinkFormatPath. Register the
+	// MagicLinkHandler with a pattern ending in this wildcard, such as "/redirect/{code}".
+	PathValueCode = "code"
+
+	// shortCodeLength is the number of base62 characters needed to encode the 128 bits of a UUID.
+	shortCodeLength = 22
+)
+
+// LinkFormat is a set of string constants that indicate where the magic link secret is placed in the magic link.
+type LinkFormat string
+
+// Valid confirms the LinkFormat is known. The empty string is valid and means LinkFormatQuery.
+func (f LinkFormat) Valid() error {
+	switch f {
+	case "", LinkFormatQuery, LinkFormatPath:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown link format %q", mld.ErrParams, f)
+}
+
+// ShortCode encodes a UUID magic link secret as a 22 character base62 code. The code has the same entropy as the secret
+// and is converted back to the secret by RequestSecret. Secrets that are not UUIDs are returned unchanged.
+func ShortCode(secret string) string {
+	u, err := uuid.Parse(secret)
+	if err != nil {
+		return secret
+	}
+	code := new(big.Int).SetBytes(u[:]).Text(62)
+	return strings.Repeat("0", shortCodeLength-len(code)) + code
+}
+
+// RequestSecret returns the magic link secret from the request. The secret is read from the URL query parameter with
+// the given key. If it is not present, the PathValueCode path value is used for magic links in the LinkFormatPath
+// format. Short codes created with ShortCode are converted back to the secret.
+func RequestSecret(r *http.Request, secretQueryKey string) string {
+	code := r.URL.Query().Get(secretQueryKey)
+	if code == "" {
+		code = r.PathValue(PathValueCode)
+	}
+	return secretFromCode(code)
+}
+
+// secretFromCode converts a short code back to the UUID secret it encodes. Anything else is returned unchanged.
+func secretFromCode(code string) string {
+	if len(code) != shortCodeLength {
+		return code
+	}
+	i, ok := new(big.Int).SetString(code, 62)
+	if !ok || i.Sign() < 0 || i.BitLen() > 128 {
+		return code
+	}
+	var u uuid.UUID
+	i.FillBytes(u[:])
+	return u.String()
+}
+
+// link builds the magic link for the given secret in the configured LinkFormat.
+func (m MagicLink) link(secret string) *url.URL {
+	code := secret
+	if m.shortCodes {
+		code = ShortCode(secret)
+	}
+	u := copyURL(m.serviceURL)
+	if m.linkFormat == LinkFormatPath {
+		return u.JoinPath(code)
+	}
+	query := u.Query()
+	query.Set(m.secretQueryKey, code) // This overwrites any existing values.
+	u.RawQuery = query.Encode()
+	return u
+}
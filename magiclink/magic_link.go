@@ -8,8 +8,11 @@ import (
 	"crypto/rsa"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"time"
 
 	"github.com/MicahParks/jwkset"
 	"github.com/golang-jwt/jwt/v5"
@@ -23,17 +26,27 @@ const (
 	DefaultRedirectQueryKey = "jwt"
 	// DefaultSecretQueryKey is the default URL query parameter to contain the secret for a magic link.
 	DefaultSecretQueryKey = "secret"
+	// DefaultStateQueryKey is the default URL query parameter to contain the State given when the magic link was
+	// created.
+	DefaultStateQueryKey = "state"
+	// VisitsUnlimited is the value of CreateParams.MaxVisits for a magic link that can be visited any number of times
+	// until it expires.
+	VisitsUnlimited = -1
 )
 
 // MagicLink holds the necessary assets for the magic link service.
 type MagicLink struct {
 	Store             Storage
+	binding           BindingConfig
 	customRedirector  Redirector
 	errorHandler      ErrorHandler
 	jwks              *jwksCache
+	linkFormat        LinkFormat
+	logger            *slog.Logger
 	reCAPTCHAV3Config ReCAPTCHAV3Config
 	secretQueryKey    string
 	serviceURL        *url.URL
+	shortCodes        bool
 }
 
 // NewMagicLink creates a new MagicLink. The given setupCtx is only used during the creation of the MagicLink.
@@ -50,11 +63,21 @@ func NewMagicLink(setupCtx context.Context, config Config) (MagicLink, error) {
 		return m, fmt.Errorf("failed to create JWK Set cache: %w", err)
 	}
 
+	binding, err := config.Binding.DefaultsAndValidate()
+	if err != nil {
+		return m, fmt.Errorf("failed to validate binding config: %w", err)
+	}
+
 	secretQueryKey := config.SecretQueryKey
 	if secretQueryKey == "" {
 		secretQueryKey = DefaultSecretQueryKey
 	}
 
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	var store Storage
 	store = config.Store
 	if store == nil {
@@ -63,12 +86,16 @@ func NewMagicLink(setupCtx context.Context, config Config) (MagicLink, error) {
 
 	m = MagicLink{
 		Store:             store,
+		binding:           binding,
 		customRedirector:  config.CustomRedirector,
 		errorHandler:      config.ErrorHandler,
 		jwks:              jCache,
+		linkFormat:        config.LinkFormat,
+		logger:            logger,
 		reCAPTCHAV3Config: ReCAPTCHAV3Config{},
 		secretQueryKey:    secretQueryKey,
 		serviceURL:        config.ServiceURL,
+		shortCodes:        config.ShortCodes,
 	}
 
 	return m, nil
@@ -100,39 +127,75 @@ func (m MagicLink) NewLink(ctx context.Context, args CreateParams) (CreateRespon
 		return CreateResponse{}, fmt.Errorf("failed to validate args: %w", err)
 	}
 
-	secret, err := m.Store.MagicLinkCreate(ctx, args)
+	result, err := m.Store.MagicLinkCreate(ctx, args)
 	if err != nil {
 		return CreateResponse{}, fmt.Errorf("failed to create link: %w", err)
 	}
 
-	serviceURL := copyURL(m.serviceURL)
-	queryResult := serviceURL.Query()
-	queryResult.Set(m.secretQueryKey, secret) // This overwrites any existing values.
-	serviceURL.RawQuery = queryResult.Encode()
-
 	resp := CreateResponse{
-		MagicLink: serviceURL,
-		Secret:    secret,
+		CrossDeviceToken: result.CrossDeviceToken,
+		ID:               result.ID,
+		MagicLink:        m.link(result.Secret),
+		QRCodeToken:      result.QRCodeToken,
+		Secret:           result.Secret,
 	}
 
 	return resp, nil
 }
 
+// Revoke revokes the magic link identified by the given parameters so that it can no longer be visited.
+func (m MagicLink) Revoke(ctx context.Context, args RevokeParams) error {
+	err := args.Valid()
+	if err != nil {
+		return fmt.Errorf("failed to validate args: %w", err)
+	}
+
+	err = m.Store.MagicLinkRevoke(ctx, args)
+	if err != nil {
+		return fmt.Errorf("failed to revoke link: %w", err)
+	}
+
+	return nil
+}
+
+// Status returns the status of the magic link with the given ID without counting as a visit.
+func (m MagicLink) Status(ctx context.Context, id string) (StatusResult, error) {
+	if id == "" {
+		return StatusResult{}, fmt.Errorf("%w: ID is required", mld.ErrParams)
+	}
+
+	result, err := m.Store.MagicLinkStatus(ctx, id)
+	if err != nil {
+		return StatusResult{}, fmt.Errorf("failed to read link status: %w", err)
+	}
+
+	return result, nil
+}
+
 // MagicLinkHandler is an HTTP handler that accepts HTTP requests with magic link secrets, then redirects to the given
-// URL with the JWT as a query parameter.
+// URL with the JWT as a query parameter. If the request's Accept header includes JSON, a JSON Redemption is written
+// instead of redirecting. The secret is read with RequestSecret, so magic links in every LinkFormat, with or without
+// short codes, are accepted.
 func (m MagicLink) MagicLinkHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		secret := r.URL.Query().Get(m.secretQueryKey)
+		secret := RequestSecret(r, m.secretQueryKey)
 		if secret == "" {
 			m.handleError(ErrMagicLinkMissingSecret, http.StatusBadRequest, r, w)
 			return
 		}
 
+		readAndExpireLink := func(ctx context.Context, secret string) (jwtB64 string, response ReadResult, err error) {
+			return m.handleMagicLink(ctx, secret, r)
+		}
+
 		if m.customRedirector != nil {
 			args := RedirectorParams{
-				ReadAndExpireLink: m.HandleMagicLink,
+				HandleError: func(err error) {
+					m.handleReadError(err, r, w)
+				},
+				ReadAndExpireLink: readAndExpireLink,
 				Request:           r,
 				Secret:            secret,
 				Writer:            w,
@@ -141,22 +204,98 @@ func (m MagicLink) MagicLinkHandler() http.Handler {
 			return
 		}
 
-		jwtB64, response, err := m.HandleMagicLink(ctx, secret)
+		jwtB64, response, err := readAndExpireLink(ctx, secret)
 		if err != nil {
-			if errors.Is(err, ErrLinkNotFound) {
-				m.handleError(err, http.StatusNotFound, r, w)
-				return
-			}
-			m.handleError(err, http.StatusInternalServerError, r, w)
+			m.handleReadError(err, r, w)
 			return
 		}
-		u := redirectURLFromResponse(response, jwtB64)
-		http.Redirect(w, r, u.String(), http.StatusSeeOther)
+		writeRedemption(w, r, jwtB64, response, false)
 	})
 }
 
-// HandleMagicLink is a method that accepts a magic link secret, then returns the signed JWT.
+// HandleMagicLink is a method that accepts a magic link secret, then returns the signed JWT. For magic links created
+// with a code challenge, the magic link is not visited and no JWT is signed. Instead, the ReadResult's Code is populated
+// so it can be delivered to the redirect URL and exchanged for a JWT with Exchange. The magic link's ClientBinding is
+// not enforced because there is no HTTP request to check, use MagicLinkHandler for that.
 func (m MagicLink) HandleMagicLink(ctx context.Context, secret string) (jwtB64 string, response ReadResult, err error) {
+	return m.handleMagicLink(ctx, secret, nil)
+}
+
+// handleMagicLink implements HandleMagicLink. If the request is not nil, the magic link's ClientBinding is enforced.
+func (m MagicLink) handleMagicLink(ctx context.Context, secret string, r *http.Request) (jwtB64 string, response ReadResult, err error) {
+	response, err = m.Store.MagicLinkPeek(ctx, secret)
+	if err != nil {
+		if errors.Is(err, ErrLinkNotFound) {
+			return "", response, err
+		}
+		return "", response, ErrMagicLinkRead
+	}
+	if r != nil {
+		err = m.enforceBinding(ctx, checkClientBinding(response.CreateParams.Binding, m.binding, r))
+		if err != nil {
+			return "", response, err
+		}
+	}
+	if response.CreateParams.CodeChallenge != "" {
+		response.Code, err = m.Store.MagicLinkCodeCreate(ctx, secret, time.Now().Add(CodeLifespan))
+		if err != nil {
+			if errors.Is(err, ErrLinkNotFound) {
+				return "", response, err
+			}
+			return "", response, fmt.Errorf("%w: failed to create code: %s", ErrMagicLinkRead, err)
+		}
+		return "", response, nil
+	}
+	return m.readAndSign(ctx, secret)
+}
+
+// Exchange accepts the code delivered for a magic link created with a code challenge and the matching code verifier,
+// then returns the signed JWT. This counts as a visit of the magic link. Each code can only be exchanged once and only
+// within CodeLifespan of the magic link being clicked. The ClientIP and UserAgent are checked against the magic link's
+// ClientBinding.
+func (m MagicLink) Exchange(ctx context.Context, args ExchangeParams) (jwtB64 string, response ReadResult, err error) {
+	err = args.Valid()
+	if err != nil {
+		return "", response, fmt.Errorf("failed to validate args: %w", err)
+	}
+	secret, err := m.Store.MagicLinkCodeTake(ctx, args.Code)
+	if err != nil {
+		if errors.Is(err, ErrLinkNotFound) {
+			return "", response, err
+		}
+		return "", response, ErrMagicLinkRead
+	}
+	response, err = m.Store.MagicLinkPeek(ctx, secret)
+	if err != nil {
+		if errors.Is(err, ErrLinkNotFound) {
+			return "", response, err
+		}
+		return "", response, ErrMagicLinkRead
+	}
+	ip, err := netip.ParseAddr(args.ClientIP)
+	err = m.enforceBinding(ctx, checkBinding(response.CreateParams.Binding, m.binding.Mode, ip.Unmap(), err == nil, args.UserAgent))
+	if err != nil {
+		return "", response, err
+	}
+	if !verifyCodeChallenge(response.CreateParams.CodeChallenge, args.CodeVerifier) {
+		return "", response, ErrCodeVerifierInvalid
+	}
+	return m.readAndSign(ctx, secret)
+}
+
+// enforceBinding returns the error from a ClientBinding check if the magic link must not be redeemed. In
+// BindingModeWarn, the mismatch is logged instead.
+func (m MagicLink) enforceBinding(ctx context.Context, err error) error {
+	if err == nil || m.binding.Mode != BindingModeWarn {
+		return err
+	}
+	m.logger.WarnContext(ctx, "Magic link visited by a client that does not match its client binding.",
+		mld.LogErr, err,
+	)
+	return nil
+}
+
+func (m MagicLink) readAndSign(ctx context.Context, secret string) (jwtB64 string, response ReadResult, err error) {
 	response, err = m.Store.MagicLinkRead(ctx, secret)
 	if err != nil {
 		if errors.Is(err, ErrLinkNotFound) {
@@ -165,36 +304,75 @@ func (m MagicLink) HandleMagicLink(ctx context.Context, secret string) (jwtB64 s
 		return "", response, ErrMagicLinkRead
 	}
 
+	claims := response.CreateParams.JWTClaims
+	if renewable, ok := claims.(RenewableClaims); ok && response.CreateParams.MultiVisit() {
+		claims, err = renewable.Renew(time.Now())
+		if err != nil {
+			return "", response, fmt.Errorf("%w: failed to renew claims: %s", ErrJWTSign, err)
+		}
+	}
+
+	jwtB64, err = m.SignJWT(ctx, claims, response.CreateParams.JWTKeyID, response.CreateParams.JWTSigningMethod)
+	if err != nil {
+		return "", response, err
+	}
+
+	if response.CreateParams.CrossDevice {
+		err = m.Store.MagicLinkCrossDeviceDeliver(ctx, response.ID, jwtB64)
+		if err != nil {
+			return "", response, fmt.Errorf("%w: %s", ErrCrossDeviceDeliver, err)
+		}
+	}
+
+	return jwtB64, response, nil
+}
+
+// SignJWT signs the claims the same way as a JWT for a magic link. If keyID is nil, the first key in the JWK Set is
+// used. If signingMethod is empty, the best signing method for the key is used.
+func (m MagicLink) SignJWT(ctx context.Context, claims jwt.Claims, keyID *string, signingMethod string) (string, error) {
 	var jwk jwkset.JWK
-	if response.CreateParams.JWTKeyID != nil {
-		jwk, err = m.jwks.storage.KeyRead(ctx, *response.CreateParams.JWTKeyID)
+	var err error
+	if keyID != nil {
+		jwk, err = m.jwks.storage.KeyRead(ctx, *keyID)
 		if err != nil {
-			return "", response, fmt.Errorf("%w: %s", ErrJWKSReadGivenKID, err)
+			return "", fmt.Errorf("%w: %s", ErrJWKSReadGivenKID, err)
 		}
 	} else {
 		allKeys, err := m.jwks.storage.KeyReadAll(ctx)
 		if err != nil {
-			return "", response, fmt.Errorf("%w: %s", ErrJWKSSnapshot, err)
+			return "", fmt.Errorf("%w: %s", ErrJWKSSnapshot, err)
 		}
 		if len(allKeys) == 0 {
-			return "", response, ErrJWKSEmpty
+			return "", ErrJWKSEmpty
 		}
 		jwk = allKeys[0] // First key is default signing key in PostgreSQL implementation.
 	}
 
-	signingMethod := jwt.GetSigningMethod(response.CreateParams.JWTSigningMethod)
-	if signingMethod == nil {
-		signingMethod = BestSigningMethod(jwk.Key())
+	method := jwt.GetSigningMethod(signingMethod)
+	if method == nil {
+		method = BestSigningMethod(jwk.Key())
 	}
 
-	token := jwt.NewWithClaims(signingMethod, response.CreateParams.JWTClaims)
+	token := jwt.NewWithClaims(method, claims)
 	token.Header[jwkset.HeaderKID] = jwk.Marshal().KID
-	jwtB64, err = token.SignedString(jwk.Key())
+	jwtB64, err := token.SignedString(jwk.Key())
 	if err != nil {
-		return "", response, fmt.Errorf("%w: %s", ErrJWTSign, err)
+		return "", fmt.Errorf("%w: %s", ErrJWTSign, err)
 	}
 
-	return jwtB64, response, nil
+	return jwtB64, nil
+}
+
+// handleReadError handles an error from reading a magic link with the status code suggested for the error.
+func (m MagicLink) handleReadError(err error, request *http.Request, writer http.ResponseWriter) {
+	switch {
+	case errors.Is(err, ErrLinkNotFound):
+		m.handleError(err, http.StatusNotFound, request, writer)
+	case errors.Is(err, ErrClientBindingMismatch):
+		m.handleError(err, http.StatusForbidden, request, writer)
+	default:
+		m.handleError(err, http.StatusInternalServerError, request, writer)
+	}
 }
 
 func (m MagicLink) handleError(err error, suggestedResponseCode int, request *http.Request, writer http.ResponseWriter) {
@@ -235,18 +413,99 @@ func BestSigningMethod(key any) jwt.SigningMethod {
 	return signingMethod
 }
 
-func redirectURLFromResponse(response ReadResult, jwtB64 string) *url.URL {
-	u := copyURL(response.CreateParams.RedirectURL)
-	query := u.Query()
-	queryKey := response.CreateParams.RedirectQueryKey
+func redirectQueryKey(params CreateParams) string {
+	queryKey := params.RedirectQueryKey
 	if queryKey == "" {
 		queryKey = DefaultRedirectQueryKey
 	}
-	query.Add(queryKey, jwtB64)
+	return queryKey
+}
+
+// deliveryQueryKey is the key that delivers the JWT, or the code for magic links created with a code challenge.
+func deliveryQueryKey(params CreateParams) string {
+	if params.CodeChallenge != "" {
+		return DefaultCodeQueryKey
+	}
+	return redirectQueryKey(params)
+}
+
+func stateQueryKey(params CreateParams) string {
+	queryKey := params.StateQueryKey
+	if queryKey == "" {
+		queryKey = DefaultStateQueryKey
+	}
+	return queryKey
+}
+
+// redirectValues are the values delivered to the redirect URL. The value is the JWT, or the code for magic links
+// created with a code challenge. The State is included if it was given when the magic link was created.
+func redirectValues(response ReadResult, value string) url.Values {
+	values := url.Values{deliveryQueryKey(response.CreateParams): {value}}
+	if response.CreateParams.State != "" {
+		values.Set(stateQueryKey(response.CreateParams), response.CreateParams.State)
+	}
+	return values
+}
+
+func redirectURL(response ReadResult, values url.Values) *url.URL {
+	u := copyURL(response.CreateParams.RedirectURL)
+	if response.CreateParams.ResponseMode == ResponseModeFragment {
+		fragment := values.Encode()
+		if u.Fragment != "" {
+			fragment = u.Fragment + "&" + fragment
+		}
+		u.Fragment = fragment
+		u.RawFragment = ""
+		return u
+	}
+	query := u.Query()
+	for key, vals := range values {
+		for _, value := range vals {
+			query.Add(key, value)
+		}
+	}
 	u.RawQuery = query.Encode()
 	return u
 }
 
+// writeRedemption writes the response for a magic link that was successfully visited. Cross-device magic links show a
+// confirmation page because the JWT is collected by the device that requested the magic link. For magic links created
+// with a code challenge, the code is delivered instead of the JWT. The form_post response mode writes an HTML form that
+// submits to the redirect URL. Redirect URLs that are not http or https, such as a mobile app's deep link, are opened
+// from an intermediate page. Otherwise, the user is redirected or, if urlBody is true, the redirect URL is written as
+// the response body for JavaScript to follow. Clients that accept JSON are sent a JSON Redemption instead. The State
+// given when the magic link was created is delivered alongside the JWT or code.
+func writeRedemption(w http.ResponseWriter, r *http.Request, jwtB64 string, response ReadResult, urlBody bool) {
+	if acceptsJSON(r) {
+		writeRedemptionJSON(w, jwtB64, response)
+		return
+	}
+	if response.CreateParams.CrossDevice {
+		writeCrossDeviceConfirmation(w)
+		return
+	}
+	value := jwtB64
+	if response.Code != "" {
+		value = response.Code
+	}
+	values := redirectValues(response, value)
+	if response.CreateParams.ResponseMode == ResponseModeFormPost {
+		writeFormPost(w, response.CreateParams.RedirectURL, values)
+		return
+	}
+	u := redirectURL(response, values)
+	if urlBody {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(u.String()))
+		return
+	}
+	if !HTTPRedirect(u) {
+		writeOpenApp(w, u)
+		return
+	}
+	http.Redirect(w, r, u.String(), http.StatusSeeOther)
+}
+
 func signingMethodECDSACurve(curve elliptic.Curve, signingMethod jwt.SigningMethod) jwt.SigningMethod {
 	switch curve {
 	case elliptic.P256():
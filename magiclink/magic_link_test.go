@@ -1,26 +1,36 @@
 package magiclink_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/MicahParks/jwkset"
 	"github.com/golang-jwt/jwt/v5"
 
+	mld "github.com/MicahParks/magiclinksdev"
 	"github.com/MicahParks/magiclinksdev/magiclink"
 	"github.com/MicahParks/magiclinksdev/mldtest"
 )
 
 const (
-	jwksPath      = "/jwks.json"
-	magicLinkPath = "/magic-link"
+	crossDevicePath = "/cross-device"
+	jwksPath        = "/jwks.json"
+	magicLinkPath   = "/magic-link"
+	qrCodePath      = "/qr-code"
+	redeemPath      = "/redeem"
 )
 
 type dynamicHandler struct {
@@ -38,12 +48,16 @@ type jwtClaims struct {
 }
 
 type setupParams struct {
+	binding          magiclink.BindingConfig
 	errorHandler     magiclink.ErrorHandler
 	jwksGet          bool
 	jwksGetDelay     time.Duration
 	jwksCacheRefresh time.Duration
 	jwksStore        jwkset.Storage
+	linkFormat       magiclink.LinkFormat
+	logger           *slog.Logger
 	secretQueryKey   string
+	shortCodes       bool
 }
 
 type createParams struct {
@@ -166,9 +180,13 @@ func magiclinkSetup(ctx context.Context, t *testing.T, args setupParams) (magicl
 	}
 
 	config := magiclink.Config{
+		Binding:        args.binding,
 		ErrorHandler:   args.errorHandler,
+		LinkFormat:     args.linkFormat,
+		Logger:         args.logger,
 		ServiceURL:     serviceURL,
 		SecretQueryKey: args.secretQueryKey,
+		ShortCodes:     args.shortCodes,
 		Store:          nil,
 		JWKS: magiclink.JWKSParams{
 			CacheRefresh: args.jwksCacheRefresh,
@@ -179,15 +197,32 @@ func magiclinkSetup(ctx context.Context, t *testing.T, args setupParams) (magicl
 	if err != nil {
 		t.Fatalf("Failed to create MagicLink service: %s", err)
 	}
+	crossDeviceHandler := m.CrossDeviceHandler()
 	jwksHandler := m.JWKSHandler()
 	magicLinkHandler := m.MagicLinkHandler()
+	qrCodeHandler := m.QRCodeHandler()
+	redeemHandler := m.RedeemHandler()
+	pathMux := http.NewServeMux()
+	pathMux.Handle(magicLinkPath+"/{"+magiclink.PathValueCode+"}", magicLinkHandler)
 	dH.handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		if request.URL.Path == jwksPath {
+		if request.URL.Path == crossDevicePath {
+			crossDeviceHandler.ServeHTTP(writer, request)
+			return
+		} else if request.URL.Path == jwksPath {
 			jwksHandler.ServeHTTP(writer, request)
 			return
 		} else if request.URL.Path == magicLinkPath {
 			magicLinkHandler.ServeHTTP(writer, request)
 			return
+		} else if strings.HasPrefix(request.URL.Path, magicLinkPath+"/") {
+			pathMux.ServeHTTP(writer, request)
+			return
+		} else if request.URL.Path == qrCodePath {
+			qrCodeHandler.ServeHTTP(writer, request)
+			return
+		} else if request.URL.Path == redeemPath {
+			redeemHandler.ServeHTTP(writer, request)
+			return
 		}
 		writer.WriteHeader(http.StatusNotFound)
 	})
@@ -216,3 +251,1164 @@ func keyfunc(ctx context.Context, store jwkset.Storage) jwt.Keyfunc {
 		return key, nil
 	}
 }
+
+func TestMagicLink_MaxVisits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	noRedirect := &http.Client{
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		maxVisits int
+		visits    int
+	}{
+		{
+			name:      "Default",
+			maxVisits: 0,
+			visits:    1,
+		},
+		{
+			name:      "Three",
+			maxVisits: 3,
+			visits:    3,
+		},
+		{
+			name:      "Unlimited",
+			maxVisits: magiclink.VisitsUnlimited,
+			visits:    10,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Now()
+			claims := mld.SigningBytesClaims{
+				Claims: []byte(fmt.Sprintf(`{"exp":%d,"iat":%d,"jti":"original"}`, now.Add(time.Minute).Unix(), now.Unix())),
+			}
+			createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+				Expires:     now.Add(mldtest.LinksExpireAfter),
+				JWTClaims:   claims,
+				MaxVisits:   tc.maxVisits,
+				RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create magic link: %s", err)
+			}
+
+			jtis := make(map[string]struct{})
+			for i := 0; i < tc.visits; i++ {
+				resp, err := noRedirect.Get(createRes.MagicLink.String())
+				if err != nil {
+					t.Fatalf("Failed to GET magic link: %s", err)
+				}
+				_ = resp.Body.Close()
+				if resp.StatusCode != http.StatusSeeOther {
+					t.Fatalf("Visit %d did not return 303 See Other: %d", i+1, resp.StatusCode)
+				}
+				location, err := url.Parse(resp.Header.Get("Location"))
+				if err != nil {
+					t.Fatalf("Failed to parse redirect location: %s", err)
+				}
+				registered := jwt.RegisteredClaims{}
+				_, err = jwt.ParseWithClaims(location.Query().Get(magiclink.DefaultRedirectQueryKey), &registered, keyfunc(ctx, m.JWKSet()))
+				if err != nil {
+					t.Fatalf("Failed to parse JWT: %s", err)
+				}
+				jtis[registered.ID] = struct{}{}
+			}
+			if tc.visits > 1 && len(jtis) != tc.visits {
+				t.Fatalf("Expected a unique JWT ID for each of %d visits, got %d", tc.visits, len(jtis))
+			}
+
+			if tc.maxVisits == magiclink.VisitsUnlimited {
+				return
+			}
+			resp, err := noRedirect.Get(createRes.MagicLink.String())
+			if err != nil {
+				t.Fatalf("Failed to GET magic link: %s", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusNotFound {
+				t.Fatalf("Visit after max visits did not return 404 Not Found: %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestMagicLink_Revoke(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	err := m.Revoke(ctx, magiclink.RevokeParams{})
+	if !errors.Is(err, mld.ErrParams) {
+		t.Fatalf("Expected error %s, got %s", mld.ErrParams, err)
+	}
+	err = m.Revoke(ctx, magiclink.RevokeParams{ID: "unknown"})
+	if !errors.Is(err, magiclink.ErrLinkNotFound) {
+		t.Fatalf("Expected error %s, got %s", magiclink.ErrLinkNotFound, err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		params func(res magiclink.CreateResponse) magiclink.RevokeParams
+	}{
+		{
+			name: "ID",
+			params: func(res magiclink.CreateResponse) magiclink.RevokeParams {
+				return magiclink.RevokeParams{ID: res.ID}
+			},
+		},
+		{
+			name: "Secret",
+			params: func(res magiclink.CreateResponse) magiclink.RevokeParams {
+				return magiclink.RevokeParams{Secret: res.Secret}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+				Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+				RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create magic link: %s", err)
+			}
+			if createRes.ID == "" {
+				t.Fatalf("Magic link ID should not be empty")
+			}
+
+			err = m.Revoke(ctx, tc.params(createRes))
+			if err != nil {
+				t.Fatalf("Failed to revoke magic link: %s", err)
+			}
+
+			resp, err := http.Get(createRes.MagicLink.String())
+			if err != nil {
+				t.Fatalf("Failed to GET magic link: %s", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusNotFound {
+				t.Fatalf("Revoked magic link did not return 404 Not Found: %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestMagicLink_CrossDevice(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	pollURL := func(token string) string {
+		return magicServer.URL + crossDevicePath + "?" + url.Values{magiclink.CrossDeviceQueryTokenKey: {token}}.Encode()
+	}
+	poll := func(token string) (magiclink.CrossDeviceResult, int) {
+		resp, err := http.Get(pollURL(token))
+		if err != nil {
+			t.Fatalf("Failed to poll cross-device endpoint: %s", err)
+		}
+		defer resp.Body.Close()
+		var result magiclink.CrossDeviceResult
+		if resp.StatusCode == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			if err != nil {
+				t.Fatalf("Failed to decode cross-device result: %s", err)
+			}
+		}
+		return result, resp.StatusCode
+	}
+
+	createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+		CrossDevice: true,
+		Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+		JWTClaims:   jwt.RegisteredClaims{Subject: "cross-device"},
+		RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+
+	_, code := poll(createRes.ID)
+	if code != http.StatusNotFound {
+		t.Fatalf("Cross-device JWTs should not be collected with the non-secret magic link ID: %d", code)
+	}
+
+	result, code := poll(createRes.CrossDeviceToken)
+	if code != http.StatusOK || result.Status != magiclink.CrossDeviceStatusPending || result.JWT != "" {
+		t.Fatalf("Unexpected cross-device result before visit: %d %+v", code, result)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pollURL(createRes.CrossDeviceToken), nil)
+	if err != nil {
+		t.Fatalf("Failed to create server-sent events request: %s", err)
+	}
+	req.Header.Set(mld.HeaderAccept, mld.ContentTypeEventStream)
+	events, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to request server-sent events: %s", err)
+	}
+	defer events.Body.Close()
+	if events.Header.Get(mld.HeaderContentType) != mld.ContentTypeEventStream {
+		t.Fatalf("Unexpected content type for server-sent events: %s", events.Header.Get(mld.HeaderContentType))
+	}
+	scanner := bufio.NewScanner(events.Body)
+	if !scanner.Scan() || scanner.Text() != "event: "+string(magiclink.CrossDeviceStatusPending) {
+		t.Fatalf("Expected pending event, got %q", scanner.Text())
+	}
+
+	resp, err := noRedirect.Get(createRes.MagicLink.String())
+	if err != nil {
+		t.Fatalf("Failed to GET magic link: %s", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to read confirmation page: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get(mld.HeaderContentType), "text/html") {
+		t.Fatalf("Cross-device magic link did not show a confirmation page: %d %s", resp.StatusCode, resp.Header.Get(mld.HeaderContentType))
+	}
+	if strings.Contains(string(body), "magiclinks.dev/?") {
+		t.Fatalf("Confirmation page should not contain the redirect URL")
+	}
+
+	var data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "event: "+string(magiclink.CrossDeviceStatusRedeemed) {
+			if !scanner.Scan() {
+				break
+			}
+			data = strings.TrimPrefix(scanner.Text(), "data: ")
+			break
+		}
+	}
+	err = json.Unmarshal([]byte(data), &result)
+	if err != nil {
+		t.Fatalf("Failed to decode redeemed event: %s", err)
+	}
+	if result.Status != magiclink.CrossDeviceStatusRedeemed {
+		t.Fatalf("Unexpected cross-device status: %s", result.Status)
+	}
+	var claims jwt.RegisteredClaims
+	_, err = jwt.ParseWithClaims(result.JWT, &claims, keyfunc(ctx, m.JWKSet()))
+	if err != nil {
+		t.Fatalf("Failed to parse cross-device JWT: %s", err)
+	}
+	if claims.Subject != "cross-device" {
+		t.Fatalf("Unexpected subject: %s", claims.Subject)
+	}
+
+	result, code = poll(createRes.CrossDeviceToken)
+	if code != http.StatusOK || result.Status != magiclink.CrossDeviceStatusCollected || result.JWT != "" {
+		t.Fatalf("Unexpected cross-device result after collection: %d %+v", code, result)
+	}
+
+	_, code = poll("unknown")
+	if code != http.StatusNotFound {
+		t.Fatalf("Unknown token did not return 404 Not Found: %d", code)
+	}
+	_, code = poll("")
+	if code != http.StatusBadRequest {
+		t.Fatalf("Missing ID did not return 400 Bad Request: %d", code)
+	}
+
+	sameDevice, err := m.NewLink(ctx, magiclink.CreateParams{
+		Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+	_, code = poll(sameDevice.ID)
+	if code != http.StatusNotFound {
+		t.Fatalf("Magic link that is not cross-device did not return 404 Not Found: %d", code)
+	}
+}
+
+func TestMagicLink_Status(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	_, err := m.Status(ctx, "")
+	if !errors.Is(err, mld.ErrParams) {
+		t.Fatalf("Expected error %s, got %s", mld.ErrParams, err)
+	}
+	_, err = m.Status(ctx, "unknown")
+	if !errors.Is(err, magiclink.ErrLinkNotFound) {
+		t.Fatalf("Expected error %s, got %s", magiclink.ErrLinkNotFound, err)
+	}
+
+	before := time.Now()
+	expires := before.Add(mldtest.LinksExpireAfter)
+	createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+		Expires:     expires,
+		RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+
+	status, err := m.Status(ctx, createRes.ID)
+	if err != nil {
+		t.Fatalf("Failed to read magic link status: %s", err)
+	}
+	if status.ID != createRes.ID || status.Created.Before(before) || !status.Expires.Equal(expires) || status.MaxVisits != 1 {
+		t.Fatalf("Unexpected magic link status: %+v", status)
+	}
+	if status.Visited != nil || status.Visits != 0 || status.Revoked != nil {
+		t.Fatalf("Magic link status should not show a visit or revocation: %+v", status)
+	}
+
+	_, err = m.Status(ctx, createRes.ID)
+	if err != nil {
+		t.Fatalf("Failed to read magic link status again: %s", err)
+	}
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := noRedirect.Get(createRes.MagicLink.String())
+	if err != nil {
+		t.Fatalf("Failed to GET magic link: %s", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("Reading the status should not count as a visit: %d", resp.StatusCode)
+	}
+
+	err = m.Revoke(ctx, magiclink.RevokeParams{ID: createRes.ID})
+	if err != nil {
+		t.Fatalf("Failed to revoke magic link: %s", err)
+	}
+	status, err = m.Status(ctx, createRes.ID)
+	if err != nil {
+		t.Fatalf("Failed to read magic link status: %s", err)
+	}
+	if status.Visited == nil || status.Visits != 1 || status.Revoked == nil {
+		t.Fatalf("Magic link status should show a visit and revocation: %+v", status)
+	}
+}
+
+func TestMagicLink_ResponseMode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	_, err := m.NewLink(ctx, magiclink.CreateParams{
+		Expires:      time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL:  must(url.Parse("https://magiclinks.dev/")),
+		ResponseMode: "unknown",
+	})
+	if !errors.Is(err, mld.ErrParams) {
+		t.Fatalf("Expected error %s, got %s", mld.ErrParams, err)
+	}
+
+	visit := func(mode magiclink.ResponseMode) (*http.Response, string) {
+		createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+			Expires:      time.Now().Add(mldtest.LinksExpireAfter),
+			RedirectURL:  must(url.Parse("https://magiclinks.dev/callback?state=abc")),
+			ResponseMode: mode,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create magic link: %s", err)
+		}
+		resp, err := noRedirect.Get(createRes.MagicLink.String())
+		if err != nil {
+			t.Fatalf("Failed to GET magic link: %s", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to read response body: %s", err)
+		}
+		return resp, string(body)
+	}
+
+	t.Run("Fragment", func(t *testing.T) {
+		resp, _ := visit(magiclink.ResponseModeFragment)
+		if resp.StatusCode != http.StatusSeeOther {
+			t.Fatalf("Unexpected status code: %d", resp.StatusCode)
+		}
+		u, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to parse redirect location: %s", err)
+		}
+		if u.Query().Has(magiclink.DefaultRedirectQueryKey) || u.Query().Get("state") != "abc" {
+			t.Fatalf("Unexpected redirect query: %s", u.RawQuery)
+		}
+		fragment, err := url.ParseQuery(u.Fragment)
+		if err != nil {
+			t.Fatalf("Failed to parse redirect fragment: %s", err)
+		}
+		_, err = jwt.Parse(fragment.Get(magiclink.DefaultRedirectQueryKey), keyfunc(ctx, m.JWKSet()))
+		if err != nil {
+			t.Fatalf("Failed to parse JWT from fragment: %s", err)
+		}
+	})
+
+	t.Run("FormPost", func(t *testing.T) {
+		resp, body := visit(magiclink.ResponseModeFormPost)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get(mld.HeaderContentType) != mld.ContentTypeHTML {
+			t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get(mld.HeaderContentType))
+		}
+		if !strings.Contains(body, `action="https://magiclinks.dev/callback?state=abc"`) {
+			t.Fatalf("Form does not submit to the redirect URL: %s", body)
+		}
+		if !strings.Contains(body, fmt.Sprintf(`name="%s"`, magiclink.DefaultRedirectQueryKey)) {
+			t.Fatalf("Form does not contain the JWT field: %s", body)
+		}
+	})
+}
+
+func TestMagicLink_State(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	const state = "/return/to?page=2"
+	_, err := m.NewLink(ctx, magiclink.CreateParams{
+		Expires:       time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL:   must(url.Parse("https://magiclinks.dev/callback")),
+		State:         state,
+		StateQueryKey: magiclink.DefaultRedirectQueryKey,
+	})
+	if !errors.Is(err, mld.ErrParams) {
+		t.Fatalf("Expected error %s, got %s", mld.ErrParams, err)
+	}
+
+	visit := func(mode magiclink.ResponseMode, accept string) (*http.Response, string) {
+		createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+			Expires:       time.Now().Add(mldtest.LinksExpireAfter),
+			RedirectURL:   must(url.Parse("https://magiclinks.dev/callback")),
+			ResponseMode:  mode,
+			State:         state,
+			StateQueryKey: "returnTo",
+		})
+		if err != nil {
+			t.Fatalf("Failed to create magic link: %s", err)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, createRes.MagicLink.String(), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %s", err)
+		}
+		if accept != "" {
+			req.Header.Set(mld.HeaderAccept, accept)
+		}
+		resp, err := noRedirect.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to GET magic link: %s", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to read response body: %s", err)
+		}
+		return resp, string(body)
+	}
+
+	t.Run("Query", func(t *testing.T) {
+		resp, _ := visit(magiclink.ResponseModeQuery, "")
+		if resp.StatusCode != http.StatusSeeOther {
+			t.Fatalf("Unexpected status code: %d", resp.StatusCode)
+		}
+		u, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to parse redirect location: %s", err)
+		}
+		if u.Query().Get("returnTo") != state || !u.Query().Has(magiclink.DefaultRedirectQueryKey) {
+			t.Fatalf("Unexpected redirect query: %s", u.RawQuery)
+		}
+	})
+
+	t.Run("FormPost", func(t *testing.T) {
+		_, body := visit(magiclink.ResponseModeFormPost, "")
+		if !strings.Contains(body, `name="returnTo" value="/return/to?page=2"`) {
+			t.Fatalf("Form does not contain the state field: %s", body)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		resp, body := visit(magiclink.ResponseModeQuery, mld.ContentTypeJSON)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected status code: %d", resp.StatusCode)
+		}
+		var redemption magiclink.Redemption
+		err := json.Unmarshal([]byte(body), &redemption)
+		if err != nil {
+			t.Fatalf("Failed to decode JSON response: %s", err)
+		}
+		if redemption.State != state {
+			t.Fatalf("Unexpected state: %q", redemption.State)
+		}
+	})
+}
+
+func TestMagicLink_LinkFormat(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	testCases := []struct {
+		name   string
+		params setupParams
+		check  func(t *testing.T, magicLink *url.URL, secret string)
+	}{
+		{
+			name:   "Query",
+			params: setupParams{},
+			check: func(t *testing.T, magicLink *url.URL, secret string) {
+				if magicLink.Path != magicLinkPath || magicLink.Query().Get(magiclink.DefaultSecretQueryKey) != secret {
+					t.Fatalf("Unexpected magic link: %s", magicLink)
+				}
+			},
+		},
+		{
+			name:   "Path",
+			params: setupParams{linkFormat: magiclink.LinkFormatPath},
+			check: func(t *testing.T, magicLink *url.URL, secret string) {
+				if magicLink.Path != magicLinkPath+"/"+secret || magicLink.RawQuery != "" {
+					t.Fatalf("Unexpected magic link: %s", magicLink)
+				}
+			},
+		},
+		{
+			name:   "PathShortCode",
+			params: setupParams{linkFormat: magiclink.LinkFormatPath, shortCodes: true},
+			check: func(t *testing.T, magicLink *url.URL, secret string) {
+				code := strings.TrimPrefix(magicLink.Path, magicLinkPath+"/")
+				if len(code) != 22 || code != magiclink.ShortCode(secret) {
+					t.Fatalf("Unexpected magic link: %s", magicLink)
+				}
+			},
+		},
+		{
+			name:   "QueryShortCode",
+			params: setupParams{shortCodes: true},
+			check: func(t *testing.T, magicLink *url.URL, secret string) {
+				if magicLink.Query().Get(magiclink.DefaultSecretQueryKey) != magiclink.ShortCode(secret) {
+					t.Fatalf("Unexpected magic link: %s", magicLink)
+				}
+			},
+		},
+	}
+
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, magicServer := magiclinkSetup(ctx, t, tc.params)
+			defer magicServer.Close()
+
+			visit := func(magicLink string) int {
+				resp, err := noRedirect.Get(magicLink)
+				if err != nil {
+					t.Fatalf("Failed to GET magic link: %s", err)
+				}
+				_ = resp.Body.Close()
+				return resp.StatusCode
+			}
+
+			createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+				Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+				RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create magic link: %s", err)
+			}
+			tc.check(t, createRes.MagicLink, createRes.Secret)
+			statusCode := visit(createRes.MagicLink.String())
+			if statusCode != http.StatusSeeOther {
+				t.Fatalf("Unexpected status code: %d", statusCode)
+			}
+
+			// The query format with the full secret is always accepted for backward compatibility.
+			createRes, err = m.NewLink(ctx, magiclink.CreateParams{
+				Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+				RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create magic link: %s", err)
+			}
+			statusCode = visit(magicServer.URL + magicLinkPath + "?" + magiclink.DefaultSecretQueryKey + "=" + createRes.Secret)
+			if statusCode != http.StatusSeeOther {
+				t.Fatalf("Unexpected status code for query format: %d", statusCode)
+			}
+		})
+	}
+}
+
+func TestShortCode(t *testing.T) {
+	secret := "00000000-0000-4000-8000-000000000000"
+	code := magiclink.ShortCode(secret)
+	if len(code) != 22 {
+		t.Fatalf("Unexpected short code length: %s", code)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/?"+magiclink.DefaultSecretQueryKey+"="+code, nil)
+	if magiclink.RequestSecret(r, magiclink.DefaultSecretQueryKey) != secret {
+		t.Fatalf("Short code was not converted back to the secret: %s", code)
+	}
+	if magiclink.ShortCode("not-a-uuid") != "not-a-uuid" {
+		t.Fatalf("Secrets that are not UUIDs should be returned unchanged.")
+	}
+}
+
+func TestMagicLink_QRCode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	get := func(token string, format magiclink.QRCodeFormat) (*http.Response, []byte) {
+		query := url.Values{magiclink.QRCodeQueryTokenKey: {token}, magiclink.QRCodeQueryFormatKey: {string(format)}}
+		resp, err := http.Get(magicServer.URL + qrCodePath + "?" + query.Encode())
+		if err != nil {
+			t.Fatalf("Failed to GET QR code: %s", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to read response body: %s", err)
+		}
+		return resp, body
+	}
+
+	createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+		Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+	if createRes.QRCodeToken != "" {
+		t.Fatalf("Magic links created without QRCode should not have a QR code token.")
+	}
+
+	createRes, err = m.NewLink(ctx, magiclink.CreateParams{
+		Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+		QRCode:      true,
+		RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+
+	resp, _ := get(createRes.ID, "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("QR codes should not be served by the non-secret magic link ID: %d", resp.StatusCode)
+	}
+
+	resp, body := get(createRes.QRCodeToken, magiclink.QRCodeFormatPNG)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(mld.HeaderContentType) != mld.ContentTypePNG {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get(mld.HeaderContentType))
+	}
+	png, err := magiclink.QRCodePNG(createRes.MagicLink)
+	if err != nil {
+		t.Fatalf("Failed to create QR code PNG: %s", err)
+	}
+	if !bytes.Equal(body, png) {
+		t.Fatalf("QR code PNG does not encode the magic link.")
+	}
+
+	resp, body = get(createRes.QRCodeToken, magiclink.QRCodeFormatSVG)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(mld.HeaderContentType) != mld.ContentTypeSVG {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get(mld.HeaderContentType))
+	}
+	if !bytes.HasPrefix(body, []byte("<svg")) {
+		t.Fatalf("Unexpected SVG: %s", body)
+	}
+
+	resp, _ = get(createRes.QRCodeToken, "gif")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Unknown formats should be rejected: %d", resp.StatusCode)
+	}
+
+	_, _, err = m.HandleMagicLink(ctx, createRes.Secret)
+	if err != nil {
+		t.Fatalf("Failed to handle magic link: %s", err)
+	}
+	resp, _ = get(createRes.QRCodeToken, "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("QR codes of used magic links should not be served: %d", resp.StatusCode)
+	}
+}
+
+func TestMagicLink_CustomScheme(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	_, err := m.NewLink(ctx, magiclink.CreateParams{
+		Expires:      time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL:  must(url.Parse("myapp://auth")),
+		ResponseMode: magiclink.ResponseModeFormPost,
+	})
+	if !errors.Is(err, mld.ErrParams) {
+		t.Fatalf("Expected error %s, got %s", mld.ErrParams, err)
+	}
+
+	createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+		Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL: must(url.Parse("myapp://auth?state=abc")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := noRedirect.Get(createRes.MagicLink.String())
+	if err != nil {
+		t.Fatalf("Failed to GET magic link: %s", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(mld.HeaderContentType) != mld.ContentTypeHTML {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get(mld.HeaderContentType))
+	}
+	if !strings.Contains(string(body), `href="myapp://auth?`) || !strings.Contains(string(body), "state=abc") {
+		t.Fatalf("Open app page does not link to the redirect URL: %s", body)
+	}
+}
+
+func TestMagicLink_Redeem(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	const redirectURL = "https://magiclinks.dev/callback?state=abc"
+	newLink := func() magiclink.CreateResponse {
+		createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+			Expires: time.Now().Add(mldtest.LinksExpireAfter),
+			JWTClaims: jwtClaims{
+				CustomValue1: "value1",
+			},
+			RedirectURL: must(url.Parse(redirectURL)),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create magic link: %s", err)
+		}
+		return createRes
+	}
+	checkRedemption := func(resp *http.Response) {
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get(mld.HeaderContentType) != mld.ContentTypeJSON {
+			t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get(mld.HeaderContentType))
+		}
+		var redemption magiclink.Redemption
+		err := json.NewDecoder(resp.Body).Decode(&redemption)
+		if err != nil {
+			t.Fatalf("Failed to decode JSON response: %s", err)
+		}
+		if redemption.RedirectURL != redirectURL {
+			t.Fatalf("Unexpected redirect URL: %s", redemption.RedirectURL)
+		}
+		_, err = jwt.Parse(redemption.JWT, keyfunc(ctx, m.JWKSet()))
+		if err != nil {
+			t.Fatalf("Failed to parse JWT: %s", err)
+		}
+		var claims jwtClaims
+		err = json.Unmarshal(redemption.Claims, &claims)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal claims: %s", err)
+		}
+		if claims.CustomValue1 != "value1" {
+			t.Fatalf("Unexpected claims: %s", redemption.Claims)
+		}
+	}
+	redeem := func(method, body string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, method, magicServer.URL+redeemPath, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to create request: %s", err)
+		}
+		req.Header.Set(mld.HeaderContentType, mld.ContentTypeJSON)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to redeem magic link: %s", err)
+		}
+		return resp
+	}
+
+	t.Run("AcceptJSON", func(t *testing.T) {
+		createRes := newLink()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, createRes.MagicLink.String(), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %s", err)
+		}
+		req.Header.Set(mld.HeaderAccept, mld.ContentTypeJSON)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to GET magic link: %s", err)
+		}
+		checkRedemption(resp)
+	})
+
+	t.Run("Endpoint", func(t *testing.T) {
+		createRes := newLink()
+		body := fmt.Sprintf(`{"secret":%q}`, createRes.Secret)
+		checkRedemption(redeem(http.MethodPost, body))
+
+		resp := redeem(http.MethodPost, body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("Expected status code %d for used magic link, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("BadRequest", func(t *testing.T) {
+		for _, tt := range []struct {
+			method     string
+			body       string
+			statusCode int
+		}{
+			{method: http.MethodGet, statusCode: http.StatusMethodNotAllowed},
+			{method: http.MethodPost, body: "not JSON", statusCode: http.StatusBadRequest},
+			{method: http.MethodPost, body: "{}", statusCode: http.StatusBadRequest},
+		} {
+			resp := redeem(tt.method, tt.body)
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.statusCode {
+				t.Fatalf("Expected status code %d for %s %q, got %d", tt.statusCode, tt.method, tt.body, resp.StatusCode)
+			}
+		}
+	})
+}
+
+func TestMagicLink_Exchange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	m, magicServer := magiclinkSetup(ctx, t, setupParams{})
+	defer magicServer.Close()
+
+	noRedirect := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	codeVerifier := strings.Repeat("v", magiclink.CodeVerifierMinLength)
+	_, err := m.NewLink(ctx, magiclink.CreateParams{
+		CodeChallenge: "not a code challenge",
+		Expires:       time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL:   must(url.Parse("https://magiclinks.dev/")),
+	})
+	if !errors.Is(err, mld.ErrParams) {
+		t.Fatalf("Expected error %s, got %s", mld.ErrParams, err)
+	}
+
+	createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+		CodeChallenge: magiclink.CodeChallengeS256(codeVerifier),
+		Expires:       time.Now().Add(mldtest.LinksExpireAfter),
+		JWTClaims:     jwt.RegisteredClaims{Subject: "pkce"},
+		RedirectURL:   must(url.Parse("https://magiclinks.dev/")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+
+	visit := func() string {
+		resp, err := noRedirect.Get(createRes.MagicLink.String())
+		if err != nil {
+			t.Fatalf("Failed to GET magic link: %s", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusSeeOther {
+			t.Fatalf("Unexpected status code: %d", resp.StatusCode)
+		}
+		location, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to parse redirect location: %s", err)
+		}
+		if location.Query().Has(magiclink.DefaultRedirectQueryKey) {
+			t.Fatalf("Magic link with a code challenge should not deliver a JWT")
+		}
+		code := location.Query().Get(magiclink.DefaultCodeQueryKey)
+		if code == "" || code == createRes.Secret {
+			t.Fatalf("Magic link did not deliver a code separate from its secret: %s", location)
+		}
+		return code
+	}
+
+	replaced := visit()
+	code := visit()
+	if code == replaced {
+		t.Fatalf("Each visit should deliver a new code")
+	}
+
+	for _, tt := range []struct {
+		code string
+		err  error
+	}{
+		{code: replaced, err: magiclink.ErrLinkNotFound},
+		{code: createRes.Secret, err: magiclink.ErrLinkNotFound},
+		{code: code, err: magiclink.ErrCodeVerifierInvalid},
+		{code: code, err: magiclink.ErrLinkNotFound},
+	} {
+		verifier := codeVerifier
+		if errors.Is(tt.err, magiclink.ErrCodeVerifierInvalid) {
+			verifier = strings.Repeat("x", magiclink.CodeVerifierMinLength)
+		}
+		_, _, err = m.Exchange(ctx, magiclink.ExchangeParams{Code: tt.code, CodeVerifier: verifier})
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("Expected error %s, got %s", tt.err, err)
+		}
+	}
+
+	expired, err := m.Store.MagicLinkCodeCreate(ctx, createRes.Secret, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("Failed to create code: %s", err)
+	}
+	_, _, err = m.Exchange(ctx, magiclink.ExchangeParams{Code: expired, CodeVerifier: codeVerifier})
+	if !errors.Is(err, magiclink.ErrLinkExpired) {
+		t.Fatalf("Expected error %s, got %s", magiclink.ErrLinkExpired, err)
+	}
+
+	code = visit()
+	jwtB64, _, err := m.Exchange(ctx, magiclink.ExchangeParams{Code: code, CodeVerifier: codeVerifier})
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %s", err)
+	}
+	var claims jwt.RegisteredClaims
+	_, err = jwt.ParseWithClaims(jwtB64, &claims, keyfunc(ctx, m.JWKSet()))
+	if err != nil {
+		t.Fatalf("Failed to parse exchanged JWT: %s", err)
+	}
+	if claims.Subject != "pkce" {
+		t.Fatalf("Unexpected subject: %s", claims.Subject)
+	}
+
+	_, _, err = m.Exchange(ctx, magiclink.ExchangeParams{Code: code, CodeVerifier: codeVerifier})
+	if !errors.Is(err, magiclink.ErrLinkNotFound) {
+		t.Fatalf("Expected error %s, got %s", magiclink.ErrLinkNotFound, err)
+	}
+
+	bound, err := m.NewLink(ctx, magiclink.CreateParams{
+		Binding: magiclink.ClientBinding{
+			IP:                   "203.0.113.0/24",
+			UserAgentFingerprint: magiclink.UserAgentFingerprint("magiclinksdev-test"),
+		},
+		CodeChallenge: magiclink.CodeChallengeS256(codeVerifier),
+		Expires:       time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL:   must(url.Parse("https://magiclinks.dev/")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+	for _, tt := range []struct {
+		clientIP  string
+		userAgent string
+		err       error
+	}{
+		{clientIP: "127.0.0.1", userAgent: "magiclinksdev-test", err: magiclink.ErrClientBindingMismatch},
+		{clientIP: "203.0.113.7", userAgent: "other", err: magiclink.ErrClientBindingMismatch},
+		{userAgent: "magiclinksdev-test", err: magiclink.ErrClientBindingMismatch},
+		{clientIP: "203.0.113.7", userAgent: "magiclinksdev-test"},
+	} {
+		code, err := m.Store.MagicLinkCodeCreate(ctx, bound.Secret, time.Now().Add(magiclink.CodeLifespan))
+		if err != nil {
+			t.Fatalf("Failed to create code: %s", err)
+		}
+		_, _, err = m.Exchange(ctx, magiclink.ExchangeParams{
+			ClientIP:     tt.clientIP,
+			Code:         code,
+			CodeVerifier: codeVerifier,
+			UserAgent:    tt.userAgent,
+		})
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("Expected error %v, got %v", tt.err, err)
+		}
+	}
+
+	plain, err := m.NewLink(ctx, magiclink.CreateParams{
+		Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create magic link: %s", err)
+	}
+	_, _, err = m.Exchange(ctx, magiclink.ExchangeParams{Code: plain.Secret, CodeVerifier: codeVerifier})
+	if !errors.Is(err, magiclink.ErrLinkNotFound) {
+		t.Fatalf("Expected error %s, got %s", magiclink.ErrLinkNotFound, err)
+	}
+}
+
+func TestMagicLink_Binding(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	const userAgent = "magiclinksdev-test"
+
+	err := magiclink.CreateParams{
+		Binding: magiclink.ClientBinding{
+			IP: "not an IP",
+		},
+		Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+		RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+	}.Valid()
+	if !errors.Is(err, mld.ErrParams) {
+		t.Fatalf("Expected error %s, got %s", mld.ErrParams, err)
+	}
+
+	tc := []struct {
+		name       string
+		binding    magiclink.ClientBinding
+		mode       magiclink.BindingMode
+		userAgent  string
+		statusCode int
+		mismatch   bool
+		mismatchIP bool
+		mismatchUA bool
+		warned     bool
+	}{
+		{
+			name:       "Match",
+			binding:    magiclink.ClientBinding{IP: "127.0.0.0/8", UserAgentFingerprint: magiclink.UserAgentFingerprint(userAgent)},
+			userAgent:  userAgent,
+			statusCode: http.StatusSeeOther,
+		},
+		{
+			name:       "StrictIPMismatch",
+			binding:    magiclink.ClientBinding{IP: "203.0.113.0/24"},
+			userAgent:  userAgent,
+			statusCode: http.StatusForbidden,
+			mismatch:   true,
+			mismatchIP: true,
+		},
+		{
+			name:       "StrictUserAgentMismatch",
+			binding:    magiclink.ClientBinding{IP: "127.0.0.1", UserAgentFingerprint: magiclink.UserAgentFingerprint(userAgent)},
+			userAgent:  "other",
+			statusCode: http.StatusForbidden,
+			mismatch:   true,
+			mismatchUA: true,
+		},
+		{
+			name:       "WarnMismatch",
+			binding:    magiclink.ClientBinding{IP: "203.0.113.0/24", UserAgentFingerprint: magiclink.UserAgentFingerprint(userAgent)},
+			mode:       magiclink.BindingModeWarn,
+			userAgent:  "other",
+			statusCode: http.StatusSeeOther,
+			warned:     true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *magiclink.ClientBindingError
+			errorHandler := magiclink.ErrorHandlerFunc(func(args magiclink.ErrorHandlerParams) {
+				if !errors.As(args.Err, &handled) {
+					t.Errorf("Unexpected error: %s", args.Err)
+				}
+				args.Writer.WriteHeader(args.SuggestedResponseCode)
+			})
+			logs := &bytes.Buffer{}
+			m, magicServer := magiclinkSetup(ctx, t, setupParams{
+				binding:      magiclink.BindingConfig{Mode: tt.mode},
+				errorHandler: errorHandler,
+				logger:       slog.New(slog.NewTextHandler(logs, nil)),
+			})
+			defer magicServer.Close()
+
+			createRes, err := m.NewLink(ctx, magiclink.CreateParams{
+				Binding:     tt.binding,
+				Expires:     time.Now().Add(mldtest.LinksExpireAfter),
+				RedirectURL: must(url.Parse("https://magiclinks.dev/")),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create magic link: %s", err)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, createRes.MagicLink.String(), nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %s", err)
+			}
+			req.Header.Set("User-Agent", tt.userAgent)
+			noRedirect := &http.Client{
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			}
+			resp, err := noRedirect.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to GET magic link: %s", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.statusCode {
+				t.Fatalf("Unexpected status code: %d", resp.StatusCode)
+			}
+
+			if (handled != nil) != tt.mismatch {
+				t.Fatalf("Expected mismatch to be %t", tt.mismatch)
+			}
+			if strings.Contains(logs.String(), magiclink.ErrClientBindingMismatch.Error()) != tt.warned {
+				t.Fatalf("Expected mismatch warning to be %t", tt.warned)
+			}
+			if handled != nil {
+				if handled.IP != tt.mismatchIP || handled.UserAgent != tt.mismatchUA {
+					t.Fatalf("Unexpected mismatch: %s", handled)
+				}
+				expectedMode := tt.mode
+				if expectedMode == "" {
+					expectedMode = magiclink.BindingModeStrict
+				}
+				if handled.Mode != expectedMode {
+					t.Fatalf("Unexpected binding mode: %s", handled.Mode)
+				}
+			}
+
+			_, err = m.Store.MagicLinkPeek(ctx, createRes.Secret)
+			if tt.statusCode == http.StatusForbidden && err != nil {
+				t.Fatalf("Magic link should not be visited after a strict mismatch: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package magiclink
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+var openAppTmpl = template.Must(template.New("").Parse(openAppTemplate))
+
+type openAppTemplateData struct {
+	ButtonText  string
+	CSS         template.CSS
+	Code        string
+	HTMLTitle   string
+	Instruction string
+	Title       string
+	URL         template.URL
+}
+
+// HTTPRedirect determines if the redirect URL uses the http or https scheme. Other schemes, such as a mobile app's deep
+// link, are redirected to with an intermediate page because browsers may not follow an HTTP redirect to them.
+func HTTPRedirect(u *url.URL) bool {
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return true
+	}
+	return false
+}
+
+// writeOpenApp writes a page that opens the redirect URL with JavaScript and, if that does not work, a button for the
+// user to open it. The redirect URL must already be validated, because the template does not escape its scheme.
+func writeOpenApp(w http.ResponseWriter, u *url.URL) {
+	data := openAppTemplateData{
+		ButtonText:  "Open app",
+		CSS:         template.CSS(defaultCSS),
+		Code:        "OPENING APP",
+		HTMLTitle:   "Magic Link - Opening App",
+		Instruction: "If the app does not open automatically, use the button below.",
+		Title:       "Signing you in...",
+		URL:         template.URL(u.String()),
+	}
+	w.Header().Set(mld.HeaderContentType, mld.ContentTypeHTML)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	_ = openAppTmpl.Execute(w, data)
+}
@@ -0,0 +1,72 @@
+package magiclink
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+const (
+	// DefaultCodeQueryKey is the URL query parameter to contain the code after a magic link created with a code
+	// challenge has been clicked.
+	DefaultCodeQueryKey = "code"
+	// CodeVerifierMinLength is the minimum length of a code verifier, as defined in RFC 7636.
+	CodeVerifierMinLength = 43
+	// CodeVerifierMaxLength is the maximum length of a code verifier, as defined in RFC 7636.
+	CodeVerifierMaxLength = 128
+	// CodeLifespan is how long the code delivered after a magic link created with a code challenge has been clicked can
+	// be exchanged. Each code can only be exchanged once.
+	CodeLifespan = 5 * time.Minute
+)
+
+// CodeChallengeS256 creates the code challenge for the given code verifier using the S256 method from RFC 7636.
+func CodeChallengeS256(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ExchangeParams are the parameters for exchanging the code from a magic link created with a code challenge for a
+// JWT.
+type ExchangeParams struct {
+	// ClientIP is the IP address of the client that clicked the magic link. It is checked against the magic link's
+	// ClientBinding the same way as a visit. Use of this field is REQUIRED if the magic link's ClientBinding has an IP.
+	ClientIP string
+	// Code is the code delivered to the redirect URL when the magic link was clicked. Use of this field is REQUIRED.
+	Code string
+	// CodeVerifier is the secret the code challenge was created from. Use of this field is REQUIRED.
+	CodeVerifier string
+	// UserAgent is the User-Agent header of the client that clicked the magic link. It is checked against the magic
+	// link's ClientBinding the same way as a visit. Use of this field is REQUIRED if the magic link's ClientBinding has a
+	// UserAgentFingerprint.
+	UserAgent string
+}
+
+// Valid confirms the ExchangeParams are valid.
+func (p ExchangeParams) Valid() error {
+	if p.Code == "" {
+		return fmt.Errorf("%w: Code is required", mld.ErrParams)
+	}
+	if len(p.CodeVerifier) < CodeVerifierMinLength || len(p.CodeVerifier) > CodeVerifierMaxLength {
+		return fmt.Errorf("%w: CodeVerifier must be between %d and %d characters", mld.ErrParams, CodeVerifierMinLength, CodeVerifierMaxLength)
+	}
+	return nil
+}
+
+func validCodeChallenge(codeChallenge string) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(codeChallenge)
+	if err != nil || len(decoded) != sha256.Size {
+		return fmt.Errorf("%w: CodeChallenge must be an unpadded Base64 URL encoded SHA-256 hash", mld.ErrParams)
+	}
+	return nil
+}
+
+func verifyCodeChallenge(codeChallenge, codeVerifier string) bool {
+	if codeChallenge == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(codeChallenge), []byte(CodeChallengeS256(codeVerifier))) == 1
+}
@@ -0,0 +1,153 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"math/bits"
+	"strconv"
+
+	jt "github.com/MicahParks/jsontype"
+)
+
+const (
+	// DefaultProofOfWorkDifficulty is the default number of leading zero bits required for a proof-of-work solution.
+	DefaultProofOfWorkDifficulty = 16
+	// MaxProofOfWorkDifficulty is the maximum number of leading zero bits that can be required for a proof-of-work
+	// solution.
+	MaxProofOfWorkDifficulty = 32
+
+	proofOfWorkMaxNonceLength = 20
+)
+
+// ErrProofOfWork indicates that a proof-of-work solution is invalid.
+var ErrProofOfWork = errors.New("proof-of-work solution is invalid")
+
+// ProofOfWorkChallenge creates the proof-of-work challenge for the magic link with the given secret.
+func ProofOfWorkChallenge(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// SolveProofOfWork finds a nonce for the given challenge and difficulty. It is the same algorithm the proof-of-work
+// page runs in the browser and is useful for non-browser clients and testing.
+func SolveProofOfWork(challenge string, difficulty int) string {
+	for nonce := uint64(0); ; nonce++ {
+		n := strconv.FormatUint(nonce, 10)
+		if proofOfWorkLeadingZeros(challenge, n) >= difficulty {
+			return n
+		}
+	}
+}
+
+// VerifyProofOfWork confirms the SHA-256 hash of the challenge concatenated with the nonce has at least difficulty
+// leading zero bits.
+func VerifyProofOfWork(challenge, nonce string, difficulty int) error {
+	if nonce == "" || len(nonce) > proofOfWorkMaxNonceLength {
+		return fmt.Errorf("%w: nonce must be between 1 and %d characters", ErrProofOfWork, proofOfWorkMaxNonceLength)
+	}
+	if proofOfWorkLeadingZeros(challenge, nonce) < difficulty {
+		return fmt.Errorf("%w: hash does not have %d leading zero bits", ErrProofOfWork, difficulty)
+	}
+	return nil
+}
+
+func proofOfWorkLeadingZeros(challenge, nonce string) int {
+	sum := sha256.Sum256([]byte(challenge + nonce))
+	zeros := 0
+	for _, b := range sum {
+		zeros += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return zeros
+}
+
+// ProofOfWorkConfig is the configuration for the self-hosted proof-of-work challenge.
+type ProofOfWorkConfig struct {
+	Difficulty   int                     `json:"difficulty"`
+	TemplateData ProofOfWorkTemplateData `json:"templateData"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (p ProofOfWorkConfig) DefaultsAndValidate() (ProofOfWorkConfig, error) {
+	if p.Difficulty == 0 {
+		p.Difficulty = DefaultProofOfWorkDifficulty
+	}
+	if p.Difficulty < 0 || p.Difficulty > MaxProofOfWorkDifficulty {
+		return p, fmt.Errorf("%w: proof-of-work difficulty must be between 1 and %d", jt.ErrDefaultsAndValidate, MaxProofOfWorkDifficulty)
+	}
+	var err error
+	p.TemplateData, err = p.TemplateData.DefaultsAndValidate()
+	if err != nil {
+		return p, fmt.Errorf("failed to validate proof-of-work template data: %w", err)
+	}
+	return p, nil
+}
+
+// ProofOfWorkRedirector is a Redirector that requires the browser to solve a hash-based proof-of-work challenge before
+// the magic link is visited. The challenge is derived from the magic link's secret, so no state is kept and no
+// third-party service is required. The solution is sent as the CaptchaQueryTokenKey URL query parameter in a POST
+// request.
+type ProofOfWorkRedirector struct {
+	difficulty int
+	tmpl       *template.Template
+	tmplData   ProofOfWorkTemplateData
+}
+
+// NewProofOfWorkRedirector creates a new ProofOfWorkRedirector with the given config.
+func NewProofOfWorkRedirector(config ProofOfWorkConfig) Redirector {
+	tmpl := template.Must(template.New("").Parse(proofOfWorkTemplate))
+	p := ProofOfWorkRedirector{
+		difficulty: config.Difficulty,
+		tmpl:       tmpl,
+		tmplData:   config.TemplateData,
+	}
+	return p
+}
+
+func (p ProofOfWorkRedirector) Redirect(args RedirectorParams) {
+	challenge := ProofOfWorkChallenge(args.Secret)
+	tData := p.tmplData
+	tData.Challenge = challenge
+	tData.Difficulty = p.difficulty
+	captchaRedirect(args, p.tmpl, tData, func(_ context.Context, nonce string) error {
+		return VerifyProofOfWork(challenge, nonce, p.difficulty)
+	})
+}
+
+// ProofOfWorkTemplateData is the configuration for the HTML template for the proof-of-work challenge.
+type ProofOfWorkTemplateData struct {
+	CSS         template.CSS `json:"css"`
+	Code        string       `json:"code"`
+	HTMLTitle   string       `json:"htmlTitle"`
+	Instruction string       `json:"instruction"`
+	Title       string       `json:"title"`
+
+	Challenge  string `json:"-"`
+	Difficulty int    `json:"-"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (p ProofOfWorkTemplateData) DefaultsAndValidate() (ProofOfWorkTemplateData, error) {
+	if p.CSS == "" {
+		p.CSS = template.CSS(defaultCSS)
+	}
+	if p.Code == "" {
+		p.Code = "BROWSER CHECK"
+	}
+	if p.HTMLTitle == "" {
+		p.HTMLTitle = "Magic Link - Browser Check"
+	}
+	if p.Instruction == "" {
+		p.Instruction = "This page helps prevent robots from using magic links. You should be redirected automatically."
+	}
+	if p.Title == "" {
+		p.Title = "Checking your browser..."
+	}
+	return p, nil
+}
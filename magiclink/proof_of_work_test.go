@@ -0,0 +1,126 @@
+package magiclink_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MicahParks/magiclinksdev/magiclink"
+	"github.com/MicahParks/magiclinksdev/mldtest"
+)
+
+func TestProofOfWork(t *testing.T) {
+	const (
+		challenge  = "ab12"
+		difficulty = 18
+	)
+	nonce := magiclink.SolveProofOfWork(challenge, difficulty)
+	if nonce != "392629" { // Same result as the browser implementation.
+		t.Fatalf("Expected nonce %q, but got %q.", "392629", nonce)
+	}
+	err := magiclink.VerifyProofOfWork(challenge, nonce, difficulty)
+	if err != nil {
+		t.Fatalf("Failed to verify proof-of-work: %v.", err)
+	}
+	err = magiclink.VerifyProofOfWork(challenge, "0", difficulty)
+	if !errors.Is(err, magiclink.ErrProofOfWork) {
+		t.Fatalf("Expected error %v, but got %v.", magiclink.ErrProofOfWork, err)
+	}
+	err = magiclink.VerifyProofOfWork(challenge, strings.Repeat("1", 21), difficulty)
+	if !errors.Is(err, magiclink.ErrProofOfWork) {
+		t.Fatalf("Expected error %v, but got %v.", magiclink.ErrProofOfWork, err)
+	}
+
+	_, err = magiclink.ProofOfWorkConfig{Difficulty: magiclink.MaxProofOfWorkDifficulty + 1}.DefaultsAndValidate()
+	if err == nil {
+		t.Fatalf("Expected an error for a difficulty that is too high.")
+	}
+}
+
+func TestProofOfWorkRedirector_Redirect(t *testing.T) {
+	const (
+		jwtB64FromBackend = "jwtB64"
+		magicLinkTarget   = "https://magiclinks.dev/"
+		secret            = "secret"
+	)
+
+	conf, err := magiclink.ProofOfWorkConfig{Difficulty: 8}.DefaultsAndValidate()
+	if err != nil {
+		t.Fatalf("Failed to apply defaults and validate: %v.", err)
+	}
+	redirector := magiclink.NewProofOfWorkRedirector(conf)
+	challenge := magiclink.ProofOfWorkChallenge(secret)
+
+	tc := []struct {
+		name     string
+		method   string
+		respCode int
+		url      string
+		visited  bool
+	}{
+		{
+			name:     "Frontend",
+			method:   http.MethodGet,
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "BackendFailure",
+			method:   http.MethodPost,
+			respCode: http.StatusBadRequest,
+			url:      "?token=bad",
+		},
+		{
+			name:     "BackendSuccess",
+			method:   http.MethodPost,
+			respCode: http.StatusOK,
+			url:      "?token=" + magiclink.SolveProofOfWork(challenge, conf.Difficulty),
+			visited:  true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v.", err)
+			}
+			recorder := httptest.NewRecorder()
+			visited := false
+			args := magiclink.RedirectorParams{
+				ReadAndExpireLink: func(ctx context.Context, secret string) (jwtB64 string, response magiclink.ReadResult, err error) {
+					visited = true
+					return jwtB64FromBackend, magiclink.ReadResult{
+						CreateParams: magiclink.CreateParams{
+							Expires:          time.Now().Add(mldtest.LinksExpireAfter),
+							RedirectQueryKey: magiclink.DefaultRedirectQueryKey,
+							RedirectURL:      must(url.Parse(magicLinkTarget)),
+						},
+					}, nil
+				},
+				Request: r,
+				Secret:  secret,
+				Writer:  recorder,
+			}
+
+			redirector.Redirect(args)
+
+			if recorder.Code != tt.respCode {
+				t.Fatalf("Expected status code %v, but got %v.", tt.respCode, recorder.Code)
+			}
+			if visited != tt.visited {
+				t.Fatalf("Expected magic link visited to be %v, but got %v.", tt.visited, visited)
+			}
+			if tt.method == http.MethodGet {
+				body := recorder.Body.String()
+				if !strings.Contains(body, fmt.Sprintf(`const challenge = "%s";`, challenge)) {
+					t.Fatalf("Expected body to contain the challenge, but got %v.", body)
+				}
+			}
+		})
+	}
+}
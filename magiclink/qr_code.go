@@ -0,0 +1,123 @@
+package magiclink
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"rsc.io/qr"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+const (
+	// QRCodeFormatPNG is a QR code as a PNG image. This is the default.
+	QRCodeFormatPNG QRCodeFormat = "png"
+	// QRCodeFormatSVG is a QR code as an SVG image.
+	QRCodeFormatSVG QRCodeFormat = "svg"
+
+	// QRCodeQueryFormatKey is the URL query parameter key for the QRCodeFormat of the QR code image.
+	QRCodeQueryFormatKey = "format"
+	// QRCodeQueryTokenKey is the URL query parameter key for the QR code token of the QR code image.
+	QRCodeQueryTokenKey = "token"
+
+	// qrCodeQuietZone is the number of modules of white space around the QR code that scanners need to find it.
+	qrCodeQuietZone = 4
+)
+
+// QRCodeFormat is a set of string constants that indicate the image format of a QR code.
+type QRCodeFormat string
+
+// Valid confirms the QRCodeFormat is known. The empty string is valid and means QRCodeFormatPNG.
+func (f QRCodeFormat) Valid() error {
+	switch f {
+	case "", QRCodeFormatPNG, QRCodeFormatSVG:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown QR code format %q", mld.ErrParams, f)
+}
+
+// QRCodePNG encodes the magic link as a QR code PNG image.
+func QRCodePNG(magicLink *url.URL) ([]byte, error) {
+	code, err := qr.Encode(magicLink.String(), qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return code.PNG(), nil
+}
+
+// QRCodeSVG encodes the magic link as a QR code SVG image. The image scales to the size of its container.
+func QRCodeSVG(magicLink *url.URL) ([]byte, error) {
+	code, err := qr.Encode(magicLink.String(), qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	size := code.Size + 2*qrCodeQuietZone
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size)
+	_, _ = fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, size, size)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				_, _ = fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+qrCodeQuietZone, y+qrCodeQuietZone)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
+
+// QRCodeHandler is an HTTP handler that responds with a QR code image of a magic link, so it can be scanned by a phone
+// to sign in on a kiosk or TV. The QR code token returned when the magic link was created is given with the
+// QRCodeQueryTokenKey URL query parameter and the image format with the QRCodeQueryFormatKey URL query parameter. Only magic links created with QRCode are served and only
+// until they expire, are revoked, or have no visits remaining. Pair it with the CrossDeviceHandler to sign in the
+// device showing the QR code.
+func (m MagicLink) QRCodeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		token := r.URL.Query().Get(QRCodeQueryTokenKey)
+		if token == "" {
+			m.handleError(fmt.Errorf("%w: missing QR code token", ErrQRCodeRequest), http.StatusBadRequest, r, w)
+			return
+		}
+		format := QRCodeFormat(r.URL.Query().Get(QRCodeQueryFormatKey))
+		err := format.Valid()
+		if err != nil {
+			m.handleError(fmt.Errorf("%w: %s", ErrQRCodeRequest, err), http.StatusBadRequest, r, w)
+			return
+		}
+
+		secret, err := m.Store.MagicLinkQRCodeRead(ctx, token)
+		if err != nil {
+			if errors.Is(err, ErrLinkNotFound) {
+				m.handleError(err, http.StatusNotFound, r, w)
+				return
+			}
+			m.handleError(fmt.Errorf("%w: %s", ErrMagicLinkRead, err), http.StatusInternalServerError, r, w)
+			return
+		}
+
+		magicLink := m.link(secret)
+		contentType := mld.ContentTypePNG
+		var image []byte
+		if format == QRCodeFormatSVG {
+			contentType = mld.ContentTypeSVG
+			image, err = QRCodeSVG(magicLink)
+		} else {
+			image, err = QRCodePNG(magicLink)
+		}
+		if err != nil {
+			m.handleError(fmt.Errorf("%w: %s", ErrQRCodeEncode, err), http.StatusInternalServerError, r, w)
+			return
+		}
+
+		w.Header().Set(mld.HeaderContentType, contentType)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(image)
+	})
+}
@@ -94,9 +94,7 @@ func (r ReCAPTCHAV3Redirector) Redirect(args RedirectorParams) {
 				args.Writer.WriteHeader(http.StatusNotFound)
 				return
 			}
-			u := redirectURLFromResponse(response, jwtB64)
-			args.Writer.WriteHeader(http.StatusOK)
-			_, _ = args.Writer.Write([]byte(u.String()))
+			writeRedemption(args.Writer, args.Request, jwtB64, response, true)
 			return
 		}
 		if r.tmplData.ButtonBypass && args.Request.URL.Query().Get(ReCAPTCHAV3QueryButtonBypassKey) == ReCAPTCHAV3QueryButtonBypassValue {
@@ -105,8 +103,7 @@ func (r ReCAPTCHAV3Redirector) Redirect(args RedirectorParams) {
 				args.Writer.WriteHeader(http.StatusNotFound)
 				return
 			}
-			u := redirectURLFromResponse(response, jwtB64)
-			http.Redirect(args.Writer, args.Request, u.String(), http.StatusSeeOther)
+			writeRedemption(args.Writer, args.Request, jwtB64, response, false)
 			return
 		}
 	}
@@ -28,12 +28,14 @@ func TestReCAPTCHAV3Redirector_Redirect(t *testing.T) {
 	tc := []struct {
 		name         string
 		body         string
+		bodyContains string
 		buttonBypass bool
 		haveBody     bool
 		htmlResp     bool
 		httpRedirect bool
 		method       string
 		respCode     int
+		responseMode magiclink.ResponseMode
 		url          string
 		verifier     recaptcha.VerifierV3
 	}{
@@ -68,6 +70,26 @@ func TestReCAPTCHAV3Redirector_Redirect(t *testing.T) {
 			url:      "?token=good",
 			verifier: recaptchav3Success{},
 		},
+		{
+			name:         "NoButtonBypassBackendSuccessFragment",
+			body:         fmt.Sprintf("%s#%s=%s", magicLinkTarget, magiclink.DefaultRedirectQueryKey, jwtB64FromBackend),
+			haveBody:     true,
+			method:       http.MethodPost,
+			respCode:     http.StatusOK,
+			responseMode: magiclink.ResponseModeFragment,
+			url:          "?token=good",
+			verifier:     recaptchav3Success{},
+		},
+		{
+			name:         "NoButtonBypassBackendSuccessFormPost",
+			bodyContains: fmt.Sprintf(`value="%s"`, jwtB64FromBackend),
+			haveBody:     true,
+			method:       http.MethodPost,
+			respCode:     http.StatusOK,
+			responseMode: magiclink.ResponseModeFormPost,
+			url:          "?token=good",
+			verifier:     recaptchav3Success{},
+		},
 		{
 			name:         "ButtonBypassFrontend",
 			buttonBypass: true,
@@ -108,6 +130,7 @@ func TestReCAPTCHAV3Redirector_Redirect(t *testing.T) {
 							Expires:          time.Now().Add(mldtest.LinksExpireAfter),
 							RedirectQueryKey: magiclink.DefaultRedirectQueryKey,
 							RedirectURL:      must(url.Parse(magicLinkTarget)),
+							ResponseMode:     tt.responseMode,
 						},
 					}, nil
 				},
@@ -134,6 +157,9 @@ func TestReCAPTCHAV3Redirector_Redirect(t *testing.T) {
 					t.Fatalf("Expected a body, but got none.")
 				}
 				body := recorder.Body.String()
+				if tt.bodyContains != "" && !strings.Contains(body, tt.bodyContains) {
+					t.Fatalf("Expected body to contain %v, but got %v.", tt.bodyContains, body)
+				}
 				if tt.htmlResp {
 					if tt.buttonBypass != strings.Contains(body, "</button>") {
 						t.Fatalf("Bypass button precense was incorrect.")
@@ -0,0 +1,109 @@
+package magiclink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+// RedeemParams is the JSON request body for RedeemHandler.
+type RedeemParams struct {
+	// Secret is the magic link secret or its short code.
+	Secret string `json:"secret"`
+}
+
+// Redemption is the JSON response for a magic link that was successfully visited by a client that accepts JSON.
+type Redemption struct {
+	// Claims are the claims of the JWT. They are empty when JWT is empty.
+	Claims json.RawMessage `json:"claims,omitempty"`
+	// Code is the code to exchange for a JWT. It is only populated for magic links created with a code challenge.
+	Code string `json:"code,omitempty"`
+	// CrossDevice indicates the JWT is collected by the device that requested the magic link, so JWT is empty.
+	CrossDevice bool `json:"crossDevice,omitempty"`
+	// JWT is the signed JWT.
+	JWT string `json:"jwt,omitempty"`
+	// RedirectURL is the redirect URL given when the magic link was created. It does not contain the JWT.
+	RedirectURL string `json:"redirectURL"`
+	// State is the opaque value given when the magic link was created.
+	State string `json:"state,omitempty"`
+}
+
+// RedeemHandler is an HTTP handler that accepts a POST request with a JSON RedeemParams body, visits the magic link,
+// then responds with a JSON Redemption instead of redirecting. This allows single-page and native apps to capture the
+// magic link secret in their own route and redeem it themselves. Robot prevention is not applied, because automated
+// link scanners do not send JSON POST requests.
+func (m MagicLink) RedeemHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if r.Method != http.MethodPost {
+			m.handleError(fmt.Errorf("%w: method must be POST", ErrRedeemRequest), http.StatusMethodNotAllowed, r, w)
+			return
+		}
+
+		var params RedeemParams
+		err := json.NewDecoder(r.Body).Decode(&params)
+		if err != nil {
+			m.handleError(fmt.Errorf("%w: %s", ErrRedeemRequest, err), http.StatusBadRequest, r, w)
+			return
+		}
+		if params.Secret == "" {
+			m.handleError(ErrMagicLinkMissingSecret, http.StatusBadRequest, r, w)
+			return
+		}
+
+		jwtB64, response, err := m.handleMagicLink(ctx, secretFromCode(params.Secret), r)
+		if err != nil {
+			if errors.Is(err, ErrLinkNotFound) {
+				m.handleError(err, http.StatusNotFound, r, w)
+				return
+			}
+			if errors.Is(err, ErrClientBindingMismatch) {
+				m.handleError(err, http.StatusForbidden, r, w)
+				return
+			}
+			m.handleError(err, http.StatusInternalServerError, r, w)
+			return
+		}
+		writeRedemptionJSON(w, jwtB64, response)
+	})
+}
+
+func acceptsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get(mld.HeaderAccept), mld.ContentTypeJSON)
+}
+
+func writeRedemptionJSON(w http.ResponseWriter, jwtB64 string, response ReadResult) {
+	redemption := Redemption{
+		Code:        response.Code,
+		CrossDevice: response.CreateParams.CrossDevice,
+		RedirectURL: response.CreateParams.RedirectURL.String(),
+		State:       response.CreateParams.State,
+	}
+	if !redemption.CrossDevice && jwtB64 != "" {
+		redemption.JWT = jwtB64
+		redemption.Claims = jwtClaimsJSON(jwtB64)
+	}
+	w.Header().Set(mld.HeaderContentType, mld.ContentTypeJSON)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(redemption)
+}
+
+// jwtClaimsJSON returns the claims segment of the signed JWT, so the claims match the JWT exactly.
+func jwtClaimsJSON(jwtB64 string) json.RawMessage {
+	parts := strings.Split(jwtB64, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !json.Valid(claims) {
+		return nil
+	}
+	return claims
+}
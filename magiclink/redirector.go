@@ -7,6 +7,9 @@ import (
 
 // RedirectorParams are passed to a Redirector when performing a redirect.
 type RedirectorParams struct {
+	// HandleError writes the response for an error returned by ReadAndExpireLink the same way as the MagicLinkHandler,
+	// using the Config's ErrorHandler.
+	HandleError       func(err error)
 	ReadAndExpireLink func(ctx context.Context, secret string) (jwtB64 string, response ReadResult, err error)
 	Request           *http.Request
 	Secret            string
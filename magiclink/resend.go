@@ -0,0 +1,46 @@
+package magiclink
+
+import (
+	"html/template"
+	"net/http"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+var resendPageMessages = map[int]errorPageMessage{
+	http.StatusOK: {
+		code:        "SENT",
+		instruction: "A new magic link was sent. Please check your email.",
+		title:       "New magic link sent",
+	},
+	http.StatusNotFound: {
+		code:        "UNAVAILABLE",
+		instruction: "A new magic link cannot be sent for this magic link. Please request a new magic link.",
+		title:       "Cannot send a new magic link",
+	},
+	http.StatusTooManyRequests: {
+		code:        "TOO MANY REQUESTS",
+		instruction: "A new magic link was sent recently. Please check your email or try again later.",
+		title:       "Please wait",
+	},
+}
+
+// WriteResendPage writes an HTML page describing the result of resending the email for an expired magic link. The
+// message on the page is chosen by the given HTTP status code.
+func WriteResendPage(w http.ResponseWriter, code int) {
+	message, ok := resendPageMessages[code]
+	if !ok {
+		message = errorPageMessages[ErrorPageKindError]
+	}
+	data := crossDeviceTemplateData{
+		CSS:         template.CSS(defaultCSS),
+		Code:        message.code,
+		HTMLTitle:   "Magic Link - " + message.title,
+		Instruction: message.instruction,
+		Title:       message.title,
+	}
+	w.Header().Set(mld.HeaderContentType, mld.ContentTypeHTML)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = crossDeviceTmpl.Execute(w, data)
+}
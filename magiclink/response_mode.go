@@ -0,0 +1,79 @@
+package magiclink
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+
+	mld "github.com/MicahParks/magiclinksdev"
+)
+
+const (
+	// ResponseModeQuery delivers the JWT in a URL query parameter of the redirect URL. This is the default.
+	ResponseModeQuery ResponseMode = "query"
+	// ResponseModeFragment delivers the JWT in the URL fragment of the redirect URL. The fragment is not sent to
+	// servers, so the JWT does not appear in access logs, proxies, or Referer headers.
+	ResponseModeFragment ResponseMode = "fragment"
+	// ResponseModeFormPost delivers the JWT in the body of an HTTP POST to the redirect URL using an automatically
+	// submitted HTML form.
+	ResponseModeFormPost ResponseMode = "form_post"
+)
+
+// ResponseMode is a set of string constants that indicate how the JWT is delivered to the redirect URL.
+type ResponseMode string
+
+// Valid confirms the ResponseMode is known. The empty string is valid and means ResponseModeQuery.
+func (r ResponseMode) Valid() error {
+	switch r {
+	case "", ResponseModeQuery, ResponseModeFragment, ResponseModeFormPost:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown response mode %q", mld.ErrParams, r)
+}
+
+var formPostTmpl = template.Must(template.New("").Parse(formPostTemplate))
+
+type formPostTemplateData struct {
+	Action     string
+	ButtonText string
+	CSS        template.CSS
+	Code       string
+	Fields     []formPostField
+	HTMLTitle  string
+	Title      string
+}
+
+type formPostField struct {
+	Key   string
+	Value string
+}
+
+func writeFormPost(w http.ResponseWriter, action *url.URL, values url.Values) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	fields := make([]formPostField, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range values[key] {
+			fields = append(fields, formPostField{Key: key, Value: value})
+		}
+	}
+	data := formPostTemplateData{
+		Action:     action.String(),
+		ButtonText: "Continue",
+		CSS:        template.CSS(defaultCSS),
+		Code:       "REDIRECTING",
+		Fields:     fields,
+		HTMLTitle:  "Magic Link - Redirecting",
+		Title:      "Signing you in...",
+	}
+	w.Header().Set(mld.HeaderContentType, mld.ContentTypeHTML)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	_ = formPostTmpl.Execute(w, data)
+}
@@ -13,50 +13,299 @@ import (
 
 // Storage represents the underlying storage for the MagicLink service.
 type Storage interface {
-	// MagicLinkCreate creates a secret for the given parameters and stores the pair. The secret is returned to the
-	// caller.
-	MagicLinkCreate(ctx context.Context, params CreateParams) (secret string, err error)
+	// MagicLinkCreate creates a secret for the given parameters and stores the pair. A non-secret identifier for the
+	// magic link, the secret, a cross-device token for magic links created with CrossDevice, and a QR code token for
+	// magic links created with QRCode are returned to the caller.
+	MagicLinkCreate(ctx context.Context, params CreateParams) (CreateResult, error)
+	// MagicLinkPeek finds the creation parameters for the given secret the same way as MagicLinkRead, but it does not
+	// count as a visit.
+	MagicLinkPeek(ctx context.Context, secret string) (ReadResult, error)
 	// MagicLinkRead finds the creation parameters for the given secret. ErrLinkNotFound is returned if the secret is
-	// not found or was deleted/expired. This will automatically expire the link.
+	// not found, was deleted/expired/revoked, or has no visits remaining. This counts as a visit and will automatically
+	// expire the link once its visits are exhausted.
 	MagicLinkRead(ctx context.Context, secret string) (ReadResult, error)
+	// MagicLinkCodeCreate creates a single-use code for the magic link with the given secret that can be taken with
+	// MagicLinkCodeTake until it expires. Any code previously created for the magic link is replaced. ErrLinkNotFound
+	// is returned if the secret is not found.
+	MagicLinkCodeCreate(ctx context.Context, secret string, expires time.Time) (code string, err error)
+	// MagicLinkCodeTake returns the secret of the magic link the given code was created for and removes the code so it
+	// can only be taken once. ErrLinkNotFound is returned if the code is not found or has expired.
+	MagicLinkCodeTake(ctx context.Context, code string) (secret string, err error)
+	// MagicLinkCrossDeviceCollect returns the state of the cross-device magic link with the given cross-device token.
+	// If a JWT is waiting to be collected, it is included in the result and removed from storage so that it is only
+	// returned once. ErrLinkNotFound is returned if the cross-device token is not found.
+	MagicLinkCrossDeviceCollect(ctx context.Context, token string) (CrossDeviceResult, error)
+	// MagicLinkCrossDeviceDeliver stores the JWT signed for a visit of the cross-device magic link with the given ID so
+	// it can be collected by the device that requested the magic link. ErrLinkNotFound is returned if the ID does not
+	// belong to a cross-device magic link.
+	MagicLinkCrossDeviceDeliver(ctx context.Context, id, jwtB64 string) error
+	// MagicLinkQRCodeRead returns the secret of the magic link with the given QR code token, so its QR code can be
+	// served. It does not count as a visit. ErrLinkNotFound is returned if the QR code token is not found or the magic
+	// link can no longer be visited.
+	MagicLinkQRCodeRead(ctx context.Context, token string) (secret string, err error)
+	// MagicLinkStatus returns the status of the magic link with the given ID without counting as a visit.
+	// ErrLinkNotFound is returned if the magic link is not found.
+	MagicLinkStatus(ctx context.Context, id string) (StatusResult, error)
+	// MagicLinkRevoke revokes the magic link identified by the given parameters so that it can no longer be visited.
+	// Revoking a magic link more than once is not an error. ErrLinkNotFound is returned if the magic link is not found.
+	MagicLinkRevoke(ctx context.Context, params RevokeParams) error
 }
 
 var _ Storage = &memoryMagicLink{}
 
+type memoryLink struct {
+	code           string
+	codeExpires    time.Time
+	collected      bool
+	created        time.Time
+	crossDeviceJWT string
+	result         ReadResult
+	revoked        *time.Time
+}
+
+// usable returns the reason the link can no longer be visited, if any.
+func (l memoryLink) usable(now time.Time) error {
+	switch {
+	case l.result.CreateParams.Expires.Before(now):
+		return ErrLinkExpired
+	case l.revoked != nil:
+		return ErrLinkRevoked
+	case l.result.CreateParams.VisitsExhausted(l.result.Visits):
+		return ErrLinkUsed
+	}
+	return nil
+}
+
+// linkNotFound returns ErrLinkMalformed if the secret could not have been created by the memory storage, otherwise
+// ErrLinkNotFound.
+func linkNotFound(secret string) error {
+	_, err := uuid.Parse(secret)
+	if err != nil {
+		return ErrLinkMalformed
+	}
+	return ErrLinkNotFound
+}
+
 type memoryMagicLink struct {
-	links map[string]ReadResult
-	mux   sync.Mutex
+	codes        map[string]string
+	crossDevices map[string]string
+	ids          map[string]string
+	links        map[string]memoryLink
+	mux          sync.Mutex
+	qrCodes      map[string]string
 }
 
 // NewMemoryStorage creates an in-memory implementation of the MagicLink Storage.
 func NewMemoryStorage() Storage {
 	return &memoryMagicLink{
-		links: map[string]ReadResult{},
+		codes:        map[string]string{},
+		crossDevices: map[string]string{},
+		ids:          map[string]string{},
+		links:        map[string]memoryLink{},
+		qrCodes:      map[string]string{},
 	}
 }
-func (m *memoryMagicLink) MagicLinkCreate(_ context.Context, args CreateParams) (secret string, err error) {
+func (m *memoryMagicLink) MagicLinkCreate(_ context.Context, args CreateParams) (CreateResult, error) {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 	u, err := uuid.NewRandom()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate UUID as secret: %w", err)
+		return CreateResult{}, fmt.Errorf("failed to generate UUID as secret: %w", err)
 	}
-	secret = u.String()
-	response := ReadResult{
-		CreateParams: args,
+	secret := u.String()
+	u, err = uuid.NewRandom()
+	if err != nil {
+		return CreateResult{}, fmt.Errorf("failed to generate UUID as ID: %w", err)
 	}
-	m.links[secret] = response
-	return secret, nil
+	result := CreateResult{
+		ID:     u.String(),
+		Secret: secret,
+	}
+	if args.CrossDevice {
+		u, err = uuid.NewRandom()
+		if err != nil {
+			return CreateResult{}, fmt.Errorf("failed to generate UUID as cross-device token: %w", err)
+		}
+		result.CrossDeviceToken = u.String()
+		m.crossDevices[result.CrossDeviceToken] = secret
+	}
+	if args.QRCode {
+		u, err = uuid.NewRandom()
+		if err != nil {
+			return CreateResult{}, fmt.Errorf("failed to generate UUID as QR code token: %w", err)
+		}
+		result.QRCodeToken = u.String()
+		m.qrCodes[result.QRCodeToken] = secret
+	}
+	link := memoryLink{
+		created: time.Now(),
+		result: ReadResult{
+			CreateParams: args,
+			ID:           result.ID,
+		},
+	}
+	m.ids[result.ID] = secret
+	m.links[secret] = link
+	return result, nil
+}
+func (m *memoryMagicLink) MagicLinkPeek(_ context.Context, secret string) (ReadResult, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	link, ok := m.links[secret]
+	if !ok {
+		return link.result, linkNotFound(secret)
+	}
+	err := link.usable(time.Now())
+	if err != nil {
+		return link.result, err
+	}
+	return link.result, nil
 }
 func (m *memoryMagicLink) MagicLinkRead(_ context.Context, secret string) (ReadResult, error) {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 	now := time.Now()
-	readResp, ok := m.links[secret]
-	if !ok || readResp.Visited != nil || readResp.CreateParams.Expires.Before(now) {
-		return readResp, ErrLinkNotFound
+	link, ok := m.links[secret]
+	if !ok {
+		return link.result, linkNotFound(secret)
 	}
-	readResp.Visited = mld.Ptr(now)
-	m.links[secret] = readResp
+	err := link.usable(now)
+	if err != nil {
+		return link.result, err
+	}
+	readResp := link.result
+	if readResp.Visited == nil {
+		readResp.Visited = mld.Ptr(now)
+	}
+	readResp.Visits++
+	link.result = readResp
+	m.links[secret] = link
 	return readResp, nil
 }
+func (m *memoryMagicLink) MagicLinkCodeCreate(_ context.Context, secret string, expires time.Time) (code string, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	link, ok := m.links[secret]
+	if !ok {
+		return "", linkNotFound(secret)
+	}
+	u, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate UUID as code: %w", err)
+	}
+	delete(m.codes, link.code)
+	link.code = u.String()
+	link.codeExpires = expires
+	m.codes[link.code] = secret
+	m.links[secret] = link
+	return link.code, nil
+}
+func (m *memoryMagicLink) MagicLinkCodeTake(_ context.Context, code string) (secret string, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	secret, ok := m.codes[code]
+	if !ok {
+		return "", ErrLinkNotFound
+	}
+	delete(m.codes, code)
+	link := m.links[secret]
+	expires := link.codeExpires
+	link.code = ""
+	link.codeExpires = time.Time{}
+	m.links[secret] = link
+	if expires.Before(time.Now()) {
+		return "", ErrLinkExpired
+	}
+	return secret, nil
+}
+func (m *memoryMagicLink) MagicLinkCrossDeviceCollect(_ context.Context, token string) (CrossDeviceResult, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	secret, ok := m.crossDevices[token]
+	if !ok {
+		return CrossDeviceResult{}, ErrLinkNotFound
+	}
+	link := m.links[secret]
+	var result CrossDeviceResult
+	switch {
+	case link.crossDeviceJWT != "":
+		result.JWT = link.crossDeviceJWT
+		result.Status = CrossDeviceStatusRedeemed
+		link.collected = true
+		link.crossDeviceJWT = ""
+		m.links[secret] = link
+	case link.revoked != nil || link.result.CreateParams.VisitsExhausted(link.result.Visits) || link.result.CreateParams.Expires.Before(time.Now()):
+		result.Status = CrossDeviceStatusExpired
+		if link.collected {
+			result.Status = CrossDeviceStatusCollected
+		}
+	default:
+		result.Status = CrossDeviceStatusPending
+	}
+	return result, nil
+}
+func (m *memoryMagicLink) MagicLinkCrossDeviceDeliver(_ context.Context, id, jwtB64 string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	secret := m.ids[id]
+	link, ok := m.links[secret]
+	if !ok || !link.result.CreateParams.CrossDevice {
+		return ErrLinkNotFound
+	}
+	link.crossDeviceJWT = jwtB64
+	m.links[secret] = link
+	return nil
+}
+func (m *memoryMagicLink) MagicLinkQRCodeRead(_ context.Context, token string) (secret string, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	secret, ok := m.qrCodes[token]
+	if !ok {
+		return "", ErrLinkNotFound
+	}
+	link := m.links[secret]
+	err = link.usable(time.Now())
+	if err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+func (m *memoryMagicLink) MagicLinkRevoke(_ context.Context, params RevokeParams) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	secret := params.Secret
+	if params.ID != "" {
+		secret = m.ids[params.ID]
+	}
+	link, ok := m.links[secret]
+	if !ok {
+		return ErrLinkNotFound
+	}
+	if link.revoked == nil {
+		link.revoked = mld.Ptr(time.Now())
+	}
+	m.links[secret] = link
+	return nil
+}
+func (m *memoryMagicLink) MagicLinkStatus(_ context.Context, id string) (StatusResult, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	link, ok := m.links[m.ids[id]]
+	if !ok {
+		return StatusResult{}, ErrLinkNotFound
+	}
+	maxVisits := link.result.CreateParams.MaxVisits
+	if maxVisits == 0 {
+		maxVisits = 1
+	}
+	result := StatusResult{
+		Created:   link.created,
+		Expires:   link.result.CreateParams.Expires,
+		ID:        id,
+		MaxVisits: maxVisits,
+		Revoked:   link.revoked,
+		Visited:   link.result.Visited,
+		Visits:    link.result.Visits,
+	}
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package magiclink
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/url"
+	"slices"
+	"time"
+
+	jt "github.com/MicahParks/jsontype"
+)
+
+// TurnstileVerifier verifies Cloudflare Turnstile tokens. Implementations other than the one returned by
+// NewTurnstileVerifier are useful for testing.
+type TurnstileVerifier interface {
+	Verify(ctx context.Context, response string, remoteIP string) (TurnstileResponse, error)
+}
+
+// TurnstileResponse is the response from the Cloudflare Turnstile verify endpoint.
+type TurnstileResponse struct {
+	Action      string    `json:"action"`
+	CData       string    `json:"cdata"`
+	ChallengeTS time.Time `json:"challenge_ts"`
+	ErrorCodes  []string  `json:"error-codes"`
+	Hostname    string    `json:"hostname"`
+	Success     bool      `json:"success"`
+}
+
+// TurnstileCheckOptions are the options for checking a TurnstileResponse.
+type TurnstileCheckOptions struct {
+	Action   []string
+	Hostname []string
+}
+
+// Check confirms the TurnstileResponse was successful and matches the given options.
+func (t TurnstileResponse) Check(options TurnstileCheckOptions) error {
+	err := checkCaptcha(t.Success, t.ErrorCodes, t.Hostname, options.Hostname)
+	if err != nil {
+		return err
+	}
+	if len(options.Action) != 0 && !slices.Contains(options.Action, t.Action) {
+		return fmt.Errorf("%w: action %q not in set", ErrCaptchaCheck, t.Action)
+	}
+	return nil
+}
+
+type turnstileVerifier struct {
+	captchaVerifier
+}
+
+// NewTurnstileVerifier creates a new TurnstileVerifier that calls the Cloudflare Turnstile verify endpoint.
+func NewTurnstileVerifier(secret string, options CaptchaVerifierOptions) TurnstileVerifier {
+	return turnstileVerifier{
+		captchaVerifier: newCaptchaVerifier(secret, "https://challenges.cloudflare.com/turnstile/v0/siteverify", options),
+	}
+}
+
+func (t turnstileVerifier) Verify(ctx context.Context, response string, remoteIP string) (TurnstileResponse, error) {
+	form := url.Values{
+		"response": {response},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	var r TurnstileResponse
+	err := t.verify(ctx, form, &r)
+	if err != nil {
+		return TurnstileResponse{}, fmt.Errorf("failed to verify Cloudflare Turnstile response: %w", err)
+	}
+	return r, nil
+}
+
+// TurnstileConfig is the configuration for Cloudflare Turnstile.
+type TurnstileConfig struct {
+	Action       []string              `json:"action"`
+	Hostname     []string              `json:"hostname"`
+	SecretKey    string                `json:"secretKey"`
+	TemplateData TurnstileTemplateData `json:"templateData"`
+
+	Verifier TurnstileVerifier `json:"-"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (t TurnstileConfig) DefaultsAndValidate() (TurnstileConfig, error) {
+	if t.SecretKey == "" {
+		return t, fmt.Errorf("%w: Cloudflare Turnstile secret key is required", jt.ErrDefaultsAndValidate)
+	}
+	var err error
+	t.TemplateData, err = t.TemplateData.DefaultsAndValidate()
+	if err != nil {
+		return t, fmt.Errorf("failed to validate Cloudflare Turnstile template data: %w", err)
+	}
+	return t, nil
+}
+
+// TurnstileRedirector is a Redirector that uses Cloudflare Turnstile to verify the user.
+type TurnstileRedirector struct {
+	checkOpts TurnstileCheckOptions
+	tmpl      *template.Template
+	tmplData  TurnstileTemplateData
+	verifier  TurnstileVerifier
+}
+
+// NewTurnstileRedirector creates a new TurnstileRedirector with the given config.
+func NewTurnstileRedirector(config TurnstileConfig) Redirector {
+	tmpl := template.Must(template.New("").Parse(turnstileTemplate))
+	verifier := config.Verifier
+	if verifier == nil {
+		verifier = NewTurnstileVerifier(config.SecretKey, CaptchaVerifierOptions{})
+	}
+	t := TurnstileRedirector{
+		checkOpts: TurnstileCheckOptions{
+			Action:   config.Action,
+			Hostname: config.Hostname,
+		},
+		tmpl:     tmpl,
+		tmplData: config.TemplateData,
+		verifier: verifier,
+	}
+	return t
+}
+
+func (t TurnstileRedirector) Redirect(args RedirectorParams) {
+	captchaRedirect(args, t.tmpl, t.tmplData, func(ctx context.Context, token string) error {
+		resp, err := t.verifier.Verify(ctx, token, "") // remoteIP left blank because reverse-proxies are a common use case.
+		if err != nil {
+			return err
+		}
+		return resp.Check(t.checkOpts)
+	})
+}
+
+// TurnstileTemplateData is the configuration for the HTML template for Cloudflare Turnstile.
+type TurnstileTemplateData struct {
+	CSS         template.CSS `json:"css"`
+	Code        string       `json:"code"`
+	HTMLTitle   string       `json:"htmlTitle"`
+	Instruction string       `json:"instruction"`
+	SiteKey     string       `json:"siteKey"`
+	Title       string       `json:"title"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (t TurnstileTemplateData) DefaultsAndValidate() (TurnstileTemplateData, error) {
+	if t.CSS == "" {
+		t.CSS = template.CSS(defaultCSS)
+	}
+	if t.Code == "" {
+		t.Code = "BROWSER CHECK"
+	}
+	if t.HTMLTitle == "" {
+		t.HTMLTitle = "Magic Link - Browser Check"
+	}
+	if t.Instruction == "" {
+		t.Instruction = "This page helps prevent robots from using magic links. Complete the check below to continue."
+	}
+	if t.SiteKey == "" {
+		return t, fmt.Errorf("%w: SiteKey is required", jt.ErrDefaultsAndValidate)
+	}
+	if t.Title == "" {
+		t.Title = "Checking your browser..."
+	}
+	return t, nil
+}
@@ -3,6 +3,7 @@ package magiclink
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
@@ -15,6 +16,24 @@ import (
 
 // CreateParams are the parameters for creating a magic link.
 type CreateParams struct {
+	// Binding restricts which clients can redeem the magic link. It is enforced by the MagicLink's MagicLinkHandler and
+	// Exchange according to the Config's BindingConfig. Use of this field is OPTIONAL.
+	Binding ClientBinding
+
+	// CodeChallenge is the S256 code challenge, as defined in RFC 7636, for the code verifier held by the client that
+	// requested the magic link. When the magic link is visited, no JWT is signed. Instead, a code is delivered to the
+	// RedirectURL and the client must exchange it along with the code verifier using the MagicLink's Exchange method.
+	// This prevents forwarded or intercepted magic links from being used by anyone else. Use CodeChallengeS256 to create
+	// the code challenge. This field cannot be used with CrossDevice. Use of this field is OPTIONAL.
+	CodeChallenge string
+
+	// CrossDevice indicates the JWT should be delivered to the device that requested the magic link instead of the
+	// device that visited it. When a cross-device magic link is visited, the JWT is kept in Storage until it is
+	// collected using the secret CrossDeviceToken returned when the magic link is created with the MagicLink's
+	// CrossDeviceHandler. The visiting device is shown a confirmation page instead of being redirected. Use of this
+	// field is OPTIONAL.
+	CrossDevice bool
+
 	// Expires is the time the magic link will expire. Use of this field is REQUIRED for all use cases.
 	Expires time.Time
 
@@ -36,12 +55,39 @@ type CreateParams struct {
 	// method will be chosen by default. Use of this field is OPTIONAL and NOT RECOMMENDED for most use cases.
 	JWTSigningMethod string
 
+	// MaxVisits is the maximum number of times the magic link can be visited before it is no longer valid. If this is
+	// zero, the magic link can only be visited once. Use VisitsUnlimited to allow the magic link to be visited any
+	// number of times until it expires. A new JWT is signed for each visit. For magic links that can be visited more
+	// than once, JWTClaims that implement RenewableClaims are renewed before each JWT is signed. Use of this field is
+	// OPTIONAL.
+	MaxVisits int
+
+	// QRCode allows a QR code image of the magic link to be served by the MagicLink's QRCodeHandler. The image is
+	// identified by the secret QRCodeToken returned when the magic link is created. Anyone with the token can get the
+	// magic link, so only give it to the device that shows the QR code. Use of this field is OPTIONAL.
+	QRCode bool
+
 	// RedirectQueryKey is the URL query key used in the redirect. It will contain the JWT. If this is empty, "jwt" will
-	// be used. Use of this field is OPTIONAL.
+	// be used. For ResponseModeFragment it is the key in the URL fragment and for ResponseModeFormPost it is the form
+	// field name. Use of this field is OPTIONAL.
 	RedirectQueryKey string
 
 	// RedirectURL is the URL to redirect to after the JWT is verified. Use of this field is REQUIRED for all use cases.
 	RedirectURL *url.URL
+
+	// ResponseMode determines how the JWT is delivered to the RedirectURL. If this is empty, ResponseModeQuery will be
+	// used. Use of this field is OPTIONAL.
+	ResponseMode ResponseMode
+
+	// State is an opaque value that is delivered to the RedirectURL alongside the JWT, in the same way as the JWT. It
+	// allows the application to restore context, such as a return-to path or CSRF state, after the redirect. It is
+	// stored with the magic link, so Storage implementations should protect it like JWTClaims. It is available to
+	// custom Redirector implementations through the ReadResult. Use of this field is OPTIONAL.
+	State string
+
+	// StateQueryKey is the URL query key used for the State in the redirect. If this is empty, "state" will be used.
+	// It must be different from the RedirectQueryKey. Use of this field is OPTIONAL.
+	StateQueryKey string
 }
 
 // Valid confirms the CreateParams are valid.
@@ -52,25 +98,164 @@ func (p CreateParams) Valid() error {
 	if p.RedirectURL == nil {
 		return fmt.Errorf("%w: RedirectURL is required", mld.ErrParams)
 	}
+	if p.MaxVisits < VisitsUnlimited {
+		return fmt.Errorf("%w: MaxVisits must be VisitsUnlimited, zero, or positive", mld.ErrParams)
+	}
+	err := p.ResponseMode.Valid()
+	if err != nil {
+		return fmt.Errorf("failed to validate ResponseMode: %w", err)
+	}
+	if p.ResponseMode == ResponseModeFormPost && !HTTPRedirect(p.RedirectURL) {
+		return fmt.Errorf("%w: ResponseModeFormPost requires an http or https RedirectURL", mld.ErrParams)
+	}
+	if p.State != "" && stateQueryKey(p) == deliveryQueryKey(p) {
+		return fmt.Errorf("%w: StateQueryKey must be different from the key used to deliver the JWT or code", mld.ErrParams)
+	}
+	err = p.Binding.Valid()
+	if err != nil {
+		return fmt.Errorf("failed to validate Binding: %w", err)
+	}
+	if p.CodeChallenge != "" {
+		if p.CrossDevice {
+			return fmt.Errorf("%w: CodeChallenge cannot be used with CrossDevice", mld.ErrParams)
+		}
+		err = validCodeChallenge(p.CodeChallenge)
+		if err != nil {
+			return fmt.Errorf("failed to validate CodeChallenge: %w", err)
+		}
+	}
 	return nil
 }
 
+// MultiVisit determines if the magic link can be visited more than once.
+func (p CreateParams) MultiVisit() bool {
+	return p.MaxVisits == VisitsUnlimited || p.MaxVisits > 1
+}
+
+// VisitsExhausted determines if a magic link that has already been visited the given number of times can no longer be
+// visited.
+func (p CreateParams) VisitsExhausted(visits int) bool {
+	if p.MaxVisits == VisitsUnlimited {
+		return false
+	}
+	return visits >= max(p.MaxVisits, 1)
+}
+
 // ReadResult is the result after a magic link has been read.
 type ReadResult struct {
-	// CreateParams are the parameters used to create the magic link.
+	// Code is the code to exchange for a JWT along with the code verifier. It is only populated for magic links created
+	// with a CodeChallenge, in which case no JWT is signed when the magic link is visited.
+	Code string
+	// CreateParams are the parameters used to create the magic link, including the State to deliver to the redirect URL.
 	CreateParams CreateParams
+	// ID is the non-secret identifier of the magic link.
+	ID string
 	// Visited is the first time the magic link was visited. This is nil if the magic link has not been visited.
 	Visited *time.Time
+	// Visits is the number of times the magic link has been visited, including the visit that produced this result.
+	Visits int
+}
+
+const (
+	// CrossDeviceStatusPending indicates the cross-device magic link has not been visited yet.
+	CrossDeviceStatusPending CrossDeviceStatus = "pending"
+	// CrossDeviceStatusRedeemed indicates the cross-device magic link was visited and the JWT is included.
+	CrossDeviceStatusRedeemed CrossDeviceStatus = "redeemed"
+	// CrossDeviceStatusCollected indicates the JWT for the cross-device magic link was already collected and the magic
+	// link can no longer be visited.
+	CrossDeviceStatusCollected CrossDeviceStatus = "collected"
+	// CrossDeviceStatusExpired indicates the cross-device magic link expired or was revoked before it was visited.
+	CrossDeviceStatusExpired CrossDeviceStatus = "expired"
+)
+
+// CrossDeviceStatus is a set of string constants that indicate the state of a cross-device magic link.
+type CrossDeviceStatus string
+
+// CrossDeviceResult is the result of collecting the JWT for a cross-device magic link.
+type CrossDeviceResult struct {
+	// JWT is the signed JWT for the most recent visit of the magic link. It is only populated when the Status is
+	// CrossDeviceStatusRedeemed and it is only returned once.
+	JWT string `json:"jwt,omitempty"`
+	// Status is the state of the cross-device magic link.
+	Status CrossDeviceStatus `json:"status"`
+}
+
+// RenewableClaims are JWT claims that can be renewed for each visit of a magic link that can be visited more than
+// once.
+type RenewableClaims interface {
+	jwt.Claims
+	// Renew returns a copy of the claims for a JWT signed at the given time.
+	Renew(now time.Time) (jwt.Claims, error)
 }
 
 // CreateResponse is the response after a magic link has been created.
 type CreateResponse struct {
-	MagicLink *url.URL
-	Secret    string
+	CrossDeviceToken string
+	ID               string
+	MagicLink        *url.URL
+	QRCodeToken      string
+	Secret           string
+}
+
+// CreateResult is the result after a magic link has been stored.
+type CreateResult struct {
+	// CrossDeviceToken is the secret identifier used to collect the JWT of a cross-device magic link. It is only
+	// populated for magic links created with CrossDevice.
+	CrossDeviceToken string
+	// ID is the non-secret identifier of the magic link.
+	ID string
+	// QRCodeToken is the secret identifier of the magic link's QR code image. It is only populated for magic links
+	// created with QRCode.
+	QRCodeToken string
+	// Secret is the secret embedded in the magic link.
+	Secret string
+}
+
+// StatusResult is the status of a magic link. Reading the status does not count as a visit.
+type StatusResult struct {
+	// Created is the time the magic link was created.
+	Created time.Time
+	// Expires is the time the magic link will expire.
+	Expires time.Time
+	// ID is the non-secret identifier of the magic link.
+	ID string
+	// MaxVisits is the maximum number of times the magic link can be visited. Unlike CreateParams.MaxVisits, this is
+	// never zero. It is VisitsUnlimited if the magic link can be visited any number of times until it expires.
+	MaxVisits int
+	// Revoked is the time the magic link was revoked. This is nil if the magic link has not been revoked.
+	Revoked *time.Time
+	// Visited is the first time the magic link was visited. This is nil if the magic link has not been visited.
+	Visited *time.Time
+	// Visits is the number of times the magic link has been visited.
+	Visits int
+}
+
+// RevokeParams identify a magic link to revoke. Exactly one of the fields is REQUIRED.
+type RevokeParams struct {
+	// ID is the non-secret identifier of the magic link returned when it was created.
+	ID string
+	// Secret is the secret embedded in the magic link.
+	Secret string
+}
+
+// Valid confirms the RevokeParams are valid.
+func (p RevokeParams) Valid() error {
+	if (p.ID == "") == (p.Secret == "") {
+		return fmt.Errorf("%w: exactly one of ID or Secret is required", mld.ErrParams)
+	}
+	return nil
 }
 
 // Errors that ErrorHandler needs to handle.
 var (
+	// ErrCrossDeviceCollect is a possible error for an ErrorHandler implementation to handle.
+	ErrCrossDeviceCollect = errors.New("failed to collect the cross-device JWT from storage")
+	// ErrCrossDeviceDeliver is a possible error for an ErrorHandler implementation to handle.
+	ErrCrossDeviceDeliver = errors.New("failed to deliver the cross-device JWT to storage")
+	// ErrCrossDeviceMissingToken is a possible error for an ErrorHandler implementation to handle.
+	ErrCrossDeviceMissingToken = errors.New("visited cross-device endpoint without a cross-device token")
+	// ErrCodeVerifierInvalid is a possible error for an ErrorHandler implementation to handle.
+	ErrCodeVerifierInvalid = errors.New("code verifier does not match the magic link's code challenge")
 	// ErrJWKSEmpty is a possible error for an ErrorHandler implementation to handle.
 	ErrJWKSEmpty = errors.New("JWK Set is empty")
 	// ErrJWKSJSON is a possible error for an ErrorHandler implementation to handle.
@@ -83,10 +268,24 @@ var (
 	ErrJWTSign = errors.New("failed to sign JWT")
 	// ErrLinkNotFound is a possible error for an ErrorHandler implementation to handle.
 	ErrLinkNotFound = errors.New("link not found")
+	// ErrLinkExpired is a possible error for an ErrorHandler implementation to handle. It wraps ErrLinkNotFound.
+	ErrLinkExpired = fmt.Errorf("%w: link expired", ErrLinkNotFound)
+	// ErrLinkMalformed is a possible error for an ErrorHandler implementation to handle. It wraps ErrLinkNotFound.
+	ErrLinkMalformed = fmt.Errorf("%w: link secret is malformed", ErrLinkNotFound)
+	// ErrLinkRevoked is a possible error for an ErrorHandler implementation to handle. It wraps ErrLinkNotFound.
+	ErrLinkRevoked = fmt.Errorf("%w: link revoked", ErrLinkNotFound)
+	// ErrLinkUsed is a possible error for an ErrorHandler implementation to handle. It wraps ErrLinkNotFound.
+	ErrLinkUsed = fmt.Errorf("%w: link already used", ErrLinkNotFound)
 	// ErrMagicLinkMissingSecret is a possible error for an ErrorHandler implementation to handle.
 	ErrMagicLinkMissingSecret = errors.New("visited magic link endpoint without a secret")
 	// ErrMagicLinkRead is a possible error for an ErrorHandler implementation to handle.
 	ErrMagicLinkRead = errors.New("failed to read the magic link from storage")
+	// ErrQRCodeEncode is a possible error for an ErrorHandler implementation to handle.
+	ErrQRCodeEncode = errors.New("failed to encode the magic link as a QR code")
+	// ErrQRCodeRequest is a possible error for an ErrorHandler implementation to handle.
+	ErrQRCodeRequest = errors.New("invalid QR code request")
+	// ErrRedeemRequest is a possible error for an ErrorHandler implementation to handle.
+	ErrRedeemRequest = errors.New("invalid magic link redemption request")
 )
 
 // ErrorHandlerParams are the parameters passed to an ErrorHandler when an error occurs.
@@ -114,11 +313,15 @@ func (f ErrorHandlerFunc) Handle(args ErrorHandlerParams) {
 
 // Config contains the required assets to create a MagicLink service.
 type Config struct {
+	Binding          BindingConfig
 	ErrorHandler     ErrorHandler
 	JWKS             JWKSParams
 	CustomRedirector Redirector
+	LinkFormat       LinkFormat
+	Logger           *slog.Logger
 	ServiceURL       *url.URL
 	SecretQueryKey   string
+	ShortCodes       bool
 	Store            Storage
 }
 
@@ -127,6 +330,10 @@ func (c Config) Valid() error {
 	if c.ServiceURL == nil {
 		return fmt.Errorf("%w: include a service URL, this is used to build magic links", mld.ErrParams)
 	}
+	err := c.LinkFormat.Valid()
+	if err != nil {
+		return fmt.Errorf("failed to validate LinkFormat: %w", err)
+	}
 	return nil
 }
 
@@ -12,6 +12,9 @@ type NopProvider struct{}
 func (n NopProvider) SendMagicLink(_ context.Context, _ email.Email) error {
 	return nil
 }
+func (n NopProvider) SendMagicLinkOTP(_ context.Context, _ email.Email) error {
+	return nil
+}
 func (n NopProvider) SendOTP(_ context.Context, _ email.Email) error {
 	return nil
 }
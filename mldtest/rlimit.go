@@ -7,6 +7,11 @@ import (
 // NopLimiter is a rate limiter that does nothing.
 type NopLimiter struct{}
 
+// Allow implements the rlimit.RateLimiter interface.
+func (n NopLimiter) Allow(_ string) bool {
+	return true
+}
+
 // Wait implements the rlimit.RateLimiter interface.
 func (n NopLimiter) Wait(_ context.Context, _ string) error {
 	return nil
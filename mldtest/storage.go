@@ -5,6 +5,7 @@ import (
 	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/MicahParks/jwkset"
 	"github.com/google/uuid"
@@ -83,15 +84,16 @@ func (t *testStorage) TestingTruncate(_ context.Context) error {
 func (t *testStorage) SAAdminCreate(_ context.Context, _ model.ValidAdminCreateParams) error {
 	return nil
 }
-func (t *testStorage) SACreate(_ context.Context, _ model.ValidServiceAccountCreateParams) (model.ServiceAccount, error) {
+func (t *testStorage) SACreate(_ context.Context, args model.ValidServiceAccountCreateParams) (model.ServiceAccount, error) {
 	u := uuid.New()
 	apiKey := uuid.New()
 	aud := uuid.New()
 	sa := model.ServiceAccount{
-		UUID:   u,
-		APIKey: apiKey,
-		Aud:    aud,
-		Admin:  false,
+		UUID:              u,
+		APIKey:            apiKey,
+		Aud:               aud,
+		Admin:             false,
+		RedirectAllowlist: args.RedirectAllowlist,
 	}
 	t.sa[u] = sa
 	return sa, nil
@@ -111,6 +113,27 @@ func (t *testStorage) SAReadFromAPIKey(_ context.Context, apiKey uuid.UUID) (mod
 	}
 	return model.ServiceAccount{}, fmt.Errorf("no service account found with API key %w", storage.ErrNotFound)
 }
+func (t *testStorage) SAErrorPageRead(_ context.Context, _ string) (magiclink.ErrorPageBranding, error) {
+	return magiclink.ErrorPageBranding{}, storage.ErrNotFound
+}
+func (t *testStorage) MagicLinkResendCreate(_ context.Context, _ string, _ model.MagicLinkEmailCreateRequest) error {
+	return nil
+}
+func (t *testStorage) MagicLinkResendRead(_ context.Context, _ string) (storage.MagicLinkResend, error) {
+	return storage.MagicLinkResend{}, storage.ErrNotFound
+}
+func (t *testStorage) MagicLinkResendTake(_ context.Context, _ string) (storage.MagicLinkResend, error) {
+	return storage.MagicLinkResend{}, storage.ErrNotFound
+}
+func (t *testStorage) MagicLinkOTPLink(_ context.Context, _, _ string) error {
+	return nil
+}
+func (t *testStorage) OTPResendCreate(_ context.Context, _ string, _ storage.OTPResend) error {
+	return nil
+}
+func (t *testStorage) OTPResendRead(_ context.Context, _ string) (storage.OTPResend, error) {
+	return storage.OTPResend{}, storage.ErrNotFound
+}
 func (t *testStorage) SigningKeyRead(_ context.Context, _ storage.ReadSigningKeyOptions) (meta jwkset.JWK, err error) {
 	return t.jwk, nil
 }
@@ -174,15 +197,58 @@ func (t *testStorage) MarshalWithOptions(ctx context.Context, marshalOptions jwk
 	}
 	return m.MarshalWithOptions(ctx, marshalOptions, validationOptions)
 }
-func (t *testStorage) MagicLinkCreate(_ context.Context, _ magiclink.CreateParams) (secret string, err error) {
-	return uuid.New().String(), nil
+func (t *testStorage) MagicLinkCreate(_ context.Context, params magiclink.CreateParams) (magiclink.CreateResult, error) {
+	result := magiclink.CreateResult{
+		ID:     uuid.New().String(),
+		Secret: uuid.New().String(),
+	}
+	if params.CrossDevice {
+		result.CrossDeviceToken = uuid.New().String()
+	}
+	if params.QRCode {
+		result.QRCodeToken = uuid.New().String()
+	}
+	return result, nil
+}
+func (t *testStorage) MagicLinkCrossDeviceCollect(_ context.Context, _ string) (magiclink.CrossDeviceResult, error) {
+	return magiclink.CrossDeviceResult{Status: magiclink.CrossDeviceStatusPending}, nil
+}
+func (t *testStorage) MagicLinkCrossDeviceDeliver(_ context.Context, _, _ string) error {
+	return nil
+}
+func (t *testStorage) MagicLinkPeek(_ context.Context, _ string) (magiclink.ReadResult, error) {
+	return magiclink.ReadResult{}, nil
 }
 func (t *testStorage) MagicLinkRead(_ context.Context, _ string) (magiclink.ReadResult, error) {
 	return magiclink.ReadResult{}, nil
 }
+func (t *testStorage) MagicLinkCodeCreate(_ context.Context, _ string, _ time.Time) (code string, err error) {
+	return uuid.New().String(), nil
+}
+func (t *testStorage) MagicLinkCodeTake(_ context.Context, _ string) (secret string, err error) {
+	return uuid.New().String(), nil
+}
+func (t *testStorage) MagicLinkQRCodeRead(_ context.Context, _ string) (secret string, err error) {
+	return uuid.New().String(), nil
+}
+func (t *testStorage) MagicLinkRevoke(_ context.Context, _ magiclink.RevokeParams) error {
+	return nil
+}
+func (t *testStorage) MagicLinkStatus(_ context.Context, id string) (magiclink.StatusResult, error) {
+	return magiclink.StatusResult{ID: id}, nil
+}
 func (t *testStorage) OTPCreate(_ context.Context, _ otp.CreateParams) (otp.CreateResult, error) {
 	return otp.CreateResult{}, nil
 }
-func (t *testStorage) OTPValidate(_ context.Context, _, _ string) error {
+func (t *testStorage) OTPValidate(_ context.Context, id, _ string) (otp.ValidateResult, error) {
+	return otp.ValidateResult{ID: id}, nil
+}
+func (t *testStorage) OTPRegenerate(_ context.Context, id string, params otp.CreateParams) (otp.CreateResult, error) {
+	return otp.CreateResult{CreateParams: params, ID: id}, nil
+}
+func (t *testStorage) TOTPCreate(_ context.Context, params otp.TOTPCreateParams) (otp.TOTPCreateResult, error) {
+	return otp.TOTPCreateResult{CreateParams: params}, nil
+}
+func (t *testStorage) TOTPValidate(_ context.Context, _ otp.TOTPValidateParams) error {
 	return nil
 }
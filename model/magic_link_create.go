@@ -8,11 +8,37 @@ import (
 	"github.com/MicahParks/magiclinksdev/magiclink"
 )
 
+type ClientBinding struct {
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+}
+
+func (c ClientBinding) Validate() (magiclink.ClientBinding, error) {
+	binding := magiclink.ClientBinding{
+		IP: c.IP,
+	}
+	if c.UserAgent != "" {
+		binding.UserAgentFingerprint = magiclink.UserAgentFingerprint(c.UserAgent)
+	}
+	if binding.Valid() != nil {
+		return magiclink.ClientBinding{}, fmt.Errorf("%w: binding IP must be an IP address or CIDR prefix", ErrInvalidModel)
+	}
+	return binding, nil
+}
+
 type MagicLinkCreateParams struct {
+	Binding          ClientBinding   `json:"binding"`
+	CodeChallenge    string          `json:"codeChallenge"`
+	CrossDevice      bool            `json:"crossDevice"`
 	JWTCreateParams  JWTCreateParams `json:"jwtCreateParams"`
 	LifespanSeconds  int             `json:"lifespanSeconds"`
+	MaxVisits        int             `json:"maxVisits"`
+	QRCode           bool            `json:"qrCode"`
 	RedirectQueryKey string          `json:"redirectQueryKey"`
 	RedirectURL      string          `json:"redirectURL"`
+	ResponseMode     string          `json:"responseMode"`
+	State            string          `json:"state"`
+	StateQueryKey    string          `json:"stateQueryKey"`
 }
 
 func (p MagicLinkCreateParams) Validate(config Validation) (ValidMagicLinkCreateParams, error) {
@@ -26,27 +52,75 @@ func (p MagicLinkCreateParams) Validate(config Validation) (ValidMagicLinkCreate
 	} else if lifespan < 5*time.Second || lifespan > config.LifeSpanSeconds.Get() {
 		return ValidMagicLinkCreateParams{}, fmt.Errorf("%w: link lifespan must be between 5 and %d", ErrInvalidModel, int(config.LifeSpanSeconds.Get().Seconds()))
 	}
+	if p.MaxVisits < magiclink.VisitsUnlimited {
+		return ValidMagicLinkCreateParams{}, fmt.Errorf("%w: max visits must be %d for unlimited visits, 0 for the default, or positive", ErrInvalidModel, magiclink.VisitsUnlimited)
+	}
 	if p.RedirectQueryKey == "" {
 		p.RedirectQueryKey = magiclink.DefaultRedirectQueryKey
 	}
-	u, err := httpURL(config, p.RedirectURL)
+	u, err := redirectURL(config, p.RedirectURL)
 	if err != nil {
 		return ValidMagicLinkCreateParams{}, fmt.Errorf("failed to validate URL: %w", err)
 	}
+	responseMode := magiclink.ResponseMode(p.ResponseMode)
+	if responseMode.Valid() != nil {
+		return ValidMagicLinkCreateParams{}, fmt.Errorf("%w: response mode must be one of %q, %q, or %q", ErrInvalidModel, magiclink.ResponseModeQuery, magiclink.ResponseModeFragment, magiclink.ResponseModeFormPost)
+	}
+	if responseMode == magiclink.ResponseModeFormPost && !magiclink.HTTPRedirect(u) {
+		return ValidMagicLinkCreateParams{}, fmt.Errorf("%w: response mode %q requires an http or https redirect URL", ErrInvalidModel, magiclink.ResponseModeFormPost)
+	}
+	binding, err := p.Binding.Validate()
+	if err != nil {
+		return ValidMagicLinkCreateParams{}, fmt.Errorf("failed to validate binding: %w", err)
+	}
+	if uint(len(p.State)) > config.StateMaxBytes {
+		return ValidMagicLinkCreateParams{}, fmt.Errorf("%w: state must be less than or equal to %d bytes", ErrInvalidModel, config.StateMaxBytes)
+	}
+	if p.StateQueryKey == "" {
+		p.StateQueryKey = magiclink.DefaultStateQueryKey
+	}
+	deliveryKey := p.RedirectQueryKey
+	if p.CodeChallenge != "" {
+		deliveryKey = magiclink.DefaultCodeQueryKey
+	}
+	if p.State != "" && p.StateQueryKey == deliveryKey {
+		return ValidMagicLinkCreateParams{}, fmt.Errorf("%w: state query key must be different from the key used to deliver the JWT or code", ErrInvalidModel)
+	}
+	if p.CodeChallenge != "" {
+		if p.CrossDevice {
+			return ValidMagicLinkCreateParams{}, fmt.Errorf("%w: code challenge cannot be used with cross-device magic links", ErrInvalidModel)
+		}
+	}
 	valid := ValidMagicLinkCreateParams{
+		Binding:          binding,
+		CodeChallenge:    p.CodeChallenge,
+		CrossDevice:      p.CrossDevice,
 		Lifespan:         lifespan,
 		JWTCreateParams:  validJWTCreateParams,
+		MaxVisits:        p.MaxVisits,
+		QRCode:           p.QRCode,
 		RedirectQueryKey: p.RedirectQueryKey,
 		RedirectURL:      u,
+		ResponseMode:     responseMode,
+		State:            p.State,
+		StateQueryKey:    p.StateQueryKey,
 	}
 	return valid, nil
 }
 
 type ValidMagicLinkCreateParams struct {
+	Binding          magiclink.ClientBinding
+	CodeChallenge    string
+	CrossDevice      bool
 	Lifespan         time.Duration
 	JWTCreateParams  ValidJWTCreateParams
+	MaxVisits        int
+	QRCode           bool
 	RedirectQueryKey string
 	RedirectURL      *url.URL
+	ResponseMode     magiclink.ResponseMode
+	State            string
+	StateQueryKey    string
 }
 
 type MagicLinkCreateRequest struct {
@@ -69,8 +143,13 @@ type ValidMagicLinkCreateRequest struct {
 }
 
 type MagicLinkCreateResults struct {
-	MagicLink string `json:"magicLink"`
-	Secret    string `json:"secret"`
+	CrossDeviceToken string `json:"crossDeviceToken,omitempty"`
+	ID               string `json:"id"`
+	MagicLink        string `json:"magicLink"`
+	QRCodePNG        []byte `json:"qrCodePNG,omitempty"`
+	QRCodeSVG        string `json:"qrCodeSVG,omitempty"`
+	QRCodeToken      string `json:"qrCodeToken,omitempty"`
+	Secret           string `json:"secret"`
 }
 
 type MagicLinkCreateResponse struct {
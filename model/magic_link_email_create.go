@@ -95,6 +95,7 @@ func (b MagicLinkEmailCreateRequest) Validate(config Validation) (ValidMagicLink
 	valid := ValidMagicLinkEmailCreateRequest{
 		MagicLinkCreateParams:      magicLinkCreateParams,
 		MagicLinkEmailCreateParams: magicLinkEmailCreateParams,
+		Resend:                     b,
 	}
 	return valid, nil
 }
@@ -102,6 +103,7 @@ func (b MagicLinkEmailCreateRequest) Validate(config Validation) (ValidMagicLink
 type ValidMagicLinkEmailCreateRequest struct {
 	MagicLinkCreateParams      ValidMagicLinkCreateParams
 	MagicLinkEmailCreateParams ValidMagicLinkEmailCreateParams
+	Resend                     MagicLinkEmailCreateRequest
 }
 
 type MagicLinkEmailCreateResults struct {
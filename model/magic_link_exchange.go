@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/magiclink"
+)
+
+type MagicLinkExchangeParams struct {
+	ClientIP     string `json:"clientIP"`
+	Code         string `json:"code"`
+	CodeVerifier string `json:"codeVerifier"`
+	UserAgent    string `json:"userAgent"`
+}
+
+func (p MagicLinkExchangeParams) Validate(_ Validation) (ValidMagicLinkExchangeParams, error) {
+	if p.ClientIP != "" {
+		_, err := netip.ParseAddr(p.ClientIP)
+		if err != nil {
+			return ValidMagicLinkExchangeParams{}, fmt.Errorf("%w: client IP must be an IP address", ErrInvalidModel)
+		}
+	}
+	_, err := uuid.Parse(p.Code)
+	if err != nil {
+		return ValidMagicLinkExchangeParams{}, fmt.Errorf("currently all magic link codes must be UUIDs: %w", ErrInvalidModel)
+	}
+	if len(p.CodeVerifier) < magiclink.CodeVerifierMinLength || len(p.CodeVerifier) > magiclink.CodeVerifierMaxLength {
+		return ValidMagicLinkExchangeParams{}, fmt.Errorf("%w: code verifier must be between %d and %d characters", ErrInvalidModel, magiclink.CodeVerifierMinLength, magiclink.CodeVerifierMaxLength)
+	}
+	valid := ValidMagicLinkExchangeParams(p)
+	return valid, nil
+}
+
+type ValidMagicLinkExchangeParams struct {
+	ClientIP     string
+	Code         string
+	CodeVerifier string
+	UserAgent    string
+}
+
+type MagicLinkExchangeRequest struct {
+	MagicLinkExchangeParams MagicLinkExchangeParams `json:"magicLinkExchangeParams"`
+}
+
+func (b MagicLinkExchangeRequest) Validate(config Validation) (ValidMagicLinkExchangeRequest, error) {
+	validParams, err := b.MagicLinkExchangeParams.Validate(config)
+	if err != nil {
+		return ValidMagicLinkExchangeRequest{}, fmt.Errorf("failed to validate magic link exchange args: %w", err)
+	}
+	valid := ValidMagicLinkExchangeRequest{
+		MagicLinkExchangeParams: validParams,
+	}
+	return valid, nil
+}
+
+type ValidMagicLinkExchangeRequest struct {
+	MagicLinkExchangeParams ValidMagicLinkExchangeParams
+}
+
+type MagicLinkExchangeResults struct {
+	JWT string `json:"jwt"`
+}
+
+type MagicLinkExchangeResponse struct {
+	MagicLinkExchangeResults MagicLinkExchangeResults `json:"magicLinkExchangeResults"`
+	RequestMetadata          RequestMetadata          `json:"requestMetadata"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+)
+
+type MagicLinkOTPEmailCreateRequest struct {
+	MagicLinkCreateParams      MagicLinkCreateParams      `json:"magicLinkCreateParams"`
+	MagicLinkEmailCreateParams MagicLinkEmailCreateParams `json:"magicLinkEmailCreateParams"`
+	OTPCreateParams            OTPCreateParams            `json:"otpCreateParams"`
+}
+
+func (b MagicLinkOTPEmailCreateRequest) Validate(config Validation) (ValidMagicLinkOTPEmailCreateRequest, error) {
+	magicLinkEmailCreateParams, err := b.MagicLinkEmailCreateParams.Validate(config)
+	if err != nil {
+		return ValidMagicLinkOTPEmailCreateRequest{}, fmt.Errorf("failed to validate email params: %w", err)
+	}
+	magicLinkCreateParams, err := b.MagicLinkCreateParams.Validate(config)
+	if err != nil {
+		return ValidMagicLinkOTPEmailCreateRequest{}, fmt.Errorf("failed to validate link params: %w", err)
+	}
+	if b.OTPCreateParams.JWTCreateParams == nil {
+		b.OTPCreateParams.JWTCreateParams = &b.MagicLinkCreateParams.JWTCreateParams // Either one gives the same JWT.
+	}
+	otpCreateParams, err := b.OTPCreateParams.Validate(config)
+	if err != nil {
+		return ValidMagicLinkOTPEmailCreateRequest{}, fmt.Errorf("failed to validate OTP params: %w", err)
+	}
+	otpCreateParams.Lifespan = magicLinkCreateParams.Lifespan // The email has one expiration for both.
+	valid := ValidMagicLinkOTPEmailCreateRequest{
+		MagicLinkCreateParams:      magicLinkCreateParams,
+		MagicLinkEmailCreateParams: magicLinkEmailCreateParams,
+		OTPCreateParams:            otpCreateParams,
+	}
+	return valid, nil
+}
+
+type ValidMagicLinkOTPEmailCreateRequest struct {
+	MagicLinkCreateParams      ValidMagicLinkCreateParams
+	MagicLinkEmailCreateParams ValidMagicLinkEmailCreateParams
+	OTPCreateParams            ValidOTPCreateParams
+}
+
+type MagicLinkOTPEmailCreateResults struct {
+	MagicLinkCreateResults MagicLinkCreateResults `json:"magicLinkCreateResults"`
+	OTPCreateResults       OTPCreateResults       `json:"otpCreateResults"`
+}
+
+type MagicLinkOTPEmailCreateResponse struct {
+	MagicLinkOTPEmailCreateResults MagicLinkOTPEmailCreateResults `json:"magicLinkOTPEmailCreateResults"`
+	RequestMetadata                RequestMetadata                `json:"requestMetadata"`
+}
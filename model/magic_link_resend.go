@@ -0,0 +1,5 @@
+package model
+
+type MagicLinkResendResponse struct {
+	RequestMetadata RequestMetadata `json:"requestMetadata"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type MagicLinkRevokeParams struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+func (p MagicLinkRevokeParams) Validate(_ Validation) (ValidMagicLinkRevokeParams, error) {
+	if (p.ID == "") == (p.Secret == "") {
+		return ValidMagicLinkRevokeParams{}, fmt.Errorf("%w: exactly one of magic link ID or secret is required", ErrInvalidModel)
+	}
+	if p.ID != "" {
+		_, err := uuid.Parse(p.ID)
+		if err != nil {
+			return ValidMagicLinkRevokeParams{}, fmt.Errorf("currently all magic link IDs must be UUIDs: %w", ErrInvalidModel)
+		}
+	}
+	valid := ValidMagicLinkRevokeParams(p)
+	return valid, nil
+}
+
+type ValidMagicLinkRevokeParams struct {
+	ID     string
+	Secret string
+}
+
+type MagicLinkRevokeRequest struct {
+	MagicLinkRevokeParams MagicLinkRevokeParams `json:"magicLinkRevokeParams"`
+}
+
+func (b MagicLinkRevokeRequest) Validate(config Validation) (ValidMagicLinkRevokeRequest, error) {
+	validParams, err := b.MagicLinkRevokeParams.Validate(config)
+	if err != nil {
+		return ValidMagicLinkRevokeRequest{}, fmt.Errorf("failed to validate magic link revoke args: %w", err)
+	}
+	valid := ValidMagicLinkRevokeRequest{
+		MagicLinkRevokeParams: validParams,
+	}
+	return valid, nil
+}
+
+type ValidMagicLinkRevokeRequest struct {
+	MagicLinkRevokeParams ValidMagicLinkRevokeParams
+}
+
+type MagicLinkRevokeResults struct{}
+
+type MagicLinkRevokeResponse struct {
+	MagicLinkRevokeResults MagicLinkRevokeResults `json:"magicLinkRevokeResults"`
+	RequestMetadata        RequestMetadata        `json:"requestMetadata"`
+}
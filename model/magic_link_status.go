@@ -0,0 +1,59 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MagicLinkStatusParams struct {
+	ID string `json:"id"`
+}
+
+func (p MagicLinkStatusParams) Validate(_ Validation) (ValidMagicLinkStatusParams, error) {
+	_, err := uuid.Parse(p.ID)
+	if err != nil {
+		return ValidMagicLinkStatusParams{}, fmt.Errorf("currently all magic link IDs must be UUIDs: %w", ErrInvalidModel)
+	}
+	valid := ValidMagicLinkStatusParams(p)
+	return valid, nil
+}
+
+type ValidMagicLinkStatusParams struct {
+	ID string
+}
+
+type MagicLinkStatusRequest struct {
+	MagicLinkStatusParams MagicLinkStatusParams `json:"magicLinkStatusParams"`
+}
+
+func (b MagicLinkStatusRequest) Validate(config Validation) (ValidMagicLinkStatusRequest, error) {
+	validParams, err := b.MagicLinkStatusParams.Validate(config)
+	if err != nil {
+		return ValidMagicLinkStatusRequest{}, fmt.Errorf("failed to validate magic link status args: %w", err)
+	}
+	valid := ValidMagicLinkStatusRequest{
+		MagicLinkStatusParams: validParams,
+	}
+	return valid, nil
+}
+
+type ValidMagicLinkStatusRequest struct {
+	MagicLinkStatusParams ValidMagicLinkStatusParams
+}
+
+type MagicLinkStatusResults struct {
+	Created   time.Time  `json:"created"`
+	Expires   time.Time  `json:"expires"`
+	ID        string     `json:"id"`
+	MaxVisits int        `json:"maxVisits"`
+	Revoked   *time.Time `json:"revoked"`
+	Visited   *time.Time `json:"visited"`
+	Visits    int        `json:"visits"`
+}
+
+type MagicLinkStatusResponse struct {
+	MagicLinkStatusResults MagicLinkStatusResults `json:"magicLinkStatusResults"`
+	RequestMetadata        RequestMetadata        `json:"requestMetadata"`
+}
@@ -1,29 +1,60 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"time"
+	"unicode/utf8"
 
 	mld "github.com/MicahParks/magiclinksdev"
+	"github.com/MicahParks/magiclinksdev/otp"
 )
 
 type OTPCreateParams struct {
-	CharSetAlphaLower bool `json:"charSetAlphaLower"`
-	CharSetAlphaUpper bool `json:"charSetAlphaUpper"`
-	CharSetNumeric    bool `json:"charSetNumeric"`
-	Length            uint `json:"length"`
-	LifespanSeconds   int  `json:"lifespanSeconds"`
+	Alphabet          string           `json:"alphabet,omitempty"`
+	CaseInsensitive   bool             `json:"caseInsensitive"`
+	CharSetAlphaLower bool             `json:"charSetAlphaLower"`
+	CharSetAlphaUpper bool             `json:"charSetAlphaUpper"`
+	CharSetNumeric    bool             `json:"charSetNumeric"`
+	ExcludeLookAlikes bool             `json:"excludeLookAlikes"`
+	GroupSize         uint             `json:"groupSize"`
+	JWTCreateParams   *JWTCreateParams `json:"jwtCreateParams,omitempty"`
+	Length            uint             `json:"length"`
+	LifespanSeconds   int              `json:"lifespanSeconds"`
 }
 
 func (o OTPCreateParams) Validate(config Validation) (ValidOTPCreateParams, error) {
-	if !o.CharSetAlphaLower && !o.CharSetAlphaUpper && !o.CharSetNumeric {
-		return ValidOTPCreateParams{}, fmt.Errorf("%w: at least one character set must be selected", ErrInvalidModel)
+	if o.Alphabet == "" && !o.CharSetAlphaLower && !o.CharSetAlphaUpper && !o.CharSetNumeric {
+		return ValidOTPCreateParams{}, fmt.Errorf("%w: at least one character set must be selected or an alphabet provided", ErrInvalidModel)
+	}
+	if utf8.RuneCountInString(o.Alphabet) > mld.MaxOTPAlphabetLength {
+		return ValidOTPCreateParams{}, fmt.Errorf("%w: alphabet must be at most %d characters", ErrInvalidModel, mld.MaxOTPAlphabetLength)
 	}
 	length := o.Length
 	if length == 0 {
 		length = mld.DefaultOTPLength
-	} else if length < 1 || length > 12 { // Limited by email template.
-		return ValidOTPCreateParams{}, fmt.Errorf("%w: link length must be between 1 and 12", ErrInvalidModel)
+	} else if length < 1 || length > mld.MaxOTPLength {
+		return ValidOTPCreateParams{}, fmt.Errorf("%w: OTP length must be between 1 and %d", ErrInvalidModel, mld.MaxOTPLength)
+	}
+	if o.GroupSize >= length {
+		return ValidOTPCreateParams{}, fmt.Errorf("%w: OTP group size must be less than the OTP length", ErrInvalidModel)
+	}
+	charSet, err := otp.CharSet(otp.CreateParams{
+		Alphabet:          o.Alphabet,
+		CaseInsensitive:   o.CaseInsensitive,
+		CharSetAlphaLower: o.CharSetAlphaLower,
+		CharSetAlphaUpper: o.CharSetAlphaUpper,
+		CharSetNumeric:    o.CharSetNumeric,
+		ExcludeLookAlikes: o.ExcludeLookAlikes,
+	})
+	if err != nil {
+		if errors.Is(err, mld.ErrParams) {
+			return ValidOTPCreateParams{}, fmt.Errorf("%w: %w", ErrInvalidModel, err)
+		}
+		return ValidOTPCreateParams{}, fmt.Errorf("failed to build OTP character set: %w", err)
+	}
+	if len(charSet) < 2 {
+		return ValidOTPCreateParams{}, fmt.Errorf("%w: OTP character set must have at least 2 distinct characters", ErrInvalidModel)
 	}
 	lifespan := time.Duration(o.LifespanSeconds) * time.Second
 	if lifespan == 0 {
@@ -31,10 +62,23 @@ func (o OTPCreateParams) Validate(config Validation) (ValidOTPCreateParams, erro
 	} else if lifespan < 5*time.Second || lifespan > config.LifeSpanSeconds.Get() {
 		return ValidOTPCreateParams{}, fmt.Errorf("%w: link lifespan must be between 5 and %d", ErrInvalidModel, int(config.LifeSpanSeconds.Get().Seconds()))
 	}
+	var jwtCreateParams *ValidJWTCreateParams
+	if o.JWTCreateParams != nil {
+		validJWTCreateParams, err := o.JWTCreateParams.Validate(config)
+		if err != nil {
+			return ValidOTPCreateParams{}, fmt.Errorf("failed to validate JWT create params: %w", err)
+		}
+		jwtCreateParams = &validJWTCreateParams
+	}
 	valid := ValidOTPCreateParams{
+		Alphabet:          o.Alphabet,
+		CaseInsensitive:   o.CaseInsensitive,
 		CharSetAlphaLower: o.CharSetAlphaLower,
 		CharSetAlphaUpper: o.CharSetAlphaUpper,
 		CharSetNumeric:    o.CharSetNumeric,
+		ExcludeLookAlikes: o.ExcludeLookAlikes,
+		GroupSize:         o.GroupSize,
+		JWTCreateParams:   jwtCreateParams,
 		Length:            length,
 		Lifespan:          lifespan,
 	}
@@ -42,9 +86,14 @@ func (o OTPCreateParams) Validate(config Validation) (ValidOTPCreateParams, erro
 }
 
 type ValidOTPCreateParams struct {
+	Alphabet          string
+	CaseInsensitive   bool
 	CharSetAlphaLower bool
 	CharSetAlphaUpper bool
 	CharSetNumeric    bool
+	ExcludeLookAlikes bool
+	GroupSize         uint
+	JWTCreateParams   *ValidJWTCreateParams
 	Length            uint
 	Lifespan          time.Duration
 }
@@ -60,12 +109,14 @@ func (o OTPCreateRequest) Validate(config Validation) (ValidOTPCreateRequest, er
 	}
 	valid := ValidOTPCreateRequest{
 		OTPCreateParams: validOTPCreateParams,
+		Resend:          o,
 	}
 	return valid, nil
 }
 
 type ValidOTPCreateRequest struct {
 	OTPCreateParams ValidOTPCreateParams
+	Resend          OTPCreateRequest
 }
 
 type OTPCreateResults struct {
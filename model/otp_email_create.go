@@ -89,6 +89,7 @@ func (b OTPEmailCreateRequest) Validate(config Validation) (ValidOTPEmailCreateR
 	valid := ValidOTPEmailCreateRequest{
 		OTPCreateParams:      otpCreateParams,
 		OTPEmailCreateParams: otpEmailCreateParams,
+		Resend:               b,
 	}
 	return valid, nil
 }
@@ -96,6 +97,7 @@ func (b OTPEmailCreateRequest) Validate(config Validation) (ValidOTPEmailCreateR
 type ValidOTPEmailCreateRequest struct {
 	OTPCreateParams      ValidOTPCreateParams
 	OTPEmailCreateParams ValidOTPEmailCreateParams
+	Resend               OTPEmailCreateRequest
 }
 
 type OTPEmailCreateResults struct {
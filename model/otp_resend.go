@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type OTPResendParams struct {
+	ID string `json:"id"`
+}
+
+func (o OTPResendParams) Validate(_ Validation) (ValidOTPResendParams, error) {
+	_, err := uuid.Parse(o.ID)
+	if err != nil {
+		return ValidOTPResendParams{}, fmt.Errorf("currently all OTP IDs must be UUIDs: %w", ErrInvalidModel)
+	}
+	valid := ValidOTPResendParams(o)
+	return valid, nil
+}
+
+type ValidOTPResendParams struct {
+	ID string
+}
+
+type OTPResendRequest struct {
+	OTPResendParams OTPResendParams `json:"otpResendParams"`
+}
+
+func (o OTPResendRequest) Validate(config Validation) (ValidOTPResendRequest, error) {
+	validParams, err := o.OTPResendParams.Validate(config)
+	if err != nil {
+		return ValidOTPResendRequest{}, fmt.Errorf("failed to validate OTP resend args: %w", err)
+	}
+	valid := ValidOTPResendRequest{
+		OTPResendParams: validParams,
+	}
+	return valid, nil
+}
+
+type ValidOTPResendRequest struct {
+	OTPResendParams ValidOTPResendParams
+}
+
+type OTPResendResults struct {
+	Emailed          bool   `json:"emailed"`
+	ID               string `json:"id"`
+	OTP              string `json:"otp"`
+	ResendsRemaining uint   `json:"resendsRemaining"`
+}
+
+type OTPResendResponse struct {
+	OTPResendResults OTPResendResults `json:"otpResendResults"`
+	RequestMetadata  RequestMetadata  `json:"requestMetadata"`
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/otp"
 )
 
 type OTPValidateParams struct {
@@ -16,6 +18,7 @@ func (o OTPValidateParams) Validate(_ Validation) (ValidOTPValidateParams, error
 	if err != nil {
 		return ValidOTPValidateParams{}, fmt.Errorf("currently all OTP IDs must be UUIDs: %w", ErrInvalidModel)
 	}
+	o.OTP = otp.Normalize(o.OTP)
 	if len(o.OTP) == 0 {
 		return ValidOTPValidateParams{}, fmt.Errorf("%w: OTP cannot be empty", ErrInvalidModel)
 	}
@@ -47,7 +50,9 @@ type ValidOTPValidateRequest struct {
 	OTPValidateParams ValidOTPValidateParams
 }
 
-type OTPValidateResults struct{}
+type OTPValidateResults struct {
+	JWT string `json:"jwt,omitempty"`
+}
 
 type OTPValidateResponse struct {
 	OTPValidateResults OTPValidateResults `json:"otpValidateResults"`
@@ -2,15 +2,68 @@ package model
 
 import (
 	"fmt"
+
+	"github.com/MicahParks/magiclinksdev/magiclink"
 )
 
-type ServiceAccountCreateParams struct{}
+type ErrorPageParams struct {
+	LogoURL           string `json:"logoURL"`
+	RequestNewLinkURL string `json:"requestNewLinkURL"`
+	ServiceName       string `json:"serviceName"`
+	Text              string `json:"text"`
+}
+
+func (e ErrorPageParams) Validate(config Validation) (magiclink.ErrorPageBranding, error) {
+	if e.LogoURL != "" {
+		_, err := httpURL(config, e.LogoURL)
+		if err != nil {
+			return magiclink.ErrorPageBranding{}, fmt.Errorf("failed to validate logo URL: %w", err)
+		}
+	}
+	if e.RequestNewLinkURL != "" {
+		_, err := httpURL(config, e.RequestNewLinkURL)
+		if err != nil {
+			return magiclink.ErrorPageBranding{}, fmt.Errorf("failed to validate request new link URL: %w", err)
+		}
+	}
+	branding := magiclink.ErrorPageBranding{
+		LogoURL:           e.LogoURL,
+		RequestNewLinkURL: e.RequestNewLinkURL,
+		ServiceName:       e.ServiceName,
+		Text:              e.Text,
+	}
+	return branding, nil
+}
 
-func (s ServiceAccountCreateParams) Validate(_ Validation) (ValidServiceAccountCreateParams, error) {
-	return ValidServiceAccountCreateParams(s), nil
+type ServiceAccountCreateParams struct {
+	ErrorPage         ErrorPageParams          `json:"errorPage"`
+	RedirectAllowlist []RedirectAllowlistEntry `json:"redirectAllowlist"`
 }
 
-type ValidServiceAccountCreateParams struct{}
+func (s ServiceAccountCreateParams) Validate(config Validation) (ValidServiceAccountCreateParams, error) {
+	errorPage, err := s.ErrorPage.Validate(config)
+	if err != nil {
+		return ValidServiceAccountCreateParams{}, fmt.Errorf("failed to validate error page: %w", err)
+	}
+	redirectAllowlist := make([]RedirectAllowlistEntry, 0, len(s.RedirectAllowlist))
+	for _, entry := range s.RedirectAllowlist {
+		entry, err = entry.Validate()
+		if err != nil {
+			return ValidServiceAccountCreateParams{}, fmt.Errorf("failed to validate redirect allowlist: %w", err)
+		}
+		redirectAllowlist = append(redirectAllowlist, entry)
+	}
+	valid := ValidServiceAccountCreateParams{
+		ErrorPage:         errorPage,
+		RedirectAllowlist: redirectAllowlist,
+	}
+	return valid, nil
+}
+
+type ValidServiceAccountCreateParams struct {
+	ErrorPage         magiclink.ErrorPageBranding
+	RedirectAllowlist []RedirectAllowlistEntry
+}
 
 type ServiceAccountCreateRequest struct {
 	ServiceAccountCreateParams ServiceAccountCreateParams `json:"serviceAccountCreateParams"`
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type TOTPCreateParams struct {
+	AccountName   string `json:"accountName"`
+	Issuer        string `json:"issuer"`
+	RecoveryCodes uint   `json:"recoveryCodes"`
+}
+
+func (p TOTPCreateParams) Validate(_ Validation) (ValidTOTPCreateParams, error) {
+	runeCount := utf8.RuneCountInString(p.AccountName)
+	if runeCount < 1 || runeCount > 256 {
+		return ValidTOTPCreateParams{}, fmt.Errorf("%w: account name must be between 1 and 256 UTF8 runes", ErrInvalidModel)
+	}
+	runeCount = utf8.RuneCountInString(p.Issuer)
+	if runeCount < 1 || runeCount > 256 {
+		return ValidTOTPCreateParams{}, fmt.Errorf("%w: issuer must be between 1 and 256 UTF8 runes", ErrInvalidModel)
+	}
+	if strings.Contains(p.AccountName, ":") || strings.Contains(p.Issuer, ":") {
+		return ValidTOTPCreateParams{}, fmt.Errorf("%w: account name and issuer cannot contain a colon", ErrInvalidModel)
+	}
+	recoveryCodes := p.RecoveryCodes
+	if recoveryCodes == 0 {
+		recoveryCodes = 10
+	} else if recoveryCodes > 20 {
+		return ValidTOTPCreateParams{}, fmt.Errorf("%w: recovery codes must be between 1 and 20", ErrInvalidModel)
+	}
+	valid := ValidTOTPCreateParams{
+		AccountName:   p.AccountName,
+		Issuer:        p.Issuer,
+		RecoveryCodes: recoveryCodes,
+	}
+	return valid, nil
+}
+
+type ValidTOTPCreateParams struct {
+	AccountName   string
+	Issuer        string
+	RecoveryCodes uint
+}
+
+type TOTPCreateRequest struct {
+	TOTPCreateParams TOTPCreateParams `json:"totpCreateParams"`
+}
+
+func (t TOTPCreateRequest) Validate(config Validation) (ValidTOTPCreateRequest, error) {
+	validTOTPCreateParams, err := t.TOTPCreateParams.Validate(config)
+	if err != nil {
+		return ValidTOTPCreateRequest{}, fmt.Errorf("failed to validate TOTP create args: %w", err)
+	}
+	valid := ValidTOTPCreateRequest{
+		TOTPCreateParams: validTOTPCreateParams,
+	}
+	return valid, nil
+}
+
+type ValidTOTPCreateRequest struct {
+	TOTPCreateParams ValidTOTPCreateParams
+}
+
+type TOTPCreateResults struct {
+	ID            string   `json:"id"`
+	QRCodePNG     []byte   `json:"qrCodePNG"`
+	QRCodeSVG     string   `json:"qrCodeSVG"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+}
+
+type TOTPCreateResponse struct {
+	TOTPCreateResults TOTPCreateResults `json:"totpCreateResults"`
+	RequestMetadata   RequestMetadata   `json:"requestMetadata"`
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/otp"
+)
+
+type TOTPValidateParams struct {
+	Code         string `json:"code"`
+	ID           string `json:"id"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+func (t TOTPValidateParams) Validate(_ Validation) (ValidTOTPValidateParams, error) {
+	_, err := uuid.Parse(t.ID)
+	if err != nil {
+		return ValidTOTPValidateParams{}, fmt.Errorf("currently all TOTP IDs must be UUIDs: %w", ErrInvalidModel)
+	}
+	if (t.Code == "") == (t.RecoveryCode == "") {
+		return ValidTOTPValidateParams{}, fmt.Errorf("%w: exactly one of code and recovery code must be provided", ErrInvalidModel)
+	}
+	if t.Code != "" && len(t.Code) != otp.TOTPDigits {
+		return ValidTOTPValidateParams{}, fmt.Errorf("%w: code must be %d digits", ErrInvalidModel, otp.TOTPDigits)
+	}
+	if t.RecoveryCode != "" && len(t.RecoveryCode) != otp.TOTPRecoveryCodeLength {
+		return ValidTOTPValidateParams{}, fmt.Errorf("%w: recovery code must be %d characters", ErrInvalidModel, otp.TOTPRecoveryCodeLength)
+	}
+	valid := ValidTOTPValidateParams(t)
+	return valid, nil
+}
+
+type ValidTOTPValidateParams struct {
+	Code         string
+	ID           string
+	RecoveryCode string
+}
+
+type TOTPValidateRequest struct {
+	TOTPValidateParams TOTPValidateParams `json:"totpValidateParams"`
+}
+
+func (t TOTPValidateRequest) Validate(config Validation) (ValidTOTPValidateRequest, error) {
+	validParams, err := t.TOTPValidateParams.Validate(config)
+	if err != nil {
+		return ValidTOTPValidateRequest{}, fmt.Errorf("failed to validate TOTP validate args: %w", err)
+	}
+	valid := ValidTOTPValidateRequest{
+		TOTPValidateParams: validParams,
+	}
+	return valid, nil
+}
+
+type ValidTOTPValidateRequest struct {
+	TOTPValidateParams ValidTOTPValidateParams
+}
+
+type TOTPValidateResults struct{}
+
+type TOTPValidateResponse struct {
+	TOTPValidateResults TOTPValidateResults `json:"totpValidateResults"`
+	RequestMetadata     RequestMetadata     `json:"requestMetadata"`
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
 	"time"
 	"unicode/utf8"
 
@@ -18,6 +20,8 @@ import (
 // ErrInvalidModel is returned when a model is invalid.
 var ErrInvalidModel = errors.New("invalid model")
 
+var redirectSchemeRegex = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+
 // Error is the model for an error.
 type Error struct {
 	Code            int             `json:"code"`
@@ -41,12 +45,36 @@ type RequestMetadata struct {
 	UUID uuid.UUID `json:"uuid"`
 }
 
+// RedirectAllowlistEntry allows magic links to redirect to a URL with a scheme other than http or https, such as a
+// mobile app's deep link. If Host is empty, any host is allowed for the scheme.
+type RedirectAllowlistEntry struct {
+	Scheme string `json:"scheme"`
+	Host   string `json:"host,omitempty"`
+}
+
+// Validate validates the RedirectAllowlistEntry and normalizes its case.
+func (r RedirectAllowlistEntry) Validate() (RedirectAllowlistEntry, error) {
+	r.Scheme = strings.ToLower(r.Scheme)
+	r.Host = strings.ToLower(r.Host)
+	if !redirectSchemeRegex.MatchString(r.Scheme) {
+		return RedirectAllowlistEntry{}, fmt.Errorf("%w: redirect allowlist scheme %q is not a valid URL scheme", ErrInvalidModel, r.Scheme)
+	}
+	switch r.Scheme {
+	case "http", "https":
+		return RedirectAllowlistEntry{}, fmt.Errorf("%w: redirect allowlist scheme must not be http or https, they are always allowed", ErrInvalidModel)
+	case "data", "file", "javascript", "vbscript":
+		return RedirectAllowlistEntry{}, fmt.Errorf("%w: redirect allowlist scheme %q is not allowed", ErrInvalidModel, r.Scheme)
+	}
+	return r, nil
+}
+
 // ServiceAccount is the model for a service account and its metadata.
 type ServiceAccount struct {
-	UUID   uuid.UUID `json:"uuid"`
-	APIKey uuid.UUID `json:"apiKey"`
-	Aud    uuid.UUID `json:"aud"`
-	Admin  bool      `json:"admin"`
+	UUID              uuid.UUID                `json:"uuid"`
+	APIKey            uuid.UUID                `json:"apiKey"`
+	Aud               uuid.UUID                `json:"aud"`
+	Admin             bool                     `json:"admin"`
+	RedirectAllowlist []RedirectAllowlistEntry `json:"redirectAllowlist"`
 }
 
 // Validation contains information on how to validate models.
@@ -58,7 +86,11 @@ type Validation struct {
 	JWTLifespanMax      *jt.JSONType[time.Duration] `json:"maxJWTLifespan"`
 	ServiceNameMinUTF8  uint                        `json:"serviceNameMinUTF8"`
 	ServiceNameMaxUTF8  uint                        `json:"serviceNameMaxUTF8"`
+	StateMaxBytes       uint                        `json:"stateMaxBytes"`
 	URLMaxLength        uint                        `json:"urlMaxLength"`
+
+	// RedirectAllowlist is the redirect allowlist of the service account making the request. It is set per request.
+	RedirectAllowlist []RedirectAllowlistEntry `json:"-"`
 }
 
 func (v Validation) DefaultsAndValidate() (Validation, error) {
@@ -83,6 +115,9 @@ func (v Validation) DefaultsAndValidate() (Validation, error) {
 	if v.ServiceNameMaxUTF8 == 0 {
 		v.ServiceNameMaxUTF8 = 256
 	}
+	if v.StateMaxBytes == 0 {
+		v.StateMaxBytes = 1024
+	}
 	if v.URLMaxLength == 0 {
 		v.URLMaxLength = 2048
 	}
@@ -105,3 +140,29 @@ func httpURL(config Validation, raw string) (*url.URL, error) {
 	}
 	return u, nil
 }
+
+func redirectURL(config Validation, raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redirect URL: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return httpURL(config, raw)
+	}
+	allowed := false
+	for _, entry := range config.RedirectAllowlist {
+		if strings.EqualFold(u.Scheme, entry.Scheme) && (entry.Host == "" || strings.EqualFold(u.Host, entry.Host)) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: redirect URL scheme and host are not in the service account's redirect allowlist", ErrInvalidModel)
+	}
+	runeCount := uint(utf8.RuneCountInString(u.String()))
+	if runeCount > config.URLMaxLength {
+		return nil, fmt.Errorf("%w: redirect URL must be less than or equal to %d runes", ErrInvalidModel, config.URLMaxLength)
+	}
+	return u, nil
+}
@@ -8,9 +8,11 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	mld "github.com/MicahParks/magiclinksdev"
 	"github.com/MicahParks/magiclinksdev/handle"
+	"github.com/MicahParks/magiclinksdev/magiclink"
 	"github.com/MicahParks/magiclinksdev/model"
 	"github.com/MicahParks/magiclinksdev/network/middleware"
 	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
@@ -171,6 +173,10 @@ func HTTPMagicLinkCreate(s *handle.Server) http.Handler {
 				middleware.WriteErrorBody(ctx, http.StatusBadRequest, responseDontRegisteredClaims, w)
 				return
 			}
+			if errors.Is(err, mld.ErrParams) {
+				middleware.WriteErrorBody(ctx, http.StatusBadRequest, "Invalid magic link parameters.", w)
+				return
+			}
 			logger.ErrorContext(ctx, "Failed to commit transaction for create link.",
 				mld.LogErr, err,
 			)
@@ -209,6 +215,10 @@ func HTTPMagicLinkEmailCreate(s *handle.Server) http.Handler {
 				middleware.WriteErrorBody(ctx, http.StatusBadRequest, responseDontRegisteredClaims, w)
 				return
 			}
+			if errors.Is(err, mld.ErrParams) {
+				middleware.WriteErrorBody(ctx, http.StatusBadRequest, "Invalid magic link parameters.", w)
+				return
+			}
 			logger.ErrorContext(ctx, "Failed to create email link.",
 				mld.LogErr, err,
 			)
@@ -229,6 +239,234 @@ func HTTPMagicLinkEmailCreate(s *handle.Server) http.Handler {
 	})
 }
 
+// HTTPMagicLinkOTPEmailCreate creates an HTTP handler for the HandleMagicLinkOTPEmailCreate method.
+func HTTPMagicLinkOTPEmailCreate(s *handle.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
+		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
+
+		validated, done := unmarshalRequest[model.MagicLinkOTPEmailCreateRequest, model.ValidMagicLinkOTPEmailCreateRequest](r, s.Config.Validation, w)
+		if done {
+			return
+		}
+
+		response, err := s.HandleMagicLinkOTPEmailCreate(ctx, validated)
+		if err != nil {
+			if errors.Is(err, handle.ErrRegisteredClaimProvided) {
+				middleware.WriteErrorBody(ctx, http.StatusBadRequest, responseDontRegisteredClaims, w)
+				return
+			}
+			if errors.Is(err, mld.ErrParams) {
+				middleware.WriteErrorBody(ctx, http.StatusBadRequest, "Invalid magic link parameters.", w)
+				return
+			}
+			logger.ErrorContext(ctx, "Failed to create magic link and OTP email.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to commit transaction for create magic link and OTP email.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		writeResponse(ctx, http.StatusCreated, response, w)
+	})
+}
+
+// HTTPMagicLinkResend creates an HTTP handler for the HandleMagicLinkResend method. It does not require an API key,
+// possession of the expired magic link's secret is used as authorization. The secret is read from the PathValueCode path
+// value, so it is not sent in the query string. Requests with an Accept header of text/html receive an HTML page instead
+// of JSON.
+func HTTPMagicLinkResend(s *handle.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
+		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
+
+		html := strings.Contains(r.Header.Get(mld.HeaderAccept), "text/html")
+		writeError := func(code int, message string) {
+			if html {
+				magiclink.WriteResendPage(w, code)
+				return
+			}
+			middleware.WriteErrorBody(ctx, code, message, w)
+		}
+
+		if r.Method != http.MethodPost {
+			writeError(http.StatusMethodNotAllowed, "Method not allowed.")
+			return
+		}
+
+		secret := r.PathValue(magiclink.PathValueCode)
+		if secret == "" {
+			writeError(http.StatusNotFound, "Magic link cannot be resent.")
+			return
+		}
+
+		response, err := s.HandleMagicLinkResend(ctx, secret)
+		if err != nil {
+			switch {
+			case errors.Is(err, handle.ErrResendNotFound):
+				writeError(http.StatusNotFound, "Magic link cannot be resent.")
+			case errors.Is(err, handle.ErrResendRateLimit):
+				logger.WarnContext(ctx, "Magic link resend exceeds rate limit.",
+					mld.LogErr, err,
+				)
+				writeError(http.StatusTooManyRequests, mld.ResponseTooManyRequests)
+			default:
+				logger.ErrorContext(ctx, "Failed to resend magic link email.",
+					mld.LogErr, err,
+				)
+				writeError(http.StatusInternalServerError, mld.ResponseInternalServerError)
+			}
+			return
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to commit transaction for magic link resend.",
+				mld.LogErr, err,
+			)
+			writeError(http.StatusInternalServerError, mld.ResponseInternalServerError)
+			return
+		}
+
+		if html {
+			magiclink.WriteResendPage(w, http.StatusOK)
+			return
+		}
+		writeResponse(ctx, http.StatusOK, response, w)
+	})
+}
+
+// HTTPMagicLinkExchange creates an HTTP handler for the HandleMagicLinkExchange method.
+func HTTPMagicLinkExchange(s *handle.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
+		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
+
+		validated, done := unmarshalRequest[model.MagicLinkExchangeRequest, model.ValidMagicLinkExchangeRequest](r, s.Config.Validation, w)
+		if done {
+			return
+		}
+
+		response, err := s.HandleMagicLinkExchange(ctx, validated)
+		switch {
+		case errors.Is(err, magiclink.ErrLinkNotFound):
+			middleware.WriteErrorBody(ctx, http.StatusNotFound, "Magic link not found.", w)
+			return
+		case errors.Is(err, magiclink.ErrCodeVerifierInvalid):
+			middleware.WriteErrorBody(ctx, http.StatusBadRequest, "Invalid code verifier.", w)
+			return
+		case errors.Is(err, magiclink.ErrClientBindingMismatch):
+			middleware.WriteErrorBody(ctx, http.StatusForbidden, "Client does not match the magic link's binding.", w)
+			return
+		case err != nil:
+			logger.ErrorContext(ctx, "Failed to exchange magic link code.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to commit transaction for magic link exchange.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		writeResponse(ctx, http.StatusOK, response, w)
+	})
+}
+
+// HTTPMagicLinkRevoke creates an HTTP handler for the HandleMagicLinkRevoke method.
+func HTTPMagicLinkRevoke(s *handle.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
+		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
+
+		validated, done := unmarshalRequest[model.MagicLinkRevokeRequest, model.ValidMagicLinkRevokeRequest](r, s.Config.Validation, w)
+		if done {
+			return
+		}
+
+		response, err := s.HandleMagicLinkRevoke(ctx, validated)
+		switch {
+		case errors.Is(err, magiclink.ErrLinkNotFound):
+			middleware.WriteErrorBody(ctx, http.StatusNotFound, "Magic link not found.", w)
+			return
+		case err != nil:
+			logger.ErrorContext(ctx, "Failed to revoke magic link.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to commit transaction for revoke magic link.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		writeResponse(ctx, http.StatusOK, response, w)
+	})
+}
+
+// HTTPMagicLinkStatus creates an HTTP handler for the HandleMagicLinkStatus method.
+func HTTPMagicLinkStatus(s *handle.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
+		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
+
+		validated, done := unmarshalRequest[model.MagicLinkStatusRequest, model.ValidMagicLinkStatusRequest](r, s.Config.Validation, w)
+		if done {
+			return
+		}
+
+		response, err := s.HandleMagicLinkStatus(ctx, validated)
+		switch {
+		case errors.Is(err, magiclink.ErrLinkNotFound):
+			middleware.WriteErrorBody(ctx, http.StatusNotFound, "Magic link not found.", w)
+			return
+		case err != nil:
+			logger.ErrorContext(ctx, "Failed to read magic link status.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to commit transaction for magic link status.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		writeResponse(ctx, http.StatusOK, response, w)
+	})
+}
+
 // HTTPOTPCreate creates an HTTP handler for the HandleOTPCreate method.
 func HTTPOTPCreate(s *handle.Server) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -243,6 +481,10 @@ func HTTPOTPCreate(s *handle.Server) http.Handler {
 
 		response, err := s.HandleOTPCreate(ctx, validated)
 		if err != nil {
+			if errors.Is(err, handle.ErrRegisteredClaimProvided) {
+				middleware.WriteErrorBody(ctx, http.StatusBadRequest, responseDontRegisteredClaims, w)
+				return
+			}
 			logger.ErrorContext(ctx, "Failed to create OTP.",
 				mld.LogErr, err,
 			)
@@ -315,6 +557,10 @@ func HTTPOTPEmailCreate(s *handle.Server) http.Handler {
 
 		response, err := s.HandleOTPEmailCreate(ctx, validated)
 		if err != nil {
+			if errors.Is(err, handle.ErrRegisteredClaimProvided) {
+				middleware.WriteErrorBody(ctx, http.StatusBadRequest, responseDontRegisteredClaims, w)
+				return
+			}
 			logger.ErrorContext(ctx, "Failed to create OTP email.",
 				mld.LogErr, err,
 			)
@@ -335,6 +581,122 @@ func HTTPOTPEmailCreate(s *handle.Server) http.Handler {
 	})
 }
 
+// HTTPOTPResend creates an HTTP handler for the HandleOTPResend method.
+func HTTPOTPResend(s *handle.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
+		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
+
+		validated, done := unmarshalRequest[model.OTPResendRequest, model.ValidOTPResendRequest](r, s.Config.Validation, w)
+		if done {
+			return
+		}
+
+		response, err := s.HandleOTPResend(ctx, validated)
+		switch {
+		case errors.Is(err, handle.ErrOTPResendNotFound):
+			middleware.WriteErrorBody(ctx, http.StatusNotFound, "OTP cannot be resent.", w)
+			return
+		case errors.Is(err, handle.ErrOTPResendCooldown):
+			middleware.WriteErrorBody(ctx, http.StatusTooManyRequests, "OTP was sent too recently to resend.", w)
+			return
+		case errors.Is(err, handle.ErrOTPResendLimit):
+			middleware.WriteErrorBody(ctx, http.StatusTooManyRequests, "OTP has been resent too many times.", w)
+			return
+		case err != nil:
+			logger.ErrorContext(ctx, "Failed to resend OTP.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to commit transaction for resend OTP.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		writeResponse(ctx, http.StatusOK, response, w)
+	})
+}
+
+// HTTPTOTPCreate creates an HTTP handler for the HandleTOTPCreate method.
+func HTTPTOTPCreate(s *handle.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
+		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
+
+		validated, done := unmarshalRequest[model.TOTPCreateRequest, model.ValidTOTPCreateRequest](r, s.Config.Validation, w)
+		if done {
+			return
+		}
+
+		response, err := s.HandleTOTPCreate(ctx, validated)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to create TOTP.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to commit transaction for create TOTP.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		writeResponse(ctx, http.StatusCreated, response, w)
+	})
+}
+
+// HTTPTOTPValidate creates an HTTP handler for the HandleTOTPValidate method.
+func HTTPTOTPValidate(s *handle.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
+		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
+
+		validated, done := unmarshalRequest[model.TOTPValidateRequest, model.ValidTOTPValidateRequest](r, s.Config.Validation, w)
+		if done {
+			return
+		}
+
+		response, err := s.HandleTOTPValidate(ctx, validated)
+		switch {
+		case errors.Is(err, otp.ErrOTPInvalid):
+			middleware.WriteErrorBody(ctx, http.StatusBadRequest, "Invalid TOTP.", w)
+			return
+		case err != nil:
+			logger.ErrorContext(ctx, "Failed to validate TOTP.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to commit transaction for validate TOTP.",
+				mld.LogErr, err,
+			)
+			middleware.WriteErrorBody(ctx, http.StatusInternalServerError, mld.ResponseInternalServerError, w)
+			return
+		}
+
+		writeResponse(ctx, http.StatusOK, response, w)
+	})
+}
+
 func writeResponse(ctx context.Context, code int, response any, w http.ResponseWriter) {
 	body, err := json.Marshal(response)
 	if err != nil {
@@ -374,6 +736,10 @@ func unmarshalRequest[T Validatable[K], K any](r *http.Request, validationConfig
 		return validated, true
 	}
 
+	sa, ok := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+	if ok {
+		validationConfig.RedirectAllowlist = sa.RedirectAllowlist
+	}
 	validated, err = unvalidated.Validate(validationConfig)
 	if err != nil {
 		middleware.WriteErrorBody(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %s.", err), w)
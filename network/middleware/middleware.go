@@ -41,7 +41,10 @@ func Apply(server *handle.Server, options handle.MiddlewareOptions) http.Handler
 	if options.Toggle.Authn {
 		h = wrap(h, createAuthn(server))
 	}
-	h = wrap(h, createTx(server), createLogger(server), createTimeout(server), requestUUID, createLimitRequestBody(server))
+	if !options.Toggle.NoTx {
+		h = wrap(h, createTx(server))
+	}
+	h = wrap(h, createLogger(server), createTimeout(server), requestUUID, createLimitRequestBody(server))
 	return h
 }
 
@@ -155,11 +158,19 @@ func createRateLimit(server *handle.Server) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
 			ctx := req.Context()
-			sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
-			err := server.Limiter.Wait(ctx, sa.UUID.String())
+			var key string
+			sa, ok := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+			if ok {
+				key = sa.UUID.String()
+			} else {
+				// Requests without an API key are rate limited by client IP address.
+				ip, _ := server.Config.ClientBinding.ClientIP(req)
+				key = ip.String()
+			}
+			err := server.Limiter.Wait(ctx, key)
 			if err != nil {
 				logger := ctx.Value(ctxkey.Logger).(*slog.Logger)
-				logger.WarnContext(ctx, "Service account request exceeds rate limit.",
+				logger.WarnContext(ctx, "Request exceeds rate limit.",
 					mld.LogErr, err,
 				)
 				WriteErrorBody(ctx, http.StatusTooManyRequests, mld.ResponseTooManyRequests, writer)
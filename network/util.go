@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/MicahParks/magiclinksdev/handle"
+	"github.com/MicahParks/magiclinksdev/magiclink"
 	"github.com/MicahParks/magiclinksdev/network/middleware"
 )
 
@@ -23,12 +24,35 @@ const (
 	PathMagicLinkCreate = "magic-link/create"
 	// PathMagicLinkEmailCreate is the path to the magic link email creation endpoint.
 	PathMagicLinkEmailCreate = "magic-link-email/create"
+	// PathMagicLinkOTPEmailCreate is the path to the endpoint that sends one email containing both a magic link and an OTP.
+	PathMagicLinkOTPEmailCreate = "magic-link-otp-email/create"
+	// PathMagicLinkExchange is the path to the endpoint that exchanges a magic link code and code verifier for a JWT.
+	PathMagicLinkExchange = "magic-link/exchange"
+	// PathMagicLinkPoll is the path to the endpoint that delivers the JWT for a cross-device magic link.
+	PathMagicLinkPoll = "magic-link/poll"
+	// PathMagicLinkQRCode is the path to the endpoint that serves the QR code image of a magic link.
+	PathMagicLinkQRCode = "magic-link/qr-code"
+	// PathMagicLinkRedeem is the path to the endpoint that visits a magic link and responds with the JWT as JSON.
+	PathMagicLinkRedeem = "magic-link/redeem"
+	// PathMagicLinkResend is the path to the endpoint that resends the email for an expired magic link. The secret of the
+	// expired magic link is the final path segment.
+	PathMagicLinkResend = "magic-link/resend"
+	// PathMagicLinkRevoke is the path to the magic link revocation endpoint.
+	PathMagicLinkRevoke = "magic-link/revoke"
+	// PathMagicLinkStatus is the path to the magic link status endpoint.
+	PathMagicLinkStatus = "magic-link/status"
 	// PathOTPCreate is the path to the OTP creation endpoint.
 	PathOTPCreate = "otp/create"
 	// PathOTPValidate is the path to the OTP validation endpoint.
 	PathOTPValidate = "otp/validate"
 	// PathOTPEmailCreate is the path to the OTP email creation endpoint.
 	PathOTPEmailCreate = "otp-email/create"
+	// PathOTPResend is the path to the endpoint that resends an OTP under the same ID.
+	PathOTPResend = "otp/resend"
+	// PathTOTPCreate is the path to the endpoint that enrolls an authenticator app.
+	PathTOTPCreate = "totp/create"
+	// PathTOTPValidate is the path to the endpoint that validates an authenticator app code or recovery code.
+	PathTOTPValidate = "totp/validate"
 )
 
 // CreateHTTPHandlers creates the HTTP handlers for the server.
@@ -49,6 +73,42 @@ func CreateHTTPHandlers(server *handle.Server) (*http.ServeMux, error) {
 				CommitTx: true,
 			},
 		},
+		{
+			Handler: server.MagicLink.MagicLinkHandler(),
+			Path:    pathMagicLinkHandler + "/{" + magiclink.PathValueCode + "}",
+			Toggle: handle.MiddlewareToggle{
+				CommitTx: true,
+			},
+		},
+		{
+			// Server-sent event streams last until the request times out, so each poll uses its own transaction.
+			Handler: server.MagicLink.CrossDeviceHandler(),
+			Path:    PathMagicLinkPoll,
+			Toggle: handle.MiddlewareToggle{
+				NoTx: true,
+			},
+		},
+		{
+			Handler: server.MagicLink.QRCodeHandler(),
+			Path:    PathMagicLinkQRCode,
+			Toggle: handle.MiddlewareToggle{
+				CommitTx: true,
+			},
+		},
+		{
+			Handler: server.MagicLink.RedeemHandler(),
+			Path:    PathMagicLinkRedeem,
+			Toggle: handle.MiddlewareToggle{
+				CommitTx: true,
+			},
+		},
+		{
+			Handler: HTTPMagicLinkResend(server),
+			Path:    PathMagicLinkResend + "/{" + magiclink.PathValueCode + "}",
+			Toggle: handle.MiddlewareToggle{
+				RateLimit: true,
+			},
+		},
 		{
 			Handler: HTTPReady(server),
 			Path:    PathReady,
@@ -94,6 +154,38 @@ func CreateHTTPHandlers(server *handle.Server) (*http.ServeMux, error) {
 				RateLimit: true,
 			},
 		},
+		{
+			Handler: HTTPMagicLinkOTPEmailCreate(server),
+			Path:    PathMagicLinkOTPEmailCreate,
+			Toggle: handle.MiddlewareToggle{
+				Authn:     true,
+				RateLimit: true,
+			},
+		},
+		{
+			Handler: HTTPMagicLinkExchange(server),
+			Path:    PathMagicLinkExchange,
+			Toggle: handle.MiddlewareToggle{
+				Authn:     true,
+				RateLimit: true,
+			},
+		},
+		{
+			Handler: HTTPMagicLinkRevoke(server),
+			Path:    PathMagicLinkRevoke,
+			Toggle: handle.MiddlewareToggle{
+				Authn:     true,
+				RateLimit: true,
+			},
+		},
+		{
+			Handler: HTTPMagicLinkStatus(server),
+			Path:    PathMagicLinkStatus,
+			Toggle: handle.MiddlewareToggle{
+				Authn:     true,
+				RateLimit: true,
+			},
+		},
 		{
 			Handler: HTTPOTPCreate(server),
 			Path:    PathOTPCreate,
@@ -118,6 +210,30 @@ func CreateHTTPHandlers(server *handle.Server) (*http.ServeMux, error) {
 				RateLimit: true,
 			},
 		},
+		{
+			Handler: HTTPOTPResend(server),
+			Path:    PathOTPResend,
+			Toggle: handle.MiddlewareToggle{
+				Authn:     true,
+				RateLimit: true,
+			},
+		},
+		{
+			Handler: HTTPTOTPCreate(server),
+			Path:    PathTOTPCreate,
+			Toggle: handle.MiddlewareToggle{
+				Authn:     true,
+				RateLimit: true,
+			},
+		},
+		{
+			Handler: HTTPTOTPValidate(server),
+			Path:    PathTOTPValidate,
+			Toggle: handle.MiddlewareToggle{
+				Authn:     true,
+				RateLimit: true,
+			},
+		},
 	}
 
 	for _, opt := range options {
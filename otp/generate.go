@@ -5,29 +5,29 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"unicode"
 
 	mld "github.com/MicahParks/magiclinksdev"
 )
 
+const (
+	// GroupSeparator separates groups of characters in an OTP for display. It is removed by Normalize.
+	GroupSeparator = "-"
+)
+
 var (
 	alphaLower = []rune("abcdefghijklmnopqrstuvwxyz")
 	alphaUpper = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
 	numeric    = []rune("0123456789")
+
+	// lookAlikes are characters that are easily confused with each other in common fonts.
+	lookAlikes = "0Oo1lI"
 )
 
 func Generate(args CreateParams) (string, error) {
-	charSet := make([]rune, 0)
-	if args.CharSetAlphaLower {
-		charSet = append(charSet, alphaLower...)
-	}
-	if args.CharSetAlphaUpper {
-		charSet = append(charSet, alphaUpper...)
-	}
-	if args.CharSetNumeric {
-		charSet = append(charSet, numeric...)
-	}
-	if len(charSet) == 0 {
-		return "", fmt.Errorf("must include at least one character set: %w", mld.ErrParams)
+	charSet, err := CharSet(args)
+	if err != nil {
+		return "", err
 	}
 	o := strings.Builder{}
 	for range args.Length {
@@ -42,3 +42,83 @@ func Generate(args CreateParams) (string, error) {
 	}
 	return o.String(), nil
 }
+
+// CharSet returns the characters an OTP is generated from. A custom Alphabet replaces the built-in character sets.
+// Duplicate characters are removed, as are characters that only differ by case when the OTP is case-insensitive, so
+// each character has the same chance of being picked.
+func CharSet(args CreateParams) ([]rune, error) {
+	candidates := make([]rune, 0)
+	if args.Alphabet != "" {
+		for _, r := range args.Alphabet {
+			if unicode.IsSpace(r) || string(r) == GroupSeparator {
+				return nil, fmt.Errorf("%w: alphabet cannot contain whitespace or %q", mld.ErrParams, GroupSeparator)
+			}
+		}
+		candidates = append(candidates, []rune(args.Alphabet)...)
+	} else {
+		if args.CharSetAlphaLower {
+			candidates = append(candidates, alphaLower...)
+		}
+		if args.CharSetAlphaUpper {
+			candidates = append(candidates, alphaUpper...)
+		}
+		if args.CharSetNumeric {
+			candidates = append(candidates, numeric...)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("must include at least one character set: %w", mld.ErrParams)
+	}
+
+	charSet := make([]rune, 0, len(candidates))
+	seen := make(map[rune]struct{}, len(candidates))
+	for _, r := range candidates {
+		if args.ExcludeLookAlikes && strings.ContainsRune(lookAlikes, r) {
+			continue
+		}
+		key := r
+		if args.CaseInsensitive {
+			key = unicode.ToLower(r)
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		charSet = append(charSet, r)
+	}
+	if len(charSet) == 0 {
+		return nil, fmt.Errorf("%w: no characters left after removing look-alikes", mld.ErrParams)
+	}
+	return charSet, nil
+}
+
+// Group splits an OTP into groups of the given size joined by GroupSeparator, such as "ABC-DEF", to make it easier to
+// read and type. A size of zero returns the OTP unchanged.
+func Group(o string, size uint) string {
+	return strings.Join(Groups(o, size), GroupSeparator)
+}
+
+// Groups splits an OTP into groups of the given size. A size of zero returns the OTP as a single group.
+func Groups(o string, size uint) []string {
+	runes := []rune(o)
+	if size == 0 || uint(len(runes)) <= size {
+		return []string{o}
+	}
+	groups := make([]string, 0, (uint(len(runes))+size-1)/size)
+	for len(runes) > 0 {
+		n := min(size, uint(len(runes)))
+		groups = append(groups, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return groups
+}
+
+// Normalize removes display grouping and whitespace from a user provided OTP.
+func Normalize(o string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || string(r) == GroupSeparator {
+			return -1
+		}
+		return r
+	}, o)
+}
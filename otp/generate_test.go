@@ -2,6 +2,7 @@ package otp
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	mld "github.com/MicahParks/magiclinksdev"
@@ -76,9 +77,43 @@ func TestGenerate(t *testing.T) {
 				CharSetAlphaLower: true,
 				CharSetAlphaUpper: true,
 				CharSetNumeric:    true,
-				Length:            12,
+				Length:            mld.MaxOTPLength,
 			},
 		},
+		{
+			name: "Alphabet",
+			params: CreateParams{
+				Alphabet: "ACDEFHJKMNPRTVWXY34679",
+				Length:   mld.DefaultOTPLength,
+			},
+		},
+		{
+			name: "AlphabetSeparator",
+			params: CreateParams{
+				Alphabet: "AB-CD",
+				Length:   mld.DefaultOTPLength,
+			},
+			expectedErr: mld.ErrParams,
+		},
+		{
+			name: "ExcludeLookAlikes",
+			params: CreateParams{
+				CharSetAlphaLower: true,
+				CharSetAlphaUpper: true,
+				CharSetNumeric:    true,
+				ExcludeLookAlikes: true,
+				Length:            mld.MaxOTPLength,
+			},
+		},
+		{
+			name: "OnlyLookAlikes",
+			params: CreateParams{
+				Alphabet:          "0O1lI",
+				ExcludeLookAlikes: true,
+				Length:            mld.DefaultOTPLength,
+			},
+			expectedErr: mld.ErrParams,
+		},
 	}
 	for _, tt := range tc {
 		t.Run(tt.name, func(t *testing.T) {
@@ -86,7 +121,10 @@ func TestGenerate(t *testing.T) {
 			if !errors.Is(err, tt.expectedErr) {
 				t.Errorf("expected %v, got %v", tt.expectedErr, err)
 			}
-			expectedSet := make([]rune, 0)
+			if tt.expectedErr != nil {
+				return
+			}
+			expectedSet := []rune(tt.params.Alphabet)
 			if tt.params.CharSetAlphaLower {
 				expectedSet = append(expectedSet, alphaLower...)
 			}
@@ -110,7 +148,77 @@ func TestGenerate(t *testing.T) {
 				if !found {
 					t.Errorf("rune %c not found in expected set", r)
 				}
+				if tt.params.ExcludeLookAlikes && strings.ContainsRune(lookAlikes, r) {
+					t.Errorf("look-alike rune %c found in OTP", r)
+				}
+			}
+		})
+	}
+}
+
+func TestCharSetCaseInsensitive(t *testing.T) {
+	charSet, err := CharSet(CreateParams{
+		CaseInsensitive:   true,
+		CharSetAlphaLower: true,
+		CharSetAlphaUpper: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create character set: %v", err)
+	}
+	if len(charSet) != len(alphaLower) {
+		t.Fatalf("expected %d characters, got %d", len(alphaLower), len(charSet))
+	}
+}
+
+func TestGroup(t *testing.T) {
+	tc := []struct {
+		name     string
+		otp      string
+		size     uint
+		expected string
+	}{
+		{
+			name:     "NoGrouping",
+			otp:      "ABCDEF",
+			size:     0,
+			expected: "ABCDEF",
+		},
+		{
+			name:     "Even",
+			otp:      "ABCDEF",
+			size:     3,
+			expected: "ABC-DEF",
+		},
+		{
+			name:     "Remainder",
+			otp:      "ABCDEFG",
+			size:     3,
+			expected: "ABC-DEF-G",
+		},
+		{
+			name:     "SizeLongerThanOTP",
+			otp:      "ABC",
+			size:     4,
+			expected: "ABC",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			grouped := Group(tt.otp, tt.size)
+			if grouped != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, grouped)
+			}
+			if Normalize(grouped) != tt.otp {
+				t.Fatalf("expected normalized %q, got %q", tt.otp, Normalize(grouped))
 			}
 		})
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	const expected = "abcDEF"
+	normalized := Normalize(" abc-DEF\n")
+	if normalized != expected {
+		t.Fatalf("expected %q, got %q", expected, normalized)
+	}
+}
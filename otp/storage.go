@@ -4,15 +4,23 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 var ErrOTPInvalid = errors.New("OTP invalid")
 
 type CreateParams struct {
+	Alphabet          string
+	CaseInsensitive   bool
 	CharSetAlphaLower bool
 	CharSetAlphaUpper bool
 	CharSetNumeric    bool
+	ExcludeLookAlikes bool
 	Expires           time.Time
+	GroupSize         uint
+	JWTClaims         jwt.Claims
+	JWTKeyID          *string
 	Length            uint
 }
 
@@ -22,7 +30,15 @@ type CreateResult struct {
 	OTP          string
 }
 
+type ValidateResult struct {
+	CreateParams CreateParams
+	ID           string
+}
+
 type Storage interface {
 	OTPCreate(ctx context.Context, params CreateParams) (CreateResult, error)
-	OTPValidate(ctx context.Context, id, o string) error
+	OTPValidate(ctx context.Context, id, o string) (ValidateResult, error)
+	OTPRegenerate(ctx context.Context, id string, params CreateParams) (CreateResult, error)
+	TOTPCreate(ctx context.Context, params TOTPCreateParams) (TOTPCreateResult, error)
+	TOTPValidate(ctx context.Context, params TOTPValidateParams) error
 }
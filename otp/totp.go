@@ -0,0 +1,148 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// TOTPDigits is the number of digits in a TOTP code. Authenticator apps assume six.
+	TOTPDigits = 6
+	// TOTPPeriod is the time step of a TOTP code. Authenticator apps assume 30 seconds.
+	TOTPPeriod = 30 * time.Second
+	// TOTPRecoveryCodeLength is the number of characters in a TOTP recovery code.
+	TOTPRecoveryCodeLength = 10
+
+	// totpSecretBytes is the size of a TOTP secret. RFC 4226 recommends 160 bits, the output size of HMAC-SHA1.
+	totpSecretBytes = 20
+)
+
+// TOTPBase32 is the encoding of TOTP secrets in otpauth:// URIs and for manual entry into authenticator apps.
+var TOTPBase32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPCreateParams are the parameters for enrolling an authenticator app.
+type TOTPCreateParams struct {
+	AccountName   string
+	Issuer        string
+	RecoveryCodes uint
+}
+
+// TOTPCreateResult is the result of enrolling an authenticator app. The secret and recovery codes are only available
+// at enrollment.
+type TOTPCreateResult struct {
+	CreateParams  TOTPCreateParams
+	ID            string
+	RecoveryCodes []string
+	Secret        []byte
+}
+
+// URI is the otpauth:// URI authenticator apps use to enroll, usually shown as a QR code.
+func (r TOTPCreateResult) URI() *url.URL {
+	return TOTPURI(r.CreateParams.Issuer, r.CreateParams.AccountName, r.Secret)
+}
+
+// TOTPValidateParams are the parameters for validating a TOTP code or a recovery code. Exactly one of Code and
+// RecoveryCode is set. Window is the number of time steps before and after the current one that are accepted to allow
+// for clock drift.
+type TOTPValidateParams struct {
+	Code         string
+	ID           string
+	RecoveryCode string
+	Window       uint
+}
+
+// GenerateTOTPSecret generates a new random TOTP secret.
+func GenerateTOTPSecret() ([]byte, error) {
+	secret := make([]byte, totpSecretBytes)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read random bytes for TOTP secret: %w", err)
+	}
+	return secret, nil
+}
+
+// GenerateTOTPRecoveryCodes generates single-use recovery codes for when the authenticator app is unavailable.
+func GenerateTOTPRecoveryCodes(count uint) ([]string, error) {
+	params := CreateParams{
+		CharSetAlphaLower: true,
+		CharSetNumeric:    true,
+		Length:            TOTPRecoveryCodeLength,
+	}
+	codes := make([]string, 0, count)
+	for range count {
+		code, err := Generate(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate TOTP recovery code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// HashTOTPRecoveryCode hashes a recovery code for storage. Recovery codes have enough entropy that a salt is not needed.
+func HashTOTPRecoveryCode(code string) []byte {
+	sum := sha256.Sum256([]byte(code))
+	return sum[:]
+}
+
+// TOTPCounter is the RFC 6238 time step counter for the given time.
+func TOTPCounter(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+// TOTPCode is the RFC 4226 HOTP code of the secret for the given counter. RFC 6238 TOTP codes use TOTPCounter as the
+// counter.
+func TOTPCode(secret []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	code := strconv.FormatUint(uint64(truncated%1_000_000), 10)
+	for len(code) < TOTPDigits {
+		code = "0" + code
+	}
+	return code
+}
+
+// TOTPMatch finds the counter of the given code within window time steps of now. Counters at or before lastCounter are
+// not accepted, so each code can only be used once. The returned counter should be stored as the next lastCounter.
+func TOTPMatch(secret []byte, code string, now time.Time, window uint, lastCounter int64) (counter int64, ok bool) {
+	current := TOTPCounter(now)
+	for c := current - int64(window); c <= current+int64(window); c++ {
+		if c <= lastCounter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(TOTPCode(secret, c)), []byte(code)) == 1 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPURI builds the otpauth:// URI for an authenticator app from the Key URI Format.
+func TOTPURI(issuer, accountName string, secret []byte) *url.URL {
+	query := url.Values{}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(TOTPDigits))
+	query.Set("issuer", issuer)
+	query.Set("period", strconv.Itoa(int(TOTPPeriod/time.Second)))
+	query.Set("secret", TOTPBase32.EncodeToString(secret))
+	return &url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}
+}
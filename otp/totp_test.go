@@ -0,0 +1,75 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 secret from the RFC 6238 test vectors.
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCode(t *testing.T) {
+	tc := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1111111111, expected: "050471"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+		{unix: 20000000000, expected: "353130"},
+	}
+	for _, tt := range tc {
+		code := TOTPCode(rfc6238Secret, TOTPCounter(time.Unix(tt.unix, 0)))
+		if code != tt.expected {
+			t.Errorf("expected %s at %d, got %s", tt.expected, tt.unix, code)
+		}
+	}
+}
+
+func TestTOTPMatch(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := TOTPCounter(now)
+	previous := TOTPCode(rfc6238Secret, current-1)
+
+	counter, ok := TOTPMatch(rfc6238Secret, previous, now, 1, -1)
+	if !ok || counter != current-1 {
+		t.Fatalf("expected previous time step to match within window")
+	}
+	_, ok = TOTPMatch(rfc6238Secret, previous, now, 0, -1)
+	if ok {
+		t.Fatalf("expected previous time step to not match without window")
+	}
+	_, ok = TOTPMatch(rfc6238Secret, previous, now, 1, counter)
+	if ok {
+		t.Fatalf("expected replayed code to not match")
+	}
+	_, ok = TOTPMatch(rfc6238Secret, "000000", now, 1, -1)
+	if ok {
+		t.Fatalf("expected wrong code to not match")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	u := TOTPURI("Example", "alice@example.com", rfc6238Secret)
+	expected := "otpauth://totp/Example:alice@example.com?algorithm=SHA1&digits=6&issuer=Example&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if u.String() != expected {
+		t.Fatalf("expected %s, got %s", expected, u.String())
+	}
+}
+
+func TestGenerateTOTPRecoveryCodes(t *testing.T) {
+	codes, err := GenerateTOTPRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("failed to generate recovery codes: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("expected 10 recovery codes, got %d", len(codes))
+	}
+	for _, code := range codes {
+		if len(code) != TOTPRecoveryCodeLength {
+			t.Errorf("expected recovery code length %d, got %d", TOTPRecoveryCodeLength, len(code))
+		}
+	}
+}
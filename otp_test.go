@@ -2,15 +2,24 @@ package magiclinksdev_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 
 	mld "github.com/MicahParks/magiclinksdev"
 	"github.com/MicahParks/magiclinksdev/model"
 	"github.com/MicahParks/magiclinksdev/network"
 	"github.com/MicahParks/magiclinksdev/network/middleware"
+	"github.com/MicahParks/magiclinksdev/network/middleware/ctxkey"
+	"github.com/MicahParks/magiclinksdev/otp"
 )
 
 func TestOTP(t *testing.T) {
@@ -31,6 +40,17 @@ func TestOTP(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "JWT",
+			reqBody: model.OTPCreateRequest{
+				OTPCreateParams: model.OTPCreateParams{
+					CharSetNumeric: true,
+					JWTCreateParams: &model.JWTCreateParams{
+						Claims: map[string]string{"foo": "bar"},
+					},
+				},
+			},
+		},
 		{
 			name: "AllLong",
 			reqBody: model.OTPCreateRequest{
@@ -43,6 +63,18 @@ func TestOTP(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "GroupedCaseInsensitive",
+			reqBody: model.OTPCreateRequest{
+				OTPCreateParams: model.OTPCreateParams{
+					Alphabet:          "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+					CaseInsensitive:   true,
+					ExcludeLookAlikes: true,
+					GroupSize:         4,
+					Length:            mld.MaxOTPLength,
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			marshaled, err := json.Marshal(tc.reqBody)
@@ -73,6 +105,16 @@ func TestOTP(t *testing.T) {
 				t.Fatalf("Failed to unmarshal response body: %v", err)
 			}
 
+			o := optCreateResponse.OTPCreateResults.OTP
+			if tc.reqBody.OTPCreateParams.GroupSize > 0 {
+				if !strings.Contains(o, otp.GroupSeparator) {
+					t.Fatalf("Expected grouped OTP, got %q", o)
+				}
+			}
+			if tc.reqBody.OTPCreateParams.CaseInsensitive {
+				o = strings.ToLower(o)
+			}
+
 			recorder = httptest.NewRecorder()
 			u, err = assets.conf.Server.BaseURL.Get().Parse(network.PathOTPValidate)
 			if err != nil {
@@ -81,7 +123,7 @@ func TestOTP(t *testing.T) {
 			body := model.OTPValidateRequest{
 				OTPValidateParams: model.OTPValidateParams{
 					ID:  optCreateResponse.OTPCreateResults.ID,
-					OTP: optCreateResponse.OTPCreateResults.OTP,
+					OTP: o,
 				},
 			}
 			marshaled, err = json.Marshal(body)
@@ -97,6 +139,30 @@ func TestOTP(t *testing.T) {
 				t.Fatalf("Expected status code %d, got %d", http.StatusOK, recorder.Code)
 			}
 
+			var otpValidateResponse model.OTPValidateResponse
+			err = json.Unmarshal(recorder.Body.Bytes(), &otpValidateResponse)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			jwtB64 := otpValidateResponse.OTPValidateResults.JWT
+			if tc.reqBody.OTPCreateParams.JWTCreateParams == nil {
+				if jwtB64 != "" {
+					t.Fatalf("Expected no JWT for OTP created without JWT create params")
+				}
+			} else {
+				claims := &testClaims{}
+				_, _, err = jwt.NewParser().ParseUnverified(jwtB64, claims)
+				if err != nil {
+					t.Fatalf("Failed to parse JWT: %v", err)
+				}
+				if claims.Foo != "bar" || claims.Issuer != assets.conf.Server.Iss {
+					t.Fatalf("Unexpected JWT claims: %+v", claims)
+				}
+				if len(claims.Audience) != 1 || claims.Audience[0] != assets.sa.Aud.String() {
+					t.Fatalf("Expected audience %q, got %q", assets.sa.Aud.String(), claims.Audience)
+				}
+			}
+
 			recorder = httptest.NewRecorder()
 			u, err = assets.conf.Server.BaseURL.Get().Parse(network.PathOTPValidate)
 			if err != nil {
@@ -123,3 +189,292 @@ func TestOTP(t *testing.T) {
 		})
 	}
 }
+
+func TestMagicLinkOTPEmail(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		visitLink bool
+	}{
+		{
+			name:      "OTPInvalidatesLink",
+			visitLink: false,
+		},
+		{
+			name:      "LinkInvalidatesOTP",
+			visitLink: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reqBody := model.MagicLinkOTPEmailCreateRequest{
+				MagicLinkCreateParams: model.MagicLinkCreateParams{
+					JWTCreateParams: model.JWTCreateParams{
+						Claims: json.RawMessage(`{"foo": "bar"}`),
+					},
+					RedirectURL: "https://github.com/MicahParks/magiclinksdev",
+				},
+				MagicLinkEmailCreateParams: model.MagicLinkEmailCreateParams{
+					ServiceName: "magiclinksdev",
+					Subject:     "Test subject",
+					Title:       "Test title",
+					ToEmail:     "customer@example.com",
+				},
+				OTPCreateParams: model.OTPCreateParams{
+					CharSetNumeric: true,
+				},
+			}
+			marshaled, err := json.Marshal(reqBody)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			u, err := assets.conf.Server.BaseURL.Get().Parse(network.PathMagicLinkOTPEmailCreate)
+			if err != nil {
+				t.Fatalf("Failed to parse URL: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewReader(marshaled))
+			req.Header.Set(mld.HeaderContentType, mld.ContentTypeJSON)
+			req.Header.Set(middleware.APIKeyHeader, assets.sa.APIKey.String())
+			assets.mux.ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusCreated {
+				t.Fatalf("Received non-201 status code: %d\n%s", recorder.Code, recorder.Body.String())
+			}
+
+			var createResponse model.MagicLinkOTPEmailCreateResponse
+			err = json.Unmarshal(recorder.Body.Bytes(), &createResponse)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			results := createResponse.MagicLinkOTPEmailCreateResults
+
+			visitLink := func(expectedCode int) {
+				recorder := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, results.MagicLinkCreateResults.MagicLink, nil)
+				assets.mux.ServeHTTP(recorder, req)
+				if recorder.Code != expectedCode {
+					t.Fatalf("Expected status code %d for magic link, got %d", expectedCode, recorder.Code)
+				}
+			}
+			validateOTP := func(expectedCode int) {
+				body := model.OTPValidateRequest{
+					OTPValidateParams: model.OTPValidateParams{
+						ID:  results.OTPCreateResults.ID,
+						OTP: results.OTPCreateResults.OTP,
+					},
+				}
+				marshaled, err := json.Marshal(body)
+				if err != nil {
+					t.Fatalf("Failed to marshal request body: %v", err)
+				}
+				u, err := assets.conf.Server.BaseURL.Get().Parse(network.PathOTPValidate)
+				if err != nil {
+					t.Fatalf("Failed to parse URL: %v", err)
+				}
+				recorder := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, u.String(), bytes.NewReader(marshaled))
+				req.Header.Set(mld.HeaderContentType, mld.ContentTypeJSON)
+				req.Header.Set(middleware.APIKeyHeader, assets.sa.APIKey.String())
+				assets.mux.ServeHTTP(recorder, req)
+				if recorder.Code != expectedCode {
+					t.Fatalf("Expected status code %d for OTP, got %d", expectedCode, recorder.Code)
+				}
+			}
+
+			if tc.visitLink {
+				visitLink(http.StatusSeeOther)
+				validateOTP(http.StatusBadRequest)
+			} else {
+				validateOTP(http.StatusOK)
+				visitLink(http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	reqBody := model.TOTPCreateRequest{
+		TOTPCreateParams: model.TOTPCreateParams{
+			AccountName:   "customer@example.com",
+			Issuer:        "magiclinksdev",
+			RecoveryCodes: 2,
+		},
+	}
+	marshaled, err := json.Marshal(reqBody)
+	if err != nil {
+		t.Fatalf("Failed to marshal request body: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	u, err := assets.conf.Server.BaseURL.Get().Parse(network.PathTOTPCreate)
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewReader(marshaled))
+	req.Header.Set(mld.HeaderContentType, mld.ContentTypeJSON)
+	req.Header.Set(middleware.APIKeyHeader, assets.sa.APIKey.String())
+	assets.mux.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Received non-201 status code: %d\n%s", recorder.Code, recorder.Body.String())
+	}
+
+	var createResponse model.TOTPCreateResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &createResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	results := createResponse.TOTPCreateResults
+	if len(results.RecoveryCodes) != 2 {
+		t.Fatalf("Expected 2 recovery codes, got %d", len(results.RecoveryCodes))
+	}
+	secret, err := otp.TOTPBase32.DecodeString(results.Secret)
+	if err != nil {
+		t.Fatalf("Failed to decode TOTP secret: %v", err)
+	}
+
+	validate := func(params model.TOTPValidateParams, expectedCode int) {
+		params.ID = results.ID
+		marshaled, err := json.Marshal(model.TOTPValidateRequest{TOTPValidateParams: params})
+		if err != nil {
+			t.Fatalf("Failed to marshal request body: %v", err)
+		}
+		u, err := assets.conf.Server.BaseURL.Get().Parse(network.PathTOTPValidate)
+		if err != nil {
+			t.Fatalf("Failed to parse URL: %v", err)
+		}
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewReader(marshaled))
+		req.Header.Set(mld.HeaderContentType, mld.ContentTypeJSON)
+		req.Header.Set(middleware.APIKeyHeader, assets.sa.APIKey.String())
+		assets.mux.ServeHTTP(recorder, req)
+		if recorder.Code != expectedCode {
+			t.Fatalf("Expected status code %d, got %d", expectedCode, recorder.Code)
+		}
+	}
+
+	code := otp.TOTPCode(secret, otp.TOTPCounter(time.Now()))
+	validate(model.TOTPValidateParams{Code: code}, http.StatusOK)
+	validate(model.TOTPValidateParams{Code: code}, http.StatusBadRequest) // Replay.
+
+	validate(model.TOTPValidateParams{RecoveryCode: results.RecoveryCodes[0]}, http.StatusOK)
+	validate(model.TOTPValidateParams{RecoveryCode: results.RecoveryCodes[0]}, http.StatusBadRequest) // Single use.
+}
+
+func TestOTPResend(t *testing.T) {
+	post := func(path string, body any, expectedCode int) []byte {
+		marshaled, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to marshal request body: %v", err)
+		}
+		u, err := assets.conf.Server.BaseURL.Get().Parse(path)
+		if err != nil {
+			t.Fatalf("Failed to parse URL: %v", err)
+		}
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, u.Path, bytes.NewReader(marshaled))
+		req.Header.Set(mld.HeaderContentType, mld.ContentTypeJSON)
+		req.Header.Set(middleware.APIKeyHeader, assets.sa.APIKey.String())
+		assets.mux.ServeHTTP(recorder, req)
+		if recorder.Code != expectedCode {
+			t.Fatalf("Expected status code %d, got %d\n%s", expectedCode, recorder.Code, recorder.Body.String())
+		}
+		return recorder.Body.Bytes()
+	}
+
+	createBody := model.OTPCreateRequest{
+		OTPCreateParams: model.OTPCreateParams{
+			CharSetAlphaUpper: true,
+			CharSetNumeric:    true,
+			Length:            12,
+		},
+	}
+	var createResponse model.OTPCreateResponse
+	err := json.Unmarshal(post(network.PathOTPCreate, createBody, http.StatusCreated), &createResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	id := createResponse.OTPCreateResults.ID
+	previous := createResponse.OTPCreateResults.OTP
+
+	maxResends := assets.conf.Server.OTPResend.MaxResends
+	resendBody := model.OTPResendRequest{
+		OTPResendParams: model.OTPResendParams{
+			ID: id,
+		},
+	}
+	for i := range maxResends {
+		time.Sleep(assets.conf.Server.OTPResend.Cooldown.Get())
+		var resendResponse model.OTPResendResponse
+		err = json.Unmarshal(post(network.PathOTPResend, resendBody, http.StatusOK), &resendResponse)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response body: %v", err)
+		}
+		results := resendResponse.OTPResendResults
+		if results.ID != id {
+			t.Fatalf("Expected resent OTP to keep ID %q, got %q", id, results.ID)
+		}
+		if results.OTP == previous {
+			t.Fatalf("Expected resent OTP to be regenerated")
+		}
+		if results.Emailed {
+			t.Fatalf("Expected OTP created without email to not be emailed")
+		}
+		if results.ResendsRemaining != maxResends-i-1 {
+			t.Fatalf("Expected %d resends remaining, got %d", maxResends-i-1, results.ResendsRemaining)
+		}
+
+		validateBody := model.OTPValidateRequest{
+			OTPValidateParams: model.OTPValidateParams{
+				ID:  id,
+				OTP: previous,
+			},
+		}
+		post(network.PathOTPValidate, validateBody, http.StatusBadRequest)
+		previous = results.OTP
+	}
+
+	time.Sleep(assets.conf.Server.OTPResend.Cooldown.Get())
+	post(network.PathOTPResend, resendBody, http.StatusTooManyRequests)
+
+	validateBody := model.OTPValidateRequest{
+		OTPValidateParams: model.OTPValidateParams{
+			ID:  id,
+			OTP: previous,
+		},
+	}
+	post(network.PathOTPValidate, validateBody, http.StatusOK)
+	post(network.PathOTPResend, resendBody, http.StatusNotFound)
+}
+
+func TestOTPServiceAccountScope(t *testing.T) {
+	ctx, tx := storeCtx(t)
+
+	created, err := server.Store.OTPCreate(ctx, otp.CreateParams{
+		CharSetNumeric: true,
+		Expires:        time.Now().Add(time.Hour),
+		Length:         6,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create OTP: %v", err)
+	}
+
+	otherCtx := context.WithValue(ctx, ctxkey.ServiceAccount, model.ServiceAccount{UUID: uuid.New()})
+	_, err = server.Store.OTPRegenerate(otherCtx, created.ID, created.CreateParams)
+	if !errors.Is(err, otp.ErrOTPInvalid) {
+		t.Fatalf("Expected error %v when regenerating another service account's OTP, got %v", otp.ErrOTPInvalid, err)
+	}
+	_, err = server.Store.OTPValidate(otherCtx, created.ID, created.OTP)
+	if !errors.Is(err, otp.ErrOTPInvalid) {
+		t.Fatalf("Expected error %v when validating another service account's OTP, got %v", otp.ErrOTPInvalid, err)
+	}
+	_, err = server.Store.OTPValidate(ctx, created.ID, created.OTP)
+	if err != nil {
+		t.Fatalf("Failed to validate OTP: %v", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+}
@@ -4,5 +4,7 @@ import "context"
 
 // RateLimiter is an interface for rate limiting.
 type RateLimiter interface {
+	// Allow reports whether an event for the key may happen now without waiting. The event is counted if it is allowed.
+	Allow(key string) bool
 	Wait(ctx context.Context, serviceAccountUUID string) error
 }
@@ -41,8 +41,17 @@ func NewMemory(conf Config) RateLimiter {
 	return m
 }
 
+// Allow implements the RateLimiter interface.
+func (m *memory) Allow(key string) bool {
+	return m.limiter(key).Allow()
+}
+
 // Wait implements the RateLimiter interface.
 func (m *memory) Wait(ctx context.Context, key string) error {
+	return m.limiter(key).Wait(ctx)
+}
+
+func (m *memory) limiter(key string) *rate.Limiter {
 	m.mux.RLock()
 	limiter, ok := m.m[key]
 	m.mux.RUnlock()
@@ -52,5 +61,5 @@ func (m *memory) Wait(ctx context.Context, key string) error {
 		m.m[key] = limiter
 		m.mux.Unlock()
 	}
-	return limiter.Wait(ctx)
+	return limiter
 }
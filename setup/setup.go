@@ -7,14 +7,23 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	jt "github.com/MicahParks/jsontype"
+
 	"github.com/MicahParks/magiclinksdev/config"
+	"github.com/MicahParks/magiclinksdev/email/filesystem"
+	"github.com/MicahParks/magiclinksdev/email/mailgun"
+	"github.com/MicahParks/magiclinksdev/email/postmark"
+	"github.com/MicahParks/magiclinksdev/email/resend"
 	"github.com/MicahParks/magiclinksdev/email/sendgrid"
 	"github.com/MicahParks/magiclinksdev/email/ses"
+	"github.com/MicahParks/magiclinksdev/email/smtp"
 	"github.com/MicahParks/magiclinksdev/magiclink"
 	"github.com/MicahParks/magiclinksdev/mldtest"
 	"github.com/MicahParks/magiclinksdev/network"
@@ -27,8 +36,12 @@ import (
 	"github.com/MicahParks/magiclinksdev/storage"
 )
 
+// DefaultDevViewerPath is the default path, relative to the base URL, of the captured email viewer in development mode.
+const DefaultDevViewerPath = "dev/mail"
+
 // NopConfig is the configuration for a no-operation email provider magiclinksdev server.
 type NopConfig struct {
+	Dev         NopDevConfig   `json:"dev"`
 	Server      config.Config  `json:"server"`
 	Storage     storage.Config `json:"storage"`
 	RateLimiter rlimit.Config  `json:"rateLimiter"`
@@ -38,6 +51,10 @@ type NopConfig struct {
 func (n NopConfig) DefaultsAndValidate() (NopConfig, error) {
 	const errMsg = "failed to validate and apply defaults to nop %s configuration: %w"
 	var err error
+	n.Dev, err = n.Dev.DefaultsAndValidate()
+	if err != nil {
+		return NopConfig{}, fmt.Errorf(errMsg, "dev", err)
+	}
 	n.Server, err = n.Server.DefaultsAndValidate()
 	if err != nil {
 		return NopConfig{}, fmt.Errorf(errMsg, "server", err)
@@ -53,6 +70,37 @@ func (n NopConfig) DefaultsAndValidate() (NopConfig, error) {
 	return n, nil
 }
 
+// NopDevConfig is the development mode configuration for a no-operation email provider magiclinksdev server. In
+// development mode, emails are written to a directory as .eml files instead of only being logged. Unless disabled, the
+// captured emails can be viewed in a browser at ViewerPath, relative to the server's base URL. The viewer exposes
+// every captured email without authentication, so development mode must never be used in production.
+type NopDevConfig struct {
+	DisableViewer bool              `json:"disableViewer"`
+	Enabled       bool              `json:"enabled"`
+	Filesystem    filesystem.Config `json:"filesystem"`
+	ViewerPath    string            `json:"viewerPath"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (d NopDevConfig) DefaultsAndValidate() (NopDevConfig, error) {
+	if !d.Enabled {
+		return d, nil
+	}
+	var err error
+	d.Filesystem, err = d.Filesystem.DefaultsAndValidate()
+	if err != nil {
+		return NopDevConfig{}, fmt.Errorf("failed to validate and apply defaults to filesystem configuration: %w", err)
+	}
+	if d.ViewerPath == "" {
+		d.ViewerPath = DefaultDevViewerPath
+	}
+	u, err := url.Parse(d.ViewerPath)
+	if err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(d.ViewerPath, "/") {
+		return NopDevConfig{}, fmt.Errorf("dev viewer path must be relative to the base URL: %w", jt.ErrDefaultsAndValidate)
+	}
+	return d, nil
+}
+
 // MultiConfig is the configuration for a multiple email provider magiclinksdev server.
 type MultiConfig struct {
 	SES         ses.Config      `json:"ses"`
@@ -151,6 +199,130 @@ func (m SendGridConfig) DefaultsAndValidate() (SendGridConfig, error) {
 	return m, nil
 }
 
+// SMTPConfig is the configuration for the SMTP email provider.
+type SMTPConfig struct {
+	SMTP        smtp.Config    `json:"smtp"`
+	Server      config.Config  `json:"server"`
+	Storage     storage.Config `json:"storage"`
+	RateLimiter rlimit.Config  `json:"rateLimiter"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (s SMTPConfig) DefaultsAndValidate() (SMTPConfig, error) {
+	const errMsg = "failed to validate and apply defaults to smtp provider %s configuration: %w"
+	var err error
+	s.SMTP, err = s.SMTP.DefaultsAndValidate()
+	if err != nil {
+		return s, fmt.Errorf(errMsg, "smtp", err)
+	}
+	s.Server, err = s.Server.DefaultsAndValidate()
+	if err != nil {
+		return SMTPConfig{}, fmt.Errorf(errMsg, "server", err)
+	}
+	s.Storage, err = s.Storage.DefaultsAndValidate()
+	if err != nil {
+		return SMTPConfig{}, fmt.Errorf(errMsg, "storage", err)
+	}
+	s.RateLimiter, err = s.RateLimiter.DefaultsAndValidate()
+	if err != nil {
+		return SMTPConfig{}, fmt.Errorf(errMsg, "rate limiter", err)
+	}
+	return s, nil
+}
+
+// PostmarkConfig is the configuration for the Postmark email provider.
+type PostmarkConfig struct {
+	Postmark    postmark.Config `json:"postmark"`
+	Server      config.Config   `json:"server"`
+	Storage     storage.Config  `json:"storage"`
+	RateLimiter rlimit.Config   `json:"rateLimiter"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (s PostmarkConfig) DefaultsAndValidate() (PostmarkConfig, error) {
+	const errMsg = "failed to validate and apply defaults to postmark provider %s configuration: %w"
+	var err error
+	s.Postmark, err = s.Postmark.DefaultsAndValidate()
+	if err != nil {
+		return s, fmt.Errorf(errMsg, "postmark", err)
+	}
+	s.Server, err = s.Server.DefaultsAndValidate()
+	if err != nil {
+		return PostmarkConfig{}, fmt.Errorf(errMsg, "server", err)
+	}
+	s.Storage, err = s.Storage.DefaultsAndValidate()
+	if err != nil {
+		return PostmarkConfig{}, fmt.Errorf(errMsg, "storage", err)
+	}
+	s.RateLimiter, err = s.RateLimiter.DefaultsAndValidate()
+	if err != nil {
+		return PostmarkConfig{}, fmt.Errorf(errMsg, "rate limiter", err)
+	}
+	return s, nil
+}
+
+// MailgunConfig is the configuration for the Mailgun email provider.
+type MailgunConfig struct {
+	Mailgun     mailgun.Config `json:"mailgun"`
+	Server      config.Config  `json:"server"`
+	Storage     storage.Config `json:"storage"`
+	RateLimiter rlimit.Config  `json:"rateLimiter"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (s MailgunConfig) DefaultsAndValidate() (MailgunConfig, error) {
+	const errMsg = "failed to validate and apply defaults to mailgun provider %s configuration: %w"
+	var err error
+	s.Mailgun, err = s.Mailgun.DefaultsAndValidate()
+	if err != nil {
+		return s, fmt.Errorf(errMsg, "mailgun", err)
+	}
+	s.Server, err = s.Server.DefaultsAndValidate()
+	if err != nil {
+		return MailgunConfig{}, fmt.Errorf(errMsg, "server", err)
+	}
+	s.Storage, err = s.Storage.DefaultsAndValidate()
+	if err != nil {
+		return MailgunConfig{}, fmt.Errorf(errMsg, "storage", err)
+	}
+	s.RateLimiter, err = s.RateLimiter.DefaultsAndValidate()
+	if err != nil {
+		return MailgunConfig{}, fmt.Errorf(errMsg, "rate limiter", err)
+	}
+	return s, nil
+}
+
+// ResendConfig is the configuration for the Resend email provider.
+type ResendConfig struct {
+	Resend      resend.Config  `json:"resend"`
+	Server      config.Config  `json:"server"`
+	Storage     storage.Config `json:"storage"`
+	RateLimiter rlimit.Config  `json:"rateLimiter"`
+}
+
+// DefaultsAndValidate implements the jsontype.Config interface.
+func (s ResendConfig) DefaultsAndValidate() (ResendConfig, error) {
+	const errMsg = "failed to validate and apply defaults to resend provider %s configuration: %w"
+	var err error
+	s.Resend, err = s.Resend.DefaultsAndValidate()
+	if err != nil {
+		return s, fmt.Errorf(errMsg, "resend", err)
+	}
+	s.Server, err = s.Server.DefaultsAndValidate()
+	if err != nil {
+		return ResendConfig{}, fmt.Errorf(errMsg, "server", err)
+	}
+	s.Storage, err = s.Storage.DefaultsAndValidate()
+	if err != nil {
+		return ResendConfig{}, fmt.Errorf(errMsg, "storage", err)
+	}
+	s.RateLimiter, err = s.RateLimiter.DefaultsAndValidate()
+	if err != nil {
+		return ResendConfig{}, fmt.Errorf(errMsg, "rate limiter", err)
+	}
+	return s, nil
+}
+
 // TestConfig is the configuration for a test magiclinksdev server.
 type TestConfig struct {
 	Server      config.Config  `json:"server"`
@@ -213,13 +385,39 @@ func CreateNopProviderServer(ctx context.Context, conf NopConfig, options Server
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage: %w", err)
 	}
-	nop := nopProvider{logger: options.Logger.With("provider", "nop")}
+	var provider email.Provider = nopProvider{logger: options.Logger.With("provider", "nop")}
+	if conf.Dev.Enabled {
+		provider, err = filesystem.NewProvider(conf.Dev.Filesystem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create filesystem email provider: %w", err)
+		}
+		options.Logger.WarnContext(ctx, "Development mode is enabled. Emails are written to a directory instead of being sent.",
+			"dir", conf.Dev.Filesystem.Dir,
+		)
+	}
 	interfaces := ServerInterfaces{
-		EmailProvider: nop,
+		EmailProvider: provider,
 		RateLimiter:   rateLimiter,
 		Store:         store,
 	}
-	return CreateServer(ctx, conf.Server, options, interfaces)
+	server, err := CreateServer(ctx, conf.Server, options, interfaces)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Dev.Enabled && !conf.Dev.DisableViewer {
+		u, err := conf.Server.BaseURL.Get().Parse(conf.Dev.ViewerPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dev viewer path: %w", err)
+		}
+		viewer := filesystem.NewViewer(conf.Dev.Filesystem.Dir, filesystem.ViewerOptions{
+			Logger: options.Logger.With("devViewer", true),
+		})
+		server.HTTPMux.Handle(u.Path, viewer)
+		options.Logger.WarnContext(ctx, "Captured emails can be viewed in a browser.",
+			"url", u.String(),
+		)
+	}
+	return server, nil
 }
 
 // CreateMultiProviderServer creates a new magiclinksdev server with multiple email providers.
@@ -290,6 +488,82 @@ func CreateSendGridProvider(ctx context.Context, conf SendGridConfig, options Se
 	return CreateServer(ctx, conf.Server, options, interfaces)
 }
 
+// CreateSMTPProvider creates a new magiclinksdev server with an SMTP email provider.
+func CreateSMTPProvider(ctx context.Context, conf SMTPConfig, options ServerOptions) (*handle.Server, error) {
+	provider, err := smtp.NewProvider(conf.SMTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email provider: %w", err)
+	}
+	rateLimiter := rlimit.NewMemory(conf.RateLimiter)
+	store, _, err := storage.NewWithSetup(ctx, conf.Storage, options.Logger.With("postgresSetup", true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage: %w", err)
+	}
+	interfaces := ServerInterfaces{
+		EmailProvider: provider,
+		RateLimiter:   rateLimiter,
+		Store:         store,
+	}
+	return CreateServer(ctx, conf.Server, options, interfaces)
+}
+
+// CreatePostmarkProvider creates a new magiclinksdev server with a Postmark email provider.
+func CreatePostmarkProvider(ctx context.Context, conf PostmarkConfig, options ServerOptions) (*handle.Server, error) {
+	provider, err := postmark.NewProvider(conf.Postmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email provider: %w", err)
+	}
+	rateLimiter := rlimit.NewMemory(conf.RateLimiter)
+	store, _, err := storage.NewWithSetup(ctx, conf.Storage, options.Logger.With("postgresSetup", true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage: %w", err)
+	}
+	interfaces := ServerInterfaces{
+		EmailProvider: provider,
+		RateLimiter:   rateLimiter,
+		Store:         store,
+	}
+	return CreateServer(ctx, conf.Server, options, interfaces)
+}
+
+// CreateMailgunProvider creates a new magiclinksdev server with a Mailgun email provider.
+func CreateMailgunProvider(ctx context.Context, conf MailgunConfig, options ServerOptions) (*handle.Server, error) {
+	provider, err := mailgun.NewProvider(conf.Mailgun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email provider: %w", err)
+	}
+	rateLimiter := rlimit.NewMemory(conf.RateLimiter)
+	store, _, err := storage.NewWithSetup(ctx, conf.Storage, options.Logger.With("postgresSetup", true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage: %w", err)
+	}
+	interfaces := ServerInterfaces{
+		EmailProvider: provider,
+		RateLimiter:   rateLimiter,
+		Store:         store,
+	}
+	return CreateServer(ctx, conf.Server, options, interfaces)
+}
+
+// CreateResendProvider creates a new magiclinksdev server with a Resend email provider.
+func CreateResendProvider(ctx context.Context, conf ResendConfig, options ServerOptions) (*handle.Server, error) {
+	provider, err := resend.NewProvider(conf.Resend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email provider: %w", err)
+	}
+	rateLimiter := rlimit.NewMemory(conf.RateLimiter)
+	store, _, err := storage.NewWithSetup(ctx, conf.Storage, options.Logger.With("postgresSetup", true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage: %w", err)
+	}
+	interfaces := ServerInterfaces{
+		EmailProvider: provider,
+		RateLimiter:   rateLimiter,
+		Store:         store,
+	}
+	return CreateServer(ctx, conf.Server, options, interfaces)
+}
+
 // CreateTestingProvider creates a new magiclinksdev server with a testing email provider.
 func CreateTestingProvider(ctx context.Context, conf TestConfig, options ServerOptions) (*handle.Server, error) {
 	provider := mldtest.NopProvider{}
@@ -318,20 +592,48 @@ func CreateServer(ctx context.Context, conf config.Config, options ServerOptions
 
 	var customRedirector magiclink.Redirector
 	switch conf.PreventRobots.Method {
+	case config.PreventRobotsHCaptcha:
+		customRedirector = magiclink.NewHCaptchaRedirector(conf.PreventRobots.HCaptcha)
+	case config.PreventRobotsInterstitial:
+		customRedirector = magiclink.NewInterstitialRedirector(conf.PreventRobots.Interstitial)
+	case config.PreventRobotsProofOfWork:
+		customRedirector = magiclink.NewProofOfWorkRedirector(conf.PreventRobots.ProofOfWork)
 	case config.PreventRobotsReCAPTCHAV3:
 		customRedirector = magiclink.NewReCAPTCHAV3Redirector(conf.PreventRobots.ReCAPTCHAV3)
+	case config.PreventRobotsTurnstile:
+		customRedirector = magiclink.NewTurnstileRedirector(conf.PreventRobots.Turnstile)
+	}
+
+	errorHandler := options.MagicLinkErrorHandler
+	if errorHandler == nil {
+		errorPageConfig := magiclink.ErrorPageConfig{
+			Branding:       conf.ErrorPage,
+			BrandingLookup: errorPageBrandingLookup(interfaces.Store, conf.SecretQueryKey),
+		}
+		if conf.MagicLinkResend.Enabled {
+			resendURL, err := conf.BaseURL.Get().Parse(network.PathMagicLinkResend)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse magic link resend URL: %w", err)
+			}
+			errorPageConfig.ResendURL = errorPageResendURL(interfaces.Store, conf.SecretQueryKey, resendURL)
+		}
+		errorHandler = magiclink.NewErrorPageHandler(errorPageConfig)
 	}
 
 	magicLinkConfig := magiclink.Config{
-		ErrorHandler: MagicLinkErrorHandler(options.MagicLinkErrorHandler),
+		Binding:      conf.ClientBinding,
+		ErrorHandler: MagicLinkErrorHandler(errorHandler),
 		JWKS: magiclink.JWKSParams{
 			CacheRefresh: time.Second,
 			Store:        interfaces.Store,
 		},
 		CustomRedirector: customRedirector,
+		LinkFormat:       conf.LinkFormat,
+		Logger:           logger,
 		ServiceURL:       magicLinkServiceURL,
 		SecretQueryKey:   conf.SecretQueryKey,
-		Store:            interfaces.Store,
+		ShortCodes:       conf.ShortCodes,
+		Store:            crossDeviceStorage{Storage: interfaces.Store},
 	}
 
 	tx, err := interfaces.Store.Begin(ctx)
@@ -398,6 +700,7 @@ func CreateServer(ctx context.Context, conf config.Config, options ServerOptions
 		Logger:         logger,
 		MagicLink:      magicLink,
 		MiddlewareHook: options.MiddlewareHook,
+		ResendLimiter:  rlimit.NewMemory(conf.MagicLinkResend.RateLimiter),
 		Store:          interfaces.Store,
 	}
 
@@ -412,21 +715,83 @@ func MagicLinkErrorHandler(h magiclink.ErrorHandler) magiclink.ErrorHandler {
 		logger.ErrorContext(ctx, "Failed to handle magic link.",
 			mld.LogErr, args.Err,
 		)
-		tx := ctx.Value(ctxkey.Tx).(storage.Tx)
-		err := tx.Rollback(ctx)
-		if err != nil {
-			logger.ErrorContext(ctx, "Failed to rollback transaction.",
-				mld.LogErr, err,
-			)
-		}
 		if h == nil {
 			args.Writer.WriteHeader(args.SuggestedResponseCode)
 		} else {
 			h.Handle(args)
 		}
+		// The error page reads from the request's transaction, so it is rolled back after the error is handled.
+		tx, ok := ctx.Value(ctxkey.Tx).(storage.Tx)
+		if ok {
+			err := tx.Rollback(ctx)
+			if err != nil {
+				logger.ErrorContext(ctx, "Failed to rollback transaction.",
+					mld.LogErr, err,
+				)
+			}
+		}
 	})
 }
 
+// errorPageBrandingLookup finds the error page branding of the service account that created the visited magic link.
+func errorPageBrandingLookup(store storage.Storage, secretQueryKey string) func(args magiclink.ErrorHandlerParams) (magiclink.ErrorPageBranding, error) {
+	return func(args magiclink.ErrorHandlerParams) (magiclink.ErrorPageBranding, error) {
+		secret := magiclink.RequestSecret(args.Request, secretQueryKey)
+		ctx := args.Request.Context()
+		_, ok := ctx.Value(ctxkey.Tx).(storage.Tx)
+		if secret == "" || !ok {
+			return magiclink.ErrorPageBranding{}, nil
+		}
+		branding, err := store.SAErrorPageRead(ctx, secret)
+		if err != nil {
+			return magiclink.ErrorPageBranding{}, fmt.Errorf("failed to read error page branding: %w", err)
+		}
+		return branding, nil
+	}
+}
+
+// errorPageResendURL creates the URL to resend the email for the visited magic link, if the email can be resent.
+func errorPageResendURL(store storage.Storage, secretQueryKey string, resendURL *url.URL) func(args magiclink.ErrorHandlerParams) string {
+	return func(args magiclink.ErrorHandlerParams) string {
+		secret := magiclink.RequestSecret(args.Request, secretQueryKey)
+		ctx := args.Request.Context()
+		_, ok := ctx.Value(ctxkey.Tx).(storage.Tx)
+		if secret == "" || !ok {
+			return ""
+		}
+		_, err := store.MagicLinkResendRead(ctx, secret)
+		if err != nil {
+			return ""
+		}
+		return resendURL.JoinPath(secret).String()
+	}
+}
+
+// crossDeviceStorage collects cross-device JWTs in their own transactions. The magic link poll endpoint has no request
+// transaction, so a server-sent event stream does not hold a transaction open and each collected JWT is committed
+// before it is written to the stream.
+type crossDeviceStorage struct {
+	storage.Storage
+}
+
+func (c crossDeviceStorage) MagicLinkCrossDeviceCollect(ctx context.Context, token string) (magiclink.CrossDeviceResult, error) {
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return magiclink.CrossDeviceResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer tx.Rollback(ctx)
+	result, err := c.Storage.MagicLinkCrossDeviceCollect(context.WithValue(ctx, ctxkey.Tx, tx), token)
+	if err != nil {
+		return magiclink.CrossDeviceResult{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return magiclink.CrossDeviceResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
 type nopMiddlewareHook struct{}
 
 // Hook implements handle.MiddlewareHook.
@@ -444,6 +809,12 @@ func (n nopProvider) SendMagicLink(ctx context.Context, e email.Email) error {
 	)
 	return nil
 }
+func (n nopProvider) SendMagicLinkOTP(ctx context.Context, e email.Email) error {
+	n.logger.DebugContext(ctx, "Sending magic link and OTP email.",
+		"email", e,
+	)
+	return nil
+}
 func (n nopProvider) SendOTP(ctx context.Context, e email.Email) error {
 	n.logger.DebugContext(ctx, "Sending OTP email.",
 		"email", e,
@@ -29,6 +29,13 @@ type Storage interface {
 	SACreate(ctx context.Context, args model.ValidServiceAccountCreateParams) (model.ServiceAccount, error)
 	SARead(ctx context.Context, u uuid.UUID) (model.ServiceAccount, error)
 	SAReadFromAPIKey(ctx context.Context, apiKey uuid.UUID) (model.ServiceAccount, error)
+	SAErrorPageRead(ctx context.Context, secret string) (magiclink.ErrorPageBranding, error)
+	MagicLinkResendCreate(ctx context.Context, secret string, request model.MagicLinkEmailCreateRequest) error
+	MagicLinkResendRead(ctx context.Context, secret string) (MagicLinkResend, error)
+	MagicLinkResendTake(ctx context.Context, secret string) (MagicLinkResend, error)
+	MagicLinkOTPLink(ctx context.Context, linkID, otpID string) error
+	OTPResendCreate(ctx context.Context, id string, resend OTPResend) error
+	OTPResendRead(ctx context.Context, id string) (OTPResend, error)
 	SigningKeyRead(ctx context.Context, options ReadSigningKeyOptions) (jwk jwkset.JWK, err error)
 	SigningKeyDefaultRead(ctx context.Context) (jwk jwkset.JWK, err error)
 	SigningKeyDefaultUpdate(ctx context.Context, keyID string) error
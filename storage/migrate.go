@@ -174,6 +174,22 @@ func NewMigrator(pool *pgxpool.Pool, options MigratorOptions) (Migrator, error)
 	migrations := []migration{
 		algMigration{},
 		otpMigration{},
+		visitsMigration{},
+		revokeMigration{},
+		crossDeviceMigration{},
+		responseModeMigration{},
+		pkceMigration{},
+		bindingMigration{},
+		errorPageMigration{},
+		resendMigration{},
+		redirectAllowlistMigration{},
+		stateMigration{},
+		qrCodeMigration{},
+		magicLinkOTPMigration{},
+		totpMigration{},
+		otpJWTMigration{},
+		otpCaseInsensitiveMigration{},
+		otpResendMigration{},
 	}
 
 	m := migrator{
@@ -30,8 +30,8 @@ import (
 const (
 	//language=sql
 	createServiceAccountQuery = `
-INSERT INTO mld.service_account (uuid, api_key, aud, is_admin)
-VALUES ($1, $2, $3, $4)
+INSERT INTO mld.service_account (uuid, api_key, aud, is_admin, error_page, redirect_allowlist)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING id
 `
 )
@@ -140,7 +140,7 @@ func (p postgres) TestingTruncate(ctx context.Context) error {
 
 	//language=sql
 	const query = `
-TRUNCATE TABLE mld.jwk, mld.link, mld.otp, mld.service_account
+TRUNCATE TABLE mld.jwk, mld.link, mld.otp, mld.service_account, mld.totp, mld.totp_recovery
 `
 	_, err := tx.Exec(ctx, query)
 	if err != nil {
@@ -153,14 +153,23 @@ TRUNCATE TABLE mld.jwk, mld.link, mld.otp, mld.service_account
 func (p postgres) SAAdminCreate(ctx context.Context, args model.ValidAdminCreateParams) error {
 	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
 
-	_, err := tx.Exec(ctx, createServiceAccountQuery, args.UUID, args.APIKey, args.Aud, true)
+	errorPage, err := json.Marshal(args.ValidServiceAccountCreateParams.ErrorPage)
+	if err != nil {
+		return fmt.Errorf("failed to marshal error page branding: %w", err)
+	}
+	redirectAllowlist, err := marshalRedirectAllowlist(args.ValidServiceAccountCreateParams.RedirectAllowlist)
+	if err != nil {
+		return fmt.Errorf("failed to marshal redirect allowlist: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, createServiceAccountQuery, args.UUID, args.APIKey, args.Aud, true, errorPage, redirectAllowlist)
 	if err != nil {
 		return fmt.Errorf("failed to create admin service account: %w", err)
 	}
 
 	return nil
 }
-func (p postgres) SACreate(ctx context.Context, _ model.ValidServiceAccountCreateParams) (model.ServiceAccount, error) {
+func (p postgres) SACreate(ctx context.Context, args model.ValidServiceAccountCreateParams) (model.ServiceAccount, error) {
 	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
 
 	apiKey, err := uuid.NewRandom()
@@ -176,16 +185,26 @@ func (p postgres) SACreate(ctx context.Context, _ model.ValidServiceAccountCreat
 		return model.ServiceAccount{}, fmt.Errorf("failed to generate service account UUID: %w", err)
 	}
 
-	_, err = tx.Exec(ctx, createServiceAccountQuery, saUUID, apiKey, aud, false)
+	errorPage, err := json.Marshal(args.ErrorPage)
+	if err != nil {
+		return model.ServiceAccount{}, fmt.Errorf("failed to marshal error page branding: %w", err)
+	}
+	redirectAllowlist, err := marshalRedirectAllowlist(args.RedirectAllowlist)
+	if err != nil {
+		return model.ServiceAccount{}, fmt.Errorf("failed to marshal redirect allowlist: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, createServiceAccountQuery, saUUID, apiKey, aud, false, errorPage, redirectAllowlist)
 	if err != nil {
 		return model.ServiceAccount{}, fmt.Errorf("failed to create service account: %w", err)
 	}
 
 	sa := model.ServiceAccount{
-		UUID:   saUUID,
-		APIKey: apiKey,
-		Aud:    aud,
-		Admin:  false,
+		UUID:              saUUID,
+		APIKey:            apiKey,
+		Aud:               aud,
+		Admin:             false,
+		RedirectAllowlist: args.RedirectAllowlist,
 	}
 
 	return sa, nil
@@ -195,20 +214,25 @@ func (p postgres) SARead(ctx context.Context, u uuid.UUID) (model.ServiceAccount
 
 	//language=sql
 	const queryAud = `
-SELECT api_key, aud, is_admin
+SELECT api_key, aud, is_admin, redirect_allowlist
 FROM mld.service_account
 WHERE uuid = $1
 `
 	sa := model.ServiceAccount{
 		UUID: u,
 	}
-	err := tx.QueryRow(ctx, queryAud, u).Scan(&sa.APIKey, &sa.Aud, &sa.Admin)
+	var redirectAllowlist []byte
+	err := tx.QueryRow(ctx, queryAud, u).Scan(&sa.APIKey, &sa.Aud, &sa.Admin, &redirectAllowlist)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return model.ServiceAccount{}, fmt.Errorf("failed to read service account audiences from Postgres using UUID: %w: %w", err, ErrNotFound)
 		}
 		return model.ServiceAccount{}, fmt.Errorf("failed to read service account audiences from Postgres using UUID: %w", err)
 	}
+	err = json.Unmarshal(redirectAllowlist, &sa.RedirectAllowlist)
+	if err != nil {
+		return model.ServiceAccount{}, fmt.Errorf("failed to unmarshal redirect allowlist: %w", err)
+	}
 
 	return sa, nil
 }
@@ -217,23 +241,60 @@ func (p postgres) SAReadFromAPIKey(ctx context.Context, apiKey uuid.UUID) (model
 
 	//language=sql
 	const queryAud = `
-SELECT uuid, aud, is_admin
+SELECT uuid, aud, is_admin, redirect_allowlist
 FROM mld.service_account
 WHERE api_key = $1
 `
 	sa := model.ServiceAccount{
 		APIKey: apiKey,
 	}
-	err := tx.QueryRow(ctx, queryAud, apiKey).Scan(&sa.UUID, &sa.Aud, &sa.Admin)
+	var redirectAllowlist []byte
+	err := tx.QueryRow(ctx, queryAud, apiKey).Scan(&sa.UUID, &sa.Aud, &sa.Admin, &redirectAllowlist)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return model.ServiceAccount{}, fmt.Errorf("failed to read service account audiences from Postgres using API key: %w: %w", err, ErrNotFound)
 		}
 		return model.ServiceAccount{}, fmt.Errorf("failed to read service account audiences from Postgres using API key: %w", err)
 	}
+	err = json.Unmarshal(redirectAllowlist, &sa.RedirectAllowlist)
+	if err != nil {
+		return model.ServiceAccount{}, fmt.Errorf("failed to unmarshal redirect allowlist: %w", err)
+	}
 
 	return sa, nil
 }
+func (p postgres) SAErrorPageRead(ctx context.Context, secret string) (magiclink.ErrorPageBranding, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	u, err := uuid.Parse(secret)
+	if err != nil {
+		return magiclink.ErrorPageBranding{}, fmt.Errorf("failed to parse UUID: %w", ErrNotFound)
+	}
+
+	//language=sql
+	const query = `
+SELECT sa.error_page
+FROM mld.link
+         JOIN mld.service_account sa ON sa.id = link.sa_id
+WHERE link.secret = $1
+`
+	var raw []byte
+	err = tx.QueryRow(ctx, query, u).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return magiclink.ErrorPageBranding{}, fmt.Errorf("failed to read error page branding from Postgres: %w: %w", err, ErrNotFound)
+		}
+		return magiclink.ErrorPageBranding{}, fmt.Errorf("failed to read error page branding from Postgres: %w", err)
+	}
+
+	var branding magiclink.ErrorPageBranding
+	err = json.Unmarshal(raw, &branding)
+	if err != nil {
+		return magiclink.ErrorPageBranding{}, fmt.Errorf("failed to unmarshal error page branding: %w", err)
+	}
+
+	return branding, nil
+}
 func (p postgres) SigningKeyRead(ctx context.Context, options ReadSigningKeyOptions) (jwk jwkset.JWK, err error) {
 	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
 
@@ -318,18 +379,43 @@ WHERE key_id = $1
   Magic link storage.
 */
 
-func (p postgres) MagicLinkCreate(ctx context.Context, args magiclink.CreateParams) (secret string, err error) {
+func (p postgres) MagicLinkCreate(ctx context.Context, args magiclink.CreateParams) (magiclink.CreateResult, error) {
 	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
 	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
 
 	s, err := uuid.NewRandom()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate random UUID: %w", err)
+		return magiclink.CreateResult{}, fmt.Errorf("failed to generate random UUID: %w", err)
+	}
+	publicID, err := uuid.NewRandom()
+	if err != nil {
+		return magiclink.CreateResult{}, fmt.Errorf("failed to generate random UUID for public ID: %w", err)
+	}
+
+	var crossDeviceToken *uuid.UUID
+	if args.CrossDevice {
+		token, err := uuid.NewRandom()
+		if err != nil {
+			return magiclink.CreateResult{}, fmt.Errorf("failed to generate random UUID for cross-device token: %w", err)
+		}
+		crossDeviceToken = &token
+	}
+	var qrCodeToken *uuid.UUID
+	if args.QRCode {
+		token, err := uuid.NewRandom()
+		if err != nil {
+			return magiclink.CreateResult{}, fmt.Errorf("failed to generate random UUID for QR code token: %w", err)
+		}
+		qrCodeToken = &token
 	}
 
 	claims, err := p.claimsMarshal(args.JWTClaims)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal JWT claims: %w", err)
+		return magiclink.CreateResult{}, fmt.Errorf("failed to marshal JWT claims: %w", err)
+	}
+	state, err := p.stateMarshal(args.State)
+	if err != nil {
+		return magiclink.CreateResult{}, fmt.Errorf("failed to marshal state: %w", err)
 	}
 
 	//language=sql
@@ -337,39 +423,110 @@ func (p postgres) MagicLinkCreate(ctx context.Context, args magiclink.CreatePara
 WITH sa AS (SELECT id FROM mld.service_account WHERE uuid = $1)
 INSERT
 INTO mld.link (expires, jwt_claims, jwt_key_id, jwt_signing_method, redirect_query_key, redirect_url, secret,
-                       sa_id)
-VALUES ($2, $3, $4, $5, $6, $7, $8, (SELECT id FROM sa))
+                       sa_id, max_visits, id_public, cross_device, response_mode, code_challenge, binding_ip,
+                       binding_user_agent, state, state_query_key, qr_code_token, cross_device_token)
+VALUES ($2, $3, $4, $5, $6, $7, $8, (SELECT id FROM sa), $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 `
-	_, err = tx.Exec(ctx, query, sa.UUID, args.Expires, claims, args.JWTKeyID, args.JWTSigningMethod, args.RedirectQueryKey, args.RedirectURL.String(), s)
+	maxVisits := args.MaxVisits
+	if maxVisits == 0 {
+		maxVisits = 1
+	}
+	_, err = tx.Exec(ctx, query, sa.UUID, args.Expires, claims, args.JWTKeyID, args.JWTSigningMethod, args.RedirectQueryKey, args.RedirectURL.String(), s, maxVisits, publicID, args.CrossDevice, args.ResponseMode, args.CodeChallenge, args.Binding.IP, args.Binding.UserAgentFingerprint, state, args.StateQueryKey, qrCodeToken, crossDeviceToken)
 	if err != nil {
-		return "", fmt.Errorf("failed to write magic link to Postgres: %w", err)
+		return magiclink.CreateResult{}, fmt.Errorf("failed to write magic link to Postgres: %w", err)
 	}
 
-	return s.String(), nil
+	result := magiclink.CreateResult{
+		ID:     publicID.String(),
+		Secret: s.String(),
+	}
+	if crossDeviceToken != nil {
+		result.CrossDeviceToken = crossDeviceToken.String()
+	}
+	if qrCodeToken != nil {
+		result.QRCodeToken = qrCodeToken.String()
+	}
+
+	return result, nil
+}
+func (p postgres) MagicLinkPeek(ctx context.Context, secret string) (magiclink.ReadResult, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	u, err := uuid.Parse(secret)
+	if err != nil {
+		return magiclink.ReadResult{}, fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkMalformed)
+	}
+
+	//language=sql
+	const query = `
+SELECT expires, jwt_claims, jwt_key_id, jwt_signing_method, redirect_query_key, redirect_url, max_visits, visited, visits, id_public, revoked, cross_device, response_mode, code_challenge, binding_ip, binding_user_agent, state, state_query_key
+FROM mld.link
+WHERE secret = $1
+`
+	return p.scanLink(tx.QueryRow(ctx, query, u.String()), false)
 }
 func (p postgres) MagicLinkRead(ctx context.Context, secret string) (magiclink.ReadResult, error) {
 	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
-	var response magiclink.ReadResult
 
 	u, err := uuid.Parse(secret)
 	if err != nil {
-		return response, fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+		return magiclink.ReadResult{}, fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkMalformed)
 	}
 
 	//language=sql
 	const query = `
 UPDATE mld.link updated
-SET visited = COALESCE(older.visited, CURRENT_TIMESTAMP)
+SET visited = COALESCE(older.visited, CURRENT_TIMESTAMP),
+    visits  = updated.visits + 1
 FROM mld.link older
 WHERE older.id = updated.id
   AND updated.secret = $1
-RETURNING updated.expires, updated.jwt_claims, updated.jwt_key_id, updated.jwt_signing_method, updated.redirect_query_key, updated.redirect_url, older.visited
+  AND updated.expires > CURRENT_TIMESTAMP
+  AND updated.revoked IS NULL
+  AND (updated.max_visits = -1 OR updated.visits < updated.max_visits)
+RETURNING updated.expires, updated.jwt_claims, updated.jwt_key_id, updated.jwt_signing_method, updated.redirect_query_key, updated.redirect_url, updated.max_visits, updated.visited, updated.visits, updated.id_public, updated.revoked, updated.cross_device, updated.response_mode, updated.code_challenge, updated.binding_ip, updated.binding_user_agent, updated.state, updated.state_query_key
 `
+	result, err := p.scanLink(tx.QueryRow(ctx, query, u.String()), true)
+	if errors.Is(err, magiclink.ErrLinkNotFound) {
+		// Unusable magic links are not updated, so a failed visit is never recorded. Read the magic link without
+		// visiting it to determine why it could not be used.
+		_, err = p.MagicLinkPeek(ctx, secret)
+		if err == nil {
+			err = fmt.Errorf("magic link not usable: %w", magiclink.ErrLinkNotFound)
+		}
+	}
+	if err != nil {
+		return magiclink.ReadResult{}, err
+	}
+
+	// Redeeming the magic link invalidates the OTP sent with it, if any.
+	//language=sql
+	const otpQuery = `
+UPDATE mld.otp
+SET used = CURRENT_TIMESTAMP
+WHERE link_id = (SELECT id FROM mld.link WHERE secret = $1)
+  AND used IS NULL
+`
+	_, err = tx.Exec(ctx, otpQuery, u.String())
+	if err != nil {
+		return magiclink.ReadResult{}, fmt.Errorf("failed to invalidate linked OTP in Postgres: %w", err)
+	}
+
+	return result, nil
+}
+
+// scanLink scans a row of magic link columns into a ReadResult. If visited is true, the row includes the current visit.
+func (p postgres) scanLink(row pgx.Row, visited bool) (magiclink.ReadResult, error) {
+	var response magiclink.ReadResult
 	claims := make([]byte, 0)
 	var args magiclink.CreateParams
-	var visited *time.Time
+	var firstVisit *time.Time
 	var redirectURL string
-	err = tx.QueryRow(ctx, query, u.String()).Scan(&args.Expires, &claims, &args.JWTKeyID, &args.JWTSigningMethod, &args.RedirectQueryKey, &redirectURL, &visited)
+	var visits int
+	var publicID uuid.UUID
+	var revoked *time.Time
+	var state []byte
+	err := row.Scan(&args.Expires, &claims, &args.JWTKeyID, &args.JWTSigningMethod, &args.RedirectQueryKey, &redirectURL, &args.MaxVisits, &firstVisit, &visits, &publicID, &revoked, &args.CrossDevice, &args.ResponseMode, &args.CodeChallenge, &args.Binding.IP, &args.Binding.UserAgentFingerprint, &state, &args.StateQueryKey)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return response, fmt.Errorf("magic link not found: %w", magiclink.ErrLinkNotFound)
@@ -378,11 +535,19 @@ RETURNING updated.expires, updated.jwt_claims, updated.jwt_key_id, updated.jwt_s
 	}
 
 	if args.Expires.Before(time.Now()) {
-		return response, fmt.Errorf("magic link expired: %w", magiclink.ErrLinkNotFound)
+		return response, fmt.Errorf("magic link expired: %w", magiclink.ErrLinkExpired)
+	}
+
+	if revoked != nil {
+		return response, fmt.Errorf("magic link revoked: %w", magiclink.ErrLinkRevoked)
 	}
 
-	if visited != nil {
-		return response, fmt.Errorf("magic link already visited: %w", magiclink.ErrLinkNotFound)
+	priorVisits := visits
+	if visited {
+		priorVisits--
+	}
+	if args.VisitsExhausted(priorVisits) {
+		return response, fmt.Errorf("magic link already visited: %w", magiclink.ErrLinkUsed)
 	}
 
 	args.JWTClaims, err = p.claimsUnmarshal(claims)
@@ -395,10 +560,407 @@ RETURNING updated.expires, updated.jwt_claims, updated.jwt_key_id, updated.jwt_s
 		return response, fmt.Errorf("failed to parse redirect URL from Postgres: %w", err)
 	}
 
+	args.State, err = p.stateUnmarshal(state)
+	if err != nil {
+		return response, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
 	response.CreateParams = args
-	response.Visited = visited
+	response.ID = publicID.String()
+	response.Visited = firstVisit
+	response.Visits = visits
 	return response, nil
 }
+func (p postgres) MagicLinkCodeCreate(ctx context.Context, secret string, expires time.Time) (code string, err error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	s, err := uuid.Parse(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkMalformed)
+	}
+	c, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random UUID for code: %w", err)
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.link
+SET code         = $2,
+    code_expires = $3
+WHERE secret = $1
+`
+	result, err := tx.Exec(ctx, query, s, c, expires)
+	if err != nil {
+		return "", fmt.Errorf("failed to write magic link code to Postgres: %w", err)
+	}
+	if result.RowsAffected() < 1 {
+		return "", fmt.Errorf("magic link not found: %w", magiclink.ErrLinkNotFound)
+	}
+
+	return c.String(), nil
+}
+func (p postgres) MagicLinkCodeTake(ctx context.Context, code string) (secret string, err error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+
+	c, err := uuid.Parse(code)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.link updated
+SET code         = NULL,
+    code_expires = NULL
+FROM mld.link older
+WHERE older.id = updated.id
+  AND updated.code = $1
+  AND updated.sa_id = (SELECT id FROM mld.service_account WHERE uuid = $2)
+RETURNING updated.secret, older.code_expires
+`
+	var s uuid.UUID
+	var expires time.Time
+	err = tx.QueryRow(ctx, query, c, sa.UUID).Scan(&s, &expires)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("magic link code not found: %w", magiclink.ErrLinkNotFound)
+		}
+		return "", fmt.Errorf("failed to take magic link code from Postgres: %w", err)
+	}
+	if expires.Before(time.Now()) {
+		return "", fmt.Errorf("magic link code expired: %w", magiclink.ErrLinkExpired)
+	}
+
+	return s.String(), nil
+}
+func (p postgres) MagicLinkCrossDeviceCollect(ctx context.Context, token string) (magiclink.CrossDeviceResult, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	var result magiclink.CrossDeviceResult
+
+	u, err := uuid.Parse(token)
+	if err != nil {
+		return result, fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+	}
+
+	//language=sql
+	query := `
+UPDATE mld.link updated
+SET cross_device_jwt       = NULL,
+    cross_device_collected = CURRENT_TIMESTAMP
+FROM mld.link older
+WHERE older.id = updated.id
+  AND updated.cross_device_token = $1
+  AND updated.cross_device_jwt IS NOT NULL
+RETURNING older.cross_device_jwt
+`
+	var jwtB64 []byte
+	err = tx.QueryRow(ctx, query, u).Scan(&jwtB64)
+	if err == nil {
+		if !p.plaintextClaims {
+			jwtB64, err = decrypt(p.aes256Key, jwtB64)
+			if err != nil {
+				return result, fmt.Errorf("failed to decrypt cross-device JWT: %w", err)
+			}
+		}
+		result.JWT = string(jwtB64)
+		result.Status = magiclink.CrossDeviceStatusRedeemed
+		return result, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return result, fmt.Errorf("failed to collect cross-device JWT from Postgres: %w", err)
+	}
+
+	//language=sql
+	query = `
+SELECT expires, max_visits, visits, revoked, cross_device_collected
+FROM mld.link
+WHERE cross_device_token = $1
+`
+	var args magiclink.CreateParams
+	var visits int
+	var revoked, collected *time.Time
+	err = tx.QueryRow(ctx, query, u).Scan(&args.Expires, &args.MaxVisits, &visits, &revoked, &collected)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return result, fmt.Errorf("cross-device magic link not found: %w", magiclink.ErrLinkNotFound)
+		}
+		return result, fmt.Errorf("failed to read cross-device magic link from Postgres: %w", err)
+	}
+
+	switch {
+	case revoked != nil || args.VisitsExhausted(visits) || args.Expires.Before(time.Now()):
+		result.Status = magiclink.CrossDeviceStatusExpired
+		if collected != nil {
+			result.Status = magiclink.CrossDeviceStatusCollected
+		}
+	default:
+		result.Status = magiclink.CrossDeviceStatusPending
+	}
+
+	return result, nil
+}
+func (p postgres) MagicLinkCrossDeviceDeliver(ctx context.Context, id, jwtB64 string) error {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+	}
+
+	data := []byte(jwtB64)
+	if !p.plaintextClaims {
+		data, err = p.encrypt(data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt cross-device JWT: %w", err)
+		}
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.link
+SET cross_device_jwt       = $2,
+    cross_device_collected = NULL
+WHERE id_public = $1
+  AND cross_device
+`
+	result, err := tx.Exec(ctx, query, u, data)
+	if err != nil {
+		return fmt.Errorf("failed to deliver cross-device JWT to Postgres: %w", err)
+	}
+	if result.RowsAffected() < 1 {
+		return fmt.Errorf("cross-device magic link not found: %w", magiclink.ErrLinkNotFound)
+	}
+
+	return nil
+}
+func (p postgres) MagicLinkRevoke(ctx context.Context, params magiclink.RevokeParams) error {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+
+	var publicID, secret *uuid.UUID
+	if params.ID != "" {
+		u, err := uuid.Parse(params.ID)
+		if err != nil {
+			return fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+		}
+		publicID = &u
+	} else {
+		u, err := uuid.Parse(params.Secret)
+		if err != nil {
+			return fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+		}
+		secret = &u
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.link
+SET revoked = COALESCE(revoked, CURRENT_TIMESTAMP)
+WHERE sa_id = (SELECT id FROM mld.service_account WHERE uuid = $1)
+  AND (id_public = $2 OR secret = $3)
+`
+	result, err := tx.Exec(ctx, query, sa.UUID, publicID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to revoke magic link in Postgres: %w", err)
+	}
+
+	if result.RowsAffected() < 1 {
+		return fmt.Errorf("magic link not found: %w", magiclink.ErrLinkNotFound)
+	}
+
+	return nil
+}
+func (p postgres) MagicLinkQRCodeRead(ctx context.Context, token string) (secret string, err error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	u, err := uuid.Parse(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+	}
+
+	//language=sql
+	const query = `
+SELECT secret, expires, max_visits, visits, revoked
+FROM mld.link
+WHERE qr_code_token = $1
+`
+	var s uuid.UUID
+	var args magiclink.CreateParams
+	var visits int
+	var revoked *time.Time
+	err = tx.QueryRow(ctx, query, u).Scan(&s, &args.Expires, &args.MaxVisits, &visits, &revoked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("magic link not found: %w", magiclink.ErrLinkNotFound)
+		}
+		return "", fmt.Errorf("failed to read magic link secret from Postgres: %w", err)
+	}
+
+	switch {
+	case args.Expires.Before(time.Now()):
+		return "", fmt.Errorf("magic link expired: %w", magiclink.ErrLinkExpired)
+	case revoked != nil:
+		return "", fmt.Errorf("magic link revoked: %w", magiclink.ErrLinkRevoked)
+	case args.VisitsExhausted(visits):
+		return "", fmt.Errorf("magic link already visited: %w", magiclink.ErrLinkUsed)
+	}
+
+	return s.String(), nil
+}
+func (p postgres) MagicLinkOTPLink(ctx context.Context, linkID, otpID string) error {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	linkUUID, err := uuid.Parse(linkID)
+	if err != nil {
+		return fmt.Errorf("failed to parse magic link UUID: %w", err)
+	}
+	otpUUID, err := uuid.Parse(otpID)
+	if err != nil {
+		return fmt.Errorf("failed to parse OTP UUID: %w", err)
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.otp
+SET link_id = (SELECT id FROM mld.link WHERE id_public = $1)
+WHERE id_public = $2
+`
+	result, err := tx.Exec(ctx, query, linkUUID, otpUUID)
+	if err != nil {
+		return fmt.Errorf("failed to link OTP to magic link in Postgres: %w", err)
+	}
+	if result.RowsAffected() < 1 {
+		return fmt.Errorf("OTP not found: %w", ErrNotFound)
+	}
+
+	return nil
+}
+func (p postgres) MagicLinkStatus(ctx context.Context, id string) (magiclink.StatusResult, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+	var result magiclink.StatusResult
+
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return result, fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+	}
+
+	//language=sql
+	const query = `
+SELECT created, expires, max_visits, revoked, visited, visits
+FROM mld.link
+WHERE sa_id = (SELECT id FROM mld.service_account WHERE uuid = $1)
+  AND id_public = $2
+`
+	err = tx.QueryRow(ctx, query, sa.UUID, u).Scan(&result.Created, &result.Expires, &result.MaxVisits, &result.Revoked, &result.Visited, &result.Visits)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return result, fmt.Errorf("magic link not found: %w", magiclink.ErrLinkNotFound)
+		}
+		return result, fmt.Errorf("failed to read magic link status from Postgres: %w", err)
+	}
+	result.ID = u.String()
+
+	return result, nil
+}
+func (p postgres) MagicLinkResendCreate(ctx context.Context, secret string, request model.MagicLinkEmailCreateRequest) error {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	u, err := uuid.Parse(secret)
+	if err != nil {
+		return fmt.Errorf("failed to parse UUID: %w", magiclink.ErrLinkNotFound)
+	}
+
+	resend, err := p.resendMarshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal resend request: %w", err)
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.link
+SET resend = $2
+WHERE secret = $1
+`
+	result, err := tx.Exec(ctx, query, u, resend)
+	if err != nil {
+		return fmt.Errorf("failed to write magic link resend request to Postgres: %w", err)
+	}
+
+	if result.RowsAffected() < 1 {
+		return fmt.Errorf("magic link not found: %w", magiclink.ErrLinkNotFound)
+	}
+
+	return nil
+}
+func (p postgres) MagicLinkResendRead(ctx context.Context, secret string) (MagicLinkResend, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	u, err := uuid.Parse(secret)
+	if err != nil {
+		return MagicLinkResend{}, fmt.Errorf("failed to parse UUID: %w", ErrNotFound)
+	}
+
+	//language=sql
+	const query = `
+SELECT link.resend, sa.uuid
+FROM mld.link
+         JOIN mld.service_account sa ON sa.id = link.sa_id
+WHERE link.secret = $1
+  AND link.resend IS NOT NULL
+  AND link.expires < CURRENT_TIMESTAMP
+  AND link.revoked IS NULL
+  AND (link.max_visits = -1 OR link.visits < link.max_visits)
+`
+	return p.scanResend(tx.QueryRow(ctx, query, u))
+}
+func (p postgres) MagicLinkResendTake(ctx context.Context, secret string) (MagicLinkResend, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	u, err := uuid.Parse(secret)
+	if err != nil {
+		return MagicLinkResend{}, fmt.Errorf("failed to parse UUID: %w", ErrNotFound)
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.link updated
+SET resend = NULL
+FROM mld.link older
+         JOIN mld.service_account sa ON sa.id = older.sa_id
+WHERE older.id = updated.id
+  AND updated.secret = $1
+  AND updated.resend IS NOT NULL
+  AND updated.expires < CURRENT_TIMESTAMP
+  AND updated.revoked IS NULL
+  AND (updated.max_visits = -1 OR updated.visits < updated.max_visits)
+RETURNING older.resend, sa.uuid
+`
+	return p.scanResend(tx.QueryRow(ctx, query, u))
+}
+func (p postgres) scanResend(row pgx.Row) (MagicLinkResend, error) {
+	var raw []byte
+	var saUUID uuid.UUID
+	err := row.Scan(&raw, &saUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return MagicLinkResend{}, fmt.Errorf("failed to read magic link resend request from Postgres: %w: %w", err, ErrNotFound)
+		}
+		return MagicLinkResend{}, fmt.Errorf("failed to read magic link resend request from Postgres: %w", err)
+	}
+
+	request, err := p.resendUnmarshal(raw)
+	if err != nil {
+		return MagicLinkResend{}, fmt.Errorf("failed to unmarshal resend request: %w", err)
+	}
+
+	return MagicLinkResend{
+		Request: request,
+		SAUUID:  saUUID,
+	}, nil
+}
 
 /*
 OTP Storage
@@ -413,14 +975,22 @@ func (p postgres) OTPCreate(ctx context.Context, params otp.CreateParams) (otp.C
 		return otp.CreateResult{}, fmt.Errorf("failed to generate OTP: %w", err)
 	}
 
+	var claims []byte
+	if params.JWTClaims != nil {
+		claims, err = p.claimsMarshal(params.JWTClaims)
+		if err != nil {
+			return otp.CreateResult{}, fmt.Errorf("failed to marshal JWT claims: %w", err)
+		}
+	}
+
 	publicID := uuid.New()
 
 	//language=sql
 	const query = `
 WITH sa AS (SELECT id FROM mld.service_account WHERE uuid = $1)
-INSERT INTO mld.otp (sa_id, expires, id_public, otp) VALUES ((SELECT id FROM sa), $2, $3, $4)
+INSERT INTO mld.otp (sa_id, expires, id_public, otp, jwt_claims, jwt_key_id, case_insensitive) VALUES ((SELECT id FROM sa), $2, $3, $4, $5, $6, $7)
 `
-	_, err = tx.Exec(ctx, query, sa.UUID, params.Expires, publicID, o)
+	_, err = tx.Exec(ctx, query, sa.UUID, params.Expires, publicID, o, claims, params.JWTKeyID, params.CaseInsensitive)
 	if err != nil {
 		return otp.CreateResult{}, fmt.Errorf("failed to write OTP to Postgres: %w", err)
 	}
@@ -433,12 +1003,13 @@ INSERT INTO mld.otp (sa_id, expires, id_public, otp) VALUES ((SELECT id FROM sa)
 
 	return results, nil
 }
-func (p postgres) OTPValidate(ctx context.Context, id, o string) error {
+func (p postgres) OTPValidate(ctx context.Context, id, o string) (otp.ValidateResult, error) {
 	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
 
 	u, err := uuid.Parse(id)
 	if err != nil {
-		return fmt.Errorf("failed to parse UUID: %w", otp.ErrOTPInvalid)
+		return otp.ValidateResult{}, fmt.Errorf("failed to parse UUID: %w", otp.ErrOTPInvalid)
 	}
 
 	//language=sql
@@ -446,17 +1017,279 @@ func (p postgres) OTPValidate(ctx context.Context, id, o string) error {
 UPDATE mld.otp updated
 SET used = CURRENT_TIMESTAMP
 WHERE updated.id_public = $1
-  AND updated.otp = $2
+  AND updated.sa_id = (SELECT id FROM mld.service_account WHERE uuid = $3)
+  AND (updated.otp = $2 OR (updated.case_insensitive AND lower(updated.otp) = lower($2)))
   AND updated.expires > CURRENT_TIMESTAMP
   AND updated.used IS NULL
+RETURNING updated.expires, updated.jwt_claims, updated.jwt_key_id, updated.case_insensitive
 `
-	result, err := tx.Exec(ctx, query, u, o)
+	var params otp.CreateParams
+	var claims []byte
+	err = tx.QueryRow(ctx, query, u, o, sa.UUID).Scan(&params.Expires, &claims, &params.JWTKeyID, &params.CaseInsensitive)
 	if err != nil {
-		return fmt.Errorf("failed to query database: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return otp.ValidateResult{}, fmt.Errorf("no rows were updated: %w", otp.ErrOTPInvalid)
+		}
+		return otp.ValidateResult{}, fmt.Errorf("failed to query database: %w", err)
+	}
+
+	if claims != nil {
+		params.JWTClaims, err = p.claimsUnmarshal(claims)
+		if err != nil {
+			return otp.ValidateResult{}, fmt.Errorf("failed to unmarshal JWT claims: %w", err)
+		}
+	}
+
+	// Validating the OTP invalidates the magic link sent with it, if any.
+	//language=sql
+	const linkQuery = `
+WITH sa AS (SELECT id FROM mld.service_account WHERE uuid = $2)
+UPDATE mld.link
+SET revoked = CURRENT_TIMESTAMP
+WHERE id = (SELECT link_id FROM mld.otp WHERE id_public = $1 AND sa_id = (SELECT id FROM sa))
+  AND sa_id = (SELECT id FROM sa)
+  AND revoked IS NULL
+`
+	_, err = tx.Exec(ctx, linkQuery, u, sa.UUID)
+	if err != nil {
+		return otp.ValidateResult{}, fmt.Errorf("failed to revoke linked magic link in Postgres: %w", err)
+	}
+
+	result := otp.ValidateResult{
+		CreateParams: params,
+		ID:           u.String(),
+	}
+
+	return result, nil
+}
+func (p postgres) OTPRegenerate(ctx context.Context, id string, params otp.CreateParams) (otp.CreateResult, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return otp.CreateResult{}, fmt.Errorf("failed to parse UUID: %w", otp.ErrOTPInvalid)
+	}
+
+	o, err := otp.Generate(params)
+	if err != nil {
+		return otp.CreateResult{}, fmt.Errorf("failed to generate OTP: %w", err)
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.otp
+SET otp          = $2,
+    resend_count = resend_count + 1,
+    sent         = CURRENT_TIMESTAMP
+WHERE id_public = $1
+  AND sa_id = (SELECT id FROM mld.service_account WHERE uuid = $3)
+  AND expires > CURRENT_TIMESTAMP
+  AND used IS NULL
+RETURNING expires
+`
+	err = tx.QueryRow(ctx, query, u, o, sa.UUID).Scan(&params.Expires)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return otp.CreateResult{}, fmt.Errorf("no rows were updated: %w", otp.ErrOTPInvalid)
+		}
+		return otp.CreateResult{}, fmt.Errorf("failed to write regenerated OTP to Postgres: %w", err)
+	}
+
+	result := otp.CreateResult{
+		CreateParams: params,
+		ID:           u.String(),
+		OTP:          o,
+	}
+
+	return result, nil
+}
+func (p postgres) OTPResendCreate(ctx context.Context, id string, resend OTPResend) error {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("failed to parse UUID: %w", ErrNotFound)
+	}
+
+	raw, err := p.otpResendMarshal(resend)
+	if err != nil {
+		return fmt.Errorf("failed to marshal OTP resend request: %w", err)
+	}
+
+	//language=sql
+	const query = `
+UPDATE mld.otp
+SET resend = $2
+WHERE id_public = $1
+`
+	result, err := tx.Exec(ctx, query, u, raw)
+	if err != nil {
+		return fmt.Errorf("failed to write OTP resend request to Postgres: %w", err)
 	}
 
 	if result.RowsAffected() < 1 {
-		return fmt.Errorf("no rows were updated: %w", otp.ErrOTPInvalid)
+		return fmt.Errorf("OTP not found: %w", ErrNotFound)
+	}
+
+	return nil
+}
+func (p postgres) OTPResendRead(ctx context.Context, id string) (OTPResend, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return OTPResend{}, fmt.Errorf("failed to parse UUID: %w", ErrNotFound)
+	}
+
+	//language=sql
+	const query = `
+SELECT otp.resend, otp.resend_count, otp.sent
+FROM mld.otp
+         JOIN mld.service_account sa ON sa.id = otp.sa_id
+WHERE otp.id_public = $1
+  AND sa.uuid = $2
+  AND otp.resend IS NOT NULL
+  AND otp.expires > CURRENT_TIMESTAMP
+  AND otp.used IS NULL
+FOR UPDATE OF otp
+`
+	var raw []byte
+	var resendCount int
+	var sent time.Time
+	err = tx.QueryRow(ctx, query, u, sa.UUID).Scan(&raw, &resendCount, &sent)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return OTPResend{}, fmt.Errorf("failed to read OTP resend request from Postgres: %w: %w", err, ErrNotFound)
+		}
+		return OTPResend{}, fmt.Errorf("failed to read OTP resend request from Postgres: %w", err)
+	}
+
+	resend, err := p.otpResendUnmarshal(raw)
+	if err != nil {
+		return OTPResend{}, fmt.Errorf("failed to unmarshal OTP resend request: %w", err)
+	}
+	resend.ResendCount = uint(resendCount)
+	resend.Sent = sent
+
+	return resend, nil
+}
+func (p postgres) TOTPCreate(ctx context.Context, params otp.TOTPCreateParams) (otp.TOTPCreateResult, error) {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+
+	secret, err := otp.GenerateTOTPSecret()
+	if err != nil {
+		return otp.TOTPCreateResult{}, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	recoveryCodes, err := otp.GenerateTOTPRecoveryCodes(params.RecoveryCodes)
+	if err != nil {
+		return otp.TOTPCreateResult{}, fmt.Errorf("failed to generate TOTP recovery codes: %w", err)
+	}
+	stored, err := p.totpSecretMarshal(secret)
+	if err != nil {
+		return otp.TOTPCreateResult{}, fmt.Errorf("failed to marshal TOTP secret: %w", err)
+	}
+
+	publicID := uuid.New()
+
+	//language=sql
+	const query = `
+WITH sa AS (SELECT id FROM mld.service_account WHERE uuid = $1)
+INSERT INTO mld.totp (sa_id, id_public, secret) VALUES ((SELECT id FROM sa), $2, $3)
+RETURNING id
+`
+	var totpID int64
+	err = tx.QueryRow(ctx, query, sa.UUID, publicID, stored).Scan(&totpID)
+	if err != nil {
+		return otp.TOTPCreateResult{}, fmt.Errorf("failed to write TOTP to Postgres: %w", err)
+	}
+
+	//language=sql
+	const recoveryQuery = `
+INSERT INTO mld.totp_recovery (totp_id, code_hash) VALUES ($1, $2)
+`
+	for _, code := range recoveryCodes {
+		_, err = tx.Exec(ctx, recoveryQuery, totpID, otp.HashTOTPRecoveryCode(code))
+		if err != nil {
+			return otp.TOTPCreateResult{}, fmt.Errorf("failed to write TOTP recovery code to Postgres: %w", err)
+		}
+	}
+
+	result := otp.TOTPCreateResult{
+		CreateParams:  params,
+		ID:            publicID.String(),
+		RecoveryCodes: recoveryCodes,
+		Secret:        secret,
+	}
+
+	return result, nil
+}
+func (p postgres) TOTPValidate(ctx context.Context, params otp.TOTPValidateParams) error {
+	tx := ctx.Value(ctxkey.Tx).(*Transaction).Tx
+	sa := ctx.Value(ctxkey.ServiceAccount).(model.ServiceAccount)
+
+	u, err := uuid.Parse(params.ID)
+	if err != nil {
+		return fmt.Errorf("failed to parse UUID: %w", otp.ErrOTPInvalid)
+	}
+
+	if params.RecoveryCode != "" {
+		//language=sql
+		const query = `
+UPDATE mld.totp_recovery
+SET used = CURRENT_TIMESTAMP
+WHERE totp_id = (SELECT id FROM mld.totp WHERE id_public = $1 AND sa_id = (SELECT id FROM mld.service_account WHERE uuid = $2))
+  AND code_hash = $3
+  AND used IS NULL
+`
+		result, err := tx.Exec(ctx, query, u, sa.UUID, otp.HashTOTPRecoveryCode(params.RecoveryCode))
+		if err != nil {
+			return fmt.Errorf("failed to use TOTP recovery code in Postgres: %w", err)
+		}
+		if result.RowsAffected() < 1 {
+			return fmt.Errorf("no rows were updated: %w", otp.ErrOTPInvalid)
+		}
+		return nil
+	}
+
+	//language=sql
+	const query = `
+SELECT secret, last_counter
+FROM mld.totp
+WHERE id_public = $1
+  AND sa_id = (SELECT id FROM mld.service_account WHERE uuid = $2)
+FOR UPDATE
+`
+	var stored []byte
+	var lastCounter int64
+	err = tx.QueryRow(ctx, query, u, sa.UUID).Scan(&stored, &lastCounter)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("TOTP not found: %w", otp.ErrOTPInvalid)
+		}
+		return fmt.Errorf("failed to read TOTP from Postgres: %w", err)
+	}
+	secret, err := p.totpSecretUnmarshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal TOTP secret: %w", err)
+	}
+
+	counter, ok := otp.TOTPMatch(secret, params.Code, time.Now(), params.Window, lastCounter)
+	if !ok {
+		return fmt.Errorf("TOTP code did not match or was already used: %w", otp.ErrOTPInvalid)
+	}
+
+	//language=sql
+	const updateQuery = `
+UPDATE mld.totp
+SET last_counter = $2
+WHERE id_public = $1
+`
+	_, err = tx.Exec(ctx, updateQuery, u, counter)
+	if err != nil {
+		return fmt.Errorf("failed to update TOTP counter in Postgres: %w", err)
 	}
 
 	return nil
@@ -681,6 +1514,117 @@ func (p postgres) claimsUnmarshal(data []byte) (magiclinksdev.SigningBytesClaims
 	}
 	return claims, nil
 }
+func (p postgres) stateMarshal(state string) ([]byte, error) {
+	if state == "" {
+		return nil, nil
+	}
+	data := []byte(state)
+	if !p.plaintextClaims {
+		var err error
+		data, err = p.encrypt(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt state: %w", err)
+		}
+	}
+	return data, nil
+}
+func (p postgres) stateUnmarshal(data []byte) (string, error) {
+	if data == nil {
+		return "", nil
+	}
+	var err error
+	if !p.plaintextClaims {
+		data, err = decrypt(p.aes256Key, data)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt state: %w", err)
+		}
+	}
+	return string(data), nil
+}
+
+// totpSecretMarshal prepares a TOTP secret for storage. TOTP secrets are keys, so they follow the plaintext JWK setting.
+func (p postgres) totpSecretMarshal(secret []byte) ([]byte, error) {
+	if p.plaintextJWK {
+		return secret, nil
+	}
+	data, err := p.encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+	return data, nil
+}
+func (p postgres) totpSecretUnmarshal(data []byte) ([]byte, error) {
+	if p.plaintextJWK {
+		return data, nil
+	}
+	secret, err := decrypt(p.aes256Key, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	return secret, nil
+}
+func marshalRedirectAllowlist(redirectAllowlist []model.RedirectAllowlistEntry) ([]byte, error) {
+	if redirectAllowlist == nil {
+		redirectAllowlist = []model.RedirectAllowlistEntry{}
+	}
+	return json.Marshal(redirectAllowlist)
+}
+func (p postgres) resendMarshal(request model.MagicLinkEmailCreateRequest) ([]byte, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to JSON marshal resend request: %w", err)
+	}
+	if !p.plaintextClaims {
+		data, err = p.encrypt(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt resend request: %w", err)
+		}
+	}
+	return data, nil
+}
+func (p postgres) resendUnmarshal(data []byte) (model.MagicLinkEmailCreateRequest, error) {
+	var err error
+	if !p.plaintextClaims {
+		data, err = decrypt(p.aes256Key, data)
+		if err != nil {
+			return model.MagicLinkEmailCreateRequest{}, fmt.Errorf("failed to decrypt resend request: %w", err)
+		}
+	}
+	var request model.MagicLinkEmailCreateRequest
+	err = json.Unmarshal(data, &request)
+	if err != nil {
+		return model.MagicLinkEmailCreateRequest{}, fmt.Errorf("failed to JSON unmarshal resend request: %w", err)
+	}
+	return request, nil
+}
+func (p postgres) otpResendMarshal(resend OTPResend) ([]byte, error) {
+	data, err := json.Marshal(resend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to JSON marshal OTP resend request: %w", err)
+	}
+	if !p.plaintextClaims {
+		data, err = p.encrypt(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt OTP resend request: %w", err)
+		}
+	}
+	return data, nil
+}
+func (p postgres) otpResendUnmarshal(data []byte) (OTPResend, error) {
+	var err error
+	if !p.plaintextClaims {
+		data, err = decrypt(p.aes256Key, data)
+		if err != nil {
+			return OTPResend{}, fmt.Errorf("failed to decrypt OTP resend request: %w", err)
+		}
+	}
+	var resend OTPResend
+	err = json.Unmarshal(data, &resend)
+	if err != nil {
+		return OTPResend{}, fmt.Errorf("failed to JSON unmarshal OTP resend request: %w", err)
+	}
+	return resend, nil
+}
 func (p postgres) jwkMarshalAssets(jwk jwkset.JWK) ([]byte, error) {
 	assets, err := json.Marshal(jwk.Marshal())
 	if err != nil {
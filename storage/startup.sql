@@ -14,17 +14,19 @@ INSERT INTO mld.setup (setup)
 VALUES ('{
   "plaintextClaims": false,
   "plaintextJWK": false,
-  "semver": "v0.2.0"
+  "semver": "v0.18.0"
 }');
 
 CREATE TABLE mld.service_account
 (
-    id       BIGSERIAL PRIMARY KEY,
-    uuid     UUID                     NOT NULL UNIQUE,
-    api_key  UUID                     NOT NULL UNIQUE,
-    aud      UUID                     NOT NULL UNIQUE,
-    is_admin BOOLEAN                  NOT NULL DEFAULT FALSE,
-    created  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+    id                 BIGSERIAL PRIMARY KEY,
+    uuid               UUID                     NOT NULL UNIQUE,
+    api_key            UUID                     NOT NULL UNIQUE,
+    aud                UUID                     NOT NULL UNIQUE,
+    is_admin           BOOLEAN                  NOT NULL DEFAULT FALSE,
+    created            TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    error_page         JSONB                    NOT NULL DEFAULT '{}',
+    redirect_allowlist JSONB                    NOT NULL DEFAULT '[]'
 );
 CREATE INDEX ON mld.service_account (uuid);
 CREATE INDEX ON mld.service_account (api_key);
@@ -46,17 +48,35 @@ CREATE INDEX ON mld.jwk (alg);
 
 CREATE TABLE mld.link
 (
-    id                 BIGSERIAL PRIMARY KEY,
-    sa_id              BIGINT                   NOT NULL REFERENCES mld.service_account (id),
-    expires            TIMESTAMP WITH TIME ZONE NOT NULL,
-    jwt_claims         BYTEA                    NOT NULL,
-    jwt_key_id         TEXT                     NOT NULL,
-    jwt_signing_method TEXT                     NOT NULL,
-    redirect_query_key TEXT                     NOT NULL,
-    redirect_url       TEXT                     NOT NULL,
-    secret             UUID                     NOT NULL UNIQUE,
-    visited            TIMESTAMP WITH TIME ZONE,
-    created            TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+    id                     BIGSERIAL PRIMARY KEY,
+    sa_id                  BIGINT                   NOT NULL REFERENCES mld.service_account (id),
+    expires                TIMESTAMP WITH TIME ZONE NOT NULL,
+    jwt_claims             BYTEA                    NOT NULL,
+    jwt_key_id             TEXT                     NOT NULL,
+    jwt_signing_method     TEXT                     NOT NULL,
+    redirect_query_key     TEXT                     NOT NULL,
+    redirect_url           TEXT                     NOT NULL,
+    secret                 UUID                     NOT NULL UNIQUE,
+    visited                TIMESTAMP WITH TIME ZONE,
+    created                TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    max_visits             INT                      NOT NULL DEFAULT 1,
+    visits                 INT                      NOT NULL DEFAULT 0,
+    id_public              UUID                     NOT NULL UNIQUE,
+    revoked                TIMESTAMP WITH TIME ZONE,
+    cross_device           BOOLEAN                  NOT NULL DEFAULT FALSE,
+    cross_device_jwt       BYTEA,
+    cross_device_collected TIMESTAMP WITH TIME ZONE,
+    cross_device_token     UUID UNIQUE,
+    response_mode          TEXT                     NOT NULL DEFAULT '',
+    code_challenge         TEXT                     NOT NULL DEFAULT '',
+    code                   UUID UNIQUE,
+    code_expires           TIMESTAMP WITH TIME ZONE,
+    binding_ip             TEXT                     NOT NULL DEFAULT '',
+    binding_user_agent     TEXT                     NOT NULL DEFAULT '',
+    resend                 BYTEA,
+    state                  BYTEA,
+    state_query_key        TEXT                     NOT NULL DEFAULT '',
+    qr_code_token          UUID UNIQUE
 );
 CREATE INDEX ON mld.link (expires);
 CREATE INDEX ON mld.link (redirect_url);
@@ -64,19 +84,53 @@ CREATE INDEX ON mld.link (secret);
 CREATE INDEX ON mld.link (sa_id);
 CREATE INDEX ON mld.link (visited);
 CREATE INDEX ON mld.link (created);
+CREATE INDEX ON mld.link (id_public);
+CREATE INDEX ON mld.link (revoked);
 
 CREATE TABLE mld.otp
 (
-    id        BIGSERIAL PRIMARY KEY,
-    sa_id     BIGINT                   NOT NULL REFERENCES mld.service_account (id),
-    expires   TIMESTAMP WITH TIME ZONE NOT NULL,
-    id_public UUID                     NOT NULL UNIQUE,
-    otp       TEXT                     NOT NULL,
-    used      TIMESTAMP WITH TIME ZONE,
-    created   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+    id               BIGSERIAL PRIMARY KEY,
+    sa_id            BIGINT                   NOT NULL REFERENCES mld.service_account (id),
+    expires          TIMESTAMP WITH TIME ZONE NOT NULL,
+    id_public        UUID                     NOT NULL UNIQUE,
+    otp              TEXT                     NOT NULL,
+    used             TIMESTAMP WITH TIME ZONE,
+    created          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    link_id          BIGINT REFERENCES mld.link (id),
+    jwt_claims       BYTEA,
+    jwt_key_id       TEXT,
+    case_insensitive BOOLEAN                  NOT NULL DEFAULT FALSE,
+    resend           BYTEA,
+    resend_count     INT                      NOT NULL DEFAULT 0,
+    sent             TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 CREATE INDEX ON mld.otp (sa_id);
 CREATE INDEX ON mld.otp (expires);
 CREATE INDEX ON mld.otp (id_public);
 CREATE INDEX ON mld.otp (used);
 CREATE INDEX ON mld.otp (created);
+CREATE INDEX ON mld.otp (link_id);
+
+CREATE TABLE mld.totp
+(
+    id           BIGSERIAL PRIMARY KEY,
+    sa_id        BIGINT                   NOT NULL REFERENCES mld.service_account (id),
+    id_public    UUID                     NOT NULL UNIQUE,
+    secret       BYTEA                    NOT NULL,
+    last_counter BIGINT                   NOT NULL DEFAULT -1,
+    created      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX ON mld.totp (sa_id);
+CREATE INDEX ON mld.totp (id_public);
+CREATE INDEX ON mld.totp (created);
+
+CREATE TABLE mld.totp_recovery
+(
+    id        BIGSERIAL PRIMARY KEY,
+    totp_id   BIGINT                   NOT NULL REFERENCES mld.totp (id),
+    code_hash BYTEA                    NOT NULL,
+    used      TIMESTAMP WITH TIME ZONE,
+    created   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX ON mld.totp_recovery (totp_id);
+CREATE INDEX ON mld.totp_recovery (code_hash);
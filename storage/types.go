@@ -1,6 +1,29 @@
 package storage
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MicahParks/magiclinksdev/model"
+)
+
 // ReadSigningKeyOptions are the options for the SigningKeyRead method.
 type ReadSigningKeyOptions struct {
 	JWTAlg string
 }
+
+// MagicLinkResend is the information needed to resend the email for an expired magic link.
+type MagicLinkResend struct {
+	Request model.MagicLinkEmailCreateRequest
+	SAUUID  uuid.UUID
+}
+
+// OTPResend is the information needed to resend an OTP under the same ID. OTPEmailCreateParams is nil if the OTP was not
+// sent by email.
+type OTPResend struct {
+	OTPCreateParams      model.OTPCreateParams       `json:"otpCreateParams"`
+	OTPEmailCreateParams *model.OTPEmailCreateParams `json:"otpEmailCreateParams,omitempty"`
+	ResendCount          uint                        `json:"-"`
+	Sent                 time.Time                   `json:"-"`
+}
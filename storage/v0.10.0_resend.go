@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// resendMigration is the migration from database version v0.9.0 to v0.10.0.
+type resendMigration struct{}
+
+func (r resendMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.9.0 to v0.10.0. This is the tenth database migration. It adds a column to the "mld.link" table to remember the email parameters used to send a magic link, so the email can be resent once the magic link expires.`,
+		Filename:    "v0.10.0_resend.go",
+		SemVer:      "v0.10.0",
+	}
+}
+
+func (r resendMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(r.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN resend BYTEA
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", r.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "resend" column to "mld.link" table.`)
+
+	return true, nil
+}
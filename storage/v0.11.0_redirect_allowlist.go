@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// redirectAllowlistMigration is the migration from database version v0.10.0 to v0.11.0.
+type redirectAllowlistMigration struct{}
+
+func (r redirectAllowlistMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.10.0 to v0.11.0. This is the eleventh database migration. It adds a column to the "mld.service_account" table for the custom URL schemes and hosts its magic links may redirect to.`,
+		Filename:    "v0.11.0_redirect_allowlist.go",
+		SemVer:      "v0.11.0",
+	}
+}
+
+func (r redirectAllowlistMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(r.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.service_account
+    ADD COLUMN redirect_allowlist JSONB NOT NULL DEFAULT '[]'
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", r.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "redirect_allowlist" column to "mld.service_account" table.`)
+
+	return true, nil
+}
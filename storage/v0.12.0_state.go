@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// stateMigration is the migration from database version v0.11.0 to v0.12.0.
+type stateMigration struct{}
+
+func (s stateMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.11.0 to v0.12.0. This is the twelfth database migration. It adds columns to the "mld.link" table for an opaque state value that is delivered to the redirect URL alongside the JWT.`,
+		Filename:    "v0.12.0_state.go",
+		SemVer:      "v0.12.0",
+	}
+}
+
+func (s stateMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(s.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN state           BYTEA,
+    ADD COLUMN state_query_key TEXT NOT NULL DEFAULT ''
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", s.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "state" and "state_query_key" columns to "mld.link" table.`)
+
+	return true, nil
+}
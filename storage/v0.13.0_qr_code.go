@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// qrCodeMigration is the migration from database version v0.12.0 to v0.13.0.
+type qrCodeMigration struct{}
+
+func (q qrCodeMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.12.0 to v0.13.0. This is the thirteenth database migration. It adds a column to the "mld.link" table for a secret token that identifies the QR code image of a magic link, so the QR code can be served without the magic link's secret or ID.`,
+		Filename:    "v0.13.0_qr_code.go",
+		SemVer:      "v0.13.0",
+	}
+}
+
+func (q qrCodeMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(q.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN qr_code_token UUID UNIQUE
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", q.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "qr_code_token" column to "mld.link" table.`)
+
+	return true, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// magicLinkOTPMigration is the migration from database version v0.13.0 to v0.14.0.
+type magicLinkOTPMigration struct{}
+
+func (m magicLinkOTPMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.13.0 to v0.14.0. This is the fourteenth database migration. It adds a column to the "mld.otp" table to link an OTP to the magic link it was sent with, so redeeming either invalidates the other.`,
+		Filename:    "v0.14.0_magic_link_otp.go",
+		SemVer:      "v0.14.0",
+	}
+}
+
+func (m magicLinkOTPMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(m.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.otp
+    ADD COLUMN link_id BIGINT REFERENCES mld.link (id)
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", m.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "link_id" column to "mld.otp" table.`)
+
+	//language=sql
+	query = `
+CREATE INDEX ON mld.otp (link_id)
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to create index for %q query: %w", m.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Created index on "link_id" column of "mld.otp" table.`)
+
+	return true, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// totpMigration is the migration from database version v0.14.0 to v0.15.0.
+type totpMigration struct{}
+
+func (t totpMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.14.0 to v0.15.0. This is the fifteenth database migration. It adds the "mld.totp" and "mld.totp_recovery" tables for authenticator app enrollment.`,
+		Filename:    "v0.15.0_totp.go",
+		SemVer:      "v0.15.0",
+	}
+}
+
+func (t totpMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(t.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+CREATE TABLE mld.totp
+(
+    id           BIGSERIAL PRIMARY KEY,
+    sa_id        BIGINT                   NOT NULL REFERENCES mld.service_account (id),
+    id_public    UUID                     NOT NULL UNIQUE,
+    secret       BYTEA                    NOT NULL,
+    last_counter BIGINT                   NOT NULL DEFAULT -1,
+    created      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+)
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to create new table for %q query: %w", t.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "mld.totp" table.`)
+
+	//language=sql
+	query = `
+CREATE TABLE mld.totp_recovery
+(
+    id        BIGSERIAL PRIMARY KEY,
+    totp_id   BIGINT                   NOT NULL REFERENCES mld.totp (id),
+    code_hash BYTEA                    NOT NULL,
+    used      TIMESTAMP WITH TIME ZONE,
+    created   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+)
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to create new table for %q query: %w", t.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "mld.totp_recovery" table.`)
+
+	indexes := []string{
+		"CREATE INDEX ON mld.totp (sa_id)",
+		"CREATE INDEX ON mld.totp (id_public)",
+		"CREATE INDEX ON mld.totp (created)",
+		"CREATE INDEX ON mld.totp_recovery (totp_id)",
+		"CREATE INDEX ON mld.totp_recovery (code_hash)",
+	}
+	for _, index := range indexes {
+		_, err = tx.Exec(ctx, index)
+		if err != nil {
+			return false, fmt.Errorf("failed to create index for %q: %q, %w", t.metadata().Filename, index, err)
+		}
+		options.Logger.DebugContext(ctx, fmt.Sprintf(`Created index on TOTP table: %q.`, index))
+	}
+
+	return true, nil
+}
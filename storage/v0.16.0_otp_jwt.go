@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// otpJWTMigration is the migration from database version v0.15.0 to v0.16.0.
+type otpJWTMigration struct{}
+
+func (o otpJWTMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.15.0 to v0.16.0. This is the sixteenth database migration. It adds columns to the "mld.otp" table so a validated OTP can return a JWT.`,
+		Filename:    "v0.16.0_otp_jwt.go",
+		SemVer:      "v0.16.0",
+	}
+}
+
+func (o otpJWTMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(o.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.otp
+    ADD COLUMN jwt_claims BYTEA,
+    ADD COLUMN jwt_key_id TEXT
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", o.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "jwt_claims" and "jwt_key_id" columns to "mld.otp" table.`)
+
+	return true, nil
+}
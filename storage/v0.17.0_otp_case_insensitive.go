@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// otpCaseInsensitiveMigration is the migration from database version v0.16.0 to v0.17.0.
+type otpCaseInsensitiveMigration struct{}
+
+func (o otpCaseInsensitiveMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.16.0 to v0.17.0. This is the seventeenth database migration. It adds a column to the "mld.otp" table so an OTP can be validated without regard to case.`,
+		Filename:    "v0.17.0_otp_case_insensitive.go",
+		SemVer:      "v0.17.0",
+	}
+}
+
+func (o otpCaseInsensitiveMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(o.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.otp
+    ADD COLUMN case_insensitive BOOLEAN NOT NULL DEFAULT FALSE
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", o.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "case_insensitive" column to "mld.otp" table.`)
+
+	return true, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// otpResendMigration is the migration from database version v0.17.0 to v0.18.0.
+type otpResendMigration struct{}
+
+func (o otpResendMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.17.0 to v0.18.0. This is the eighteenth database migration. It adds columns to the "mld.otp" table to remember the parameters used to create an OTP, so it can be resent under the same ID with a cooldown and a maximum number of resends.`,
+		Filename:    "v0.18.0_otp_resend.go",
+		SemVer:      "v0.18.0",
+	}
+}
+
+func (o otpResendMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(o.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.otp
+    ADD COLUMN resend       BYTEA,
+    ADD COLUMN resend_count INT                      NOT NULL DEFAULT 0,
+    ADD COLUMN sent         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", o.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "resend", "resend_count", and "sent" columns to "mld.otp" table.`)
+
+	return true, nil
+}
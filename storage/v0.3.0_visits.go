@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// visitsMigration is the migration from database version v0.2.0 to v0.3.0.
+type visitsMigration struct{}
+
+func (v visitsMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.2.0 to v0.3.0. This is the third database migration. It adds columns to the "mld.link" table to support magic links that can be visited more than once.`,
+		Filename:    "v0.3.0_visits.go",
+		SemVer:      "v0.3.0",
+	}
+}
+
+func (v visitsMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(v.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN max_visits INT NOT NULL DEFAULT 1,
+    ADD COLUMN visits     INT NOT NULL DEFAULT 0
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", v.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "max_visits" and "visits" columns to "mld.link" table.`)
+
+	//language=sql
+	query = `
+UPDATE mld.link
+SET visits = 1
+WHERE visited IS NOT NULL
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to update visits for %q query: %w", v.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Set "visits" column for visited magic links in "mld.link" table.`)
+
+	return true, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// revokeMigration is the migration from database version v0.3.0 to v0.4.0.
+type revokeMigration struct{}
+
+func (r revokeMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.3.0 to v0.4.0. This is the fourth database migration. It adds columns to the "mld.link" table for a non-secret magic link identifier and to support revoking magic links.`,
+		Filename:    "v0.4.0_revoke.go",
+		SemVer:      "v0.4.0",
+	}
+}
+
+func (r revokeMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(r.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN id_public UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    ADD COLUMN revoked   TIMESTAMP WITH TIME ZONE
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", r.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "id_public" and "revoked" columns to "mld.link" table.`)
+
+	//language=sql
+	query = `
+ALTER TABLE mld.link
+    ALTER COLUMN id_public DROP DEFAULT
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to drop default for %q query: %w", r.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Dropped default for "id_public" column of "mld.link" table.`)
+
+	indexes := []string{
+		"CREATE INDEX ON mld.link (id_public)",
+		"CREATE INDEX ON mld.link (revoked)",
+	}
+	for _, index := range indexes {
+		_, err = tx.Exec(ctx, index)
+		if err != nil {
+			return false, fmt.Errorf("failed to create index for %q: %q, %w", r.metadata().Filename, index, err)
+		}
+		options.Logger.DebugContext(ctx, fmt.Sprintf(`Created index on "mld.link" table: %q.`, index))
+	}
+
+	return true, nil
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// crossDeviceMigration is the migration from database version v0.4.0 to v0.5.0.
+type crossDeviceMigration struct{}
+
+func (c crossDeviceMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.4.0 to v0.5.0. This is the fifth database migration. It adds columns to the "mld.link" table to deliver JWTs for cross-device magic links to the device that requested them. The device collects the JWT with a secret cross-device token.`,
+		Filename:    "v0.5.0_cross_device.go",
+		SemVer:      "v0.5.0",
+	}
+}
+
+func (c crossDeviceMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(c.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN cross_device           BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN cross_device_jwt       BYTEA,
+    ADD COLUMN cross_device_collected TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN cross_device_token     UUID UNIQUE
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", c.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "cross_device", "cross_device_jwt", "cross_device_collected", and "cross_device_token" columns to "mld.link" table.`)
+
+	return true, nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// responseModeMigration is the migration from database version v0.5.0 to v0.6.0.
+type responseModeMigration struct{}
+
+func (r responseModeMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.5.0 to v0.6.0. This is the sixth database migration. It adds a column to the "mld.link" table to choose how the JWT is delivered to the redirect URL.`,
+		Filename:    "v0.6.0_response_mode.go",
+		SemVer:      "v0.6.0",
+	}
+}
+
+func (r responseModeMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(r.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN response_mode TEXT NOT NULL DEFAULT ''
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", r.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "response_mode" column to "mld.link" table.`)
+
+	return true, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// pkceMigration is the migration from database version v0.6.0 to v0.7.0.
+type pkceMigration struct{}
+
+func (p pkceMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.6.0 to v0.7.0. This is the seventh database migration. It adds columns to the "mld.link" table for a PKCE code challenge and the single-use code exchanged with its code verifier.`,
+		Filename:    "v0.7.0_pkce.go",
+		SemVer:      "v0.7.0",
+	}
+}
+
+func (p pkceMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(p.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN code_challenge TEXT NOT NULL DEFAULT '',
+    ADD COLUMN code           UUID UNIQUE,
+    ADD COLUMN code_expires   TIMESTAMP WITH TIME ZONE
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", p.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "code_challenge", "code", and "code_expires" columns to "mld.link" table.`)
+
+	return true, nil
+}
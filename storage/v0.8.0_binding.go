@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// bindingMigration is the migration from database version v0.7.0 to v0.8.0.
+type bindingMigration struct{}
+
+func (b bindingMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.7.0 to v0.8.0. This is the eighth database migration. It adds columns to the "mld.link" table to bind magic links to a client's IP address and user agent.`,
+		Filename:    "v0.8.0_binding.go",
+		SemVer:      "v0.8.0",
+	}
+}
+
+func (b bindingMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(b.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.link
+    ADD COLUMN binding_ip         TEXT NOT NULL DEFAULT '',
+    ADD COLUMN binding_user_agent TEXT NOT NULL DEFAULT ''
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", b.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "binding_ip" and "binding_user_agent" columns to "mld.link" table.`)
+
+	return true, nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// errorPageMigration is the migration from database version v0.8.0 to v0.9.0.
+type errorPageMigration struct{}
+
+func (e errorPageMigration) metadata() metadata {
+	return metadata{
+		Description: `This migrates the database from version v0.8.0 to v0.9.0. This is the ninth database migration. It adds a column to the "mld.service_account" table for the branding of magic link error pages.`,
+		Filename:    "v0.9.0_error_page.go",
+		SemVer:      "v0.9.0",
+	}
+}
+
+func (e errorPageMigration) migrate(ctx context.Context, setup Setup, tx pgx.Tx, options migrationOptions) (applied bool, err error) {
+	needed, err := migrationNeeded(e.metadata().SemVer, setup.SemVer)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine if migration is needed: %w", err)
+	}
+	if !needed {
+		return false, nil
+	}
+
+	//language=sql
+	query := `
+ALTER TABLE mld.service_account
+    ADD COLUMN error_page JSONB NOT NULL DEFAULT '{}'
+`
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("failed to alter table for %q query: %w", e.metadata().Filename, err)
+	}
+	options.Logger.DebugContext(ctx, `Added "error_page" column to "mld.service_account" table.`)
+
+	return true, nil
+}